@@ -4,6 +4,11 @@ import (
 	"context"
 	"github.com/lastbackend/toolkit/pkg/client/grpc/resolver"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/encoding"
+	"net/url"
+	"strings"
 	"time"
 )
 
@@ -14,13 +19,19 @@ type GRPCClient interface {
 	Conn(pool string) (grpc.ClientConnInterface, error)
 	Call(ctx context.Context, service, method string, req, rsp interface{}, opts ...GRPCCallOption) error
 	Stream(ctx context.Context, service, method string, body interface{}, opts ...GRPCCallOption) (grpc.ClientStream, error)
+	UseInterceptor(interceptors ...grpc.UnaryClientInterceptor)
+	UseStreamInterceptor(interceptors ...grpc.StreamClientInterceptor)
 }
 
 type GRPCCallOption func(*GRPCCallOptions)
 
 type GRPCCallOptions struct {
 	Backoff               GRPCBackoffFunc
-	Retries               time.Duration
+	Retry                 GRPCRetryFunc
+	Retries               int
+	RetryCodes            []codes.Code
+	Idempotent            bool
+	BypassBreaker         bool
 	RequestTimeout        time.Duration
 	Context               context.Context
 	Headers               map[string]string
@@ -28,6 +39,24 @@ type GRPCCallOptions struct {
 	MaxCallRecvMsgSize    int
 	MaxRetryRPCBufferSize int
 	CallContentSubtype    string
+	Interceptors          []grpc.UnaryClientInterceptor
+	StreamInterceptors    []grpc.StreamClientInterceptor
+	Credentials           credentials.PerRPCCredentials
+	Lookup                []resolver.LookupOption
+	Hedging               *GRPCHedging
+	Codec                 encoding.Codec
+	ResolvedOnly          bool
+}
+
+// GRPCHedging - hedging policy of unary call, hedged attempts are sent to different addresses
+// when there is no response after delay, the first successful response is used
+type GRPCHedging struct {
+	// Delay - send hedged attempt after delay, used until enough latencies are tracked for Percentile
+	Delay time.Duration
+	// Percentile - send hedged attempt after the latency percentile of the service calls, e.g. 0.95
+	Percentile float64
+	// MaxAttempts - max number of attempts including the first one (default 2)
+	MaxAttempts int
 }
 
 func GRPCOptionHeaders(h map[string]string) GRPCCallOption {
@@ -54,6 +83,135 @@ func GRPCOptionRequestTimeout(timeout time.Duration) GRPCCallOption {
 	}
 }
 
+// GRPCOptionRetries - set max number of call retries
+func GRPCOptionRetries(retries int) GRPCCallOption {
+	return func(o *GRPCCallOptions) {
+		o.Retries = retries
+	}
+}
+
+// GRPCOptionRetryCodes - set gRPC status codes the call is retried on
+func GRPCOptionRetryCodes(c ...codes.Code) GRPCCallOption {
+	return func(o *GRPCCallOptions) {
+		o.RetryCodes = c
+	}
+}
+
+// GRPCOptionRetryFunc - set func deciding if the call should be retried instead of retry codes
+func GRPCOptionRetryFunc(fn GRPCRetryFunc) GRPCCallOption {
+	return func(o *GRPCCallOptions) {
+		o.Retry = fn
+	}
+}
+
+// GRPCOptionBackoff - set func calculating delay between retries
+func GRPCOptionBackoff(fn GRPCBackoffFunc) GRPCCallOption {
+	return func(o *GRPCCallOptions) {
+		o.Backoff = fn
+	}
+}
+
+// GRPCOptionIdempotent - mark the call as idempotent, idempotent calls are retried on a different address,
+// other calls are retried on the same address
+func GRPCOptionIdempotent() GRPCCallOption {
+	return func(o *GRPCCallOptions) {
+		o.Idempotent = true
+	}
+}
+
+// GRPCOptionHedging - send hedged attempt to a different address if there is no response after delay,
+// only idempotent read-only calls should be hedged
+func GRPCOptionHedging(delay time.Duration) GRPCCallOption {
+	return func(o *GRPCCallOptions) {
+		o.Hedging = &GRPCHedging{Delay: delay}
+	}
+}
+
+// GRPCOptionHedgingPercentile - send hedged attempt to a different address if there is no response
+// after the latency percentile of the service calls, delay is used until enough latencies are tracked
+func GRPCOptionHedgingPercentile(percentile float64, delay time.Duration) GRPCCallOption {
+	return func(o *GRPCCallOptions) {
+		o.Hedging = &GRPCHedging{Delay: delay, Percentile: percentile}
+	}
+}
+
+// GRPCOptionBypassBreaker - send the call regardless of circuit breaker state
+func GRPCOptionBypassBreaker() GRPCCallOption {
+	return func(o *GRPCCallOptions) {
+		o.BypassBreaker = true
+	}
+}
+
+// GRPCOptionCodec - marshal messages of the call with the codec instead of the registered one
+func GRPCOptionCodec(codec encoding.Codec) GRPCCallOption {
+	return func(o *GRPCCallOptions) {
+		o.Codec = codec
+	}
+}
+
+// GRPCOptionResolvedOnly - fail the call with codes.Unimplemented if the service is not resolved,
+// by default such calls are sent to the default port of the local host
+func GRPCOptionResolvedOnly() GRPCCallOption {
+	return func(o *GRPCCallOptions) {
+		o.ResolvedOnly = true
+	}
+}
+
+// GRPCOptionLookup - filter service routes by lookup options, e.g. resolver.WithVersion("v2")
+func GRPCOptionLookup(opts ...resolver.LookupOption) GRPCCallOption {
+	return func(o *GRPCCallOptions) {
+		o.Lookup = append(o.Lookup, opts...)
+	}
+}
+
+// GRPCOptionInterceptors - add unary interceptors applied after client global interceptors
+func GRPCOptionInterceptors(interceptors ...grpc.UnaryClientInterceptor) GRPCCallOption {
+	return func(o *GRPCCallOptions) {
+		o.Interceptors = append(o.Interceptors[:len(o.Interceptors):len(o.Interceptors)], interceptors...)
+	}
+}
+
+// GRPCOptionStreamInterceptors - add stream interceptors applied after client global stream interceptors
+func GRPCOptionStreamInterceptors(interceptors ...grpc.StreamClientInterceptor) GRPCCallOption {
+	return func(o *GRPCCallOptions) {
+		o.StreamInterceptors = append(o.StreamInterceptors[:len(o.StreamInterceptors):len(o.StreamInterceptors)], interceptors...)
+	}
+}
+
+// GRPCOptionPerRPCCredentials - attach credentials to the call
+func GRPCOptionPerRPCCredentials(creds credentials.PerRPCCredentials) GRPCCallOption {
+	return func(o *GRPCCallOptions) {
+		o.Credentials = creds
+	}
+}
+
+// GRPCOptionBearerToken - attach bearer token authorization to the call, requires TLS connection
+func GRPCOptionBearerToken(token string) GRPCCallOption {
+	return GRPCOptionPerRPCCredentials(BearerToken{Token: token})
+}
+
+// BearerToken - per-RPC credentials sending token in authorization header
+type BearerToken struct {
+	Token string
+	// AllowInsecure allows to send token over plaintext connections
+	AllowInsecure bool
+}
+
+func (t BearerToken) GetRequestMetadata(_ context.Context, _ ...string) (map[string]string, error) {
+	if t.Token == "" {
+		return nil, nil
+	}
+	token := t.Token
+	if !strings.HasPrefix(strings.ToLower(token), "bearer ") {
+		token = "Bearer " + token
+	}
+	return map[string]string{"authorization": token}, nil
+}
+
+func (t BearerToken) RequireTransportSecurity() bool {
+	return !t.AllowInsecure
+}
+
 type GRPCBackoffFunc func(ctx context.Context, req *GRPCRequest, attempts int) (time.Duration, error)
 type GRPCRetryFunc func(ctx context.Context, req *GRPCRequest, retryCount int, err error) (bool, error)
 
@@ -93,6 +251,59 @@ func (r *GRPCRequest) Headers() map[string]string {
 }
 
 type HTTPClient interface {
-	Get() error
-	Post() error
+	Get(ctx context.Context, service, path string, rsp interface{}, opts ...HTTPCallOption) error
+	Post(ctx context.Context, service, path string, body, rsp interface{}, opts ...HTTPCallOption) error
+	Put(ctx context.Context, service, path string, body, rsp interface{}, opts ...HTTPCallOption) error
+	Patch(ctx context.Context, service, path string, body, rsp interface{}, opts ...HTTPCallOption) error
+	Delete(ctx context.Context, service, path string, rsp interface{}, opts ...HTTPCallOption) error
+	Do(ctx context.Context, method, service, path string, body, rsp interface{}, opts ...HTTPCallOption) error
+}
+
+type HTTPCallOption func(*HTTPCallOptions)
+
+type HTTPCallOptions struct {
+	Headers        map[string]string
+	Query          url.Values
+	ContentType    string
+	RequestTimeout time.Duration
+	Retries        int
+	Idempotent     bool
+}
+
+func HTTPOptionHeaders(h map[string]string) HTTPCallOption {
+	return func(o *HTTPCallOptions) {
+		o.Headers = h
+	}
+}
+
+func HTTPOptionQuery(q url.Values) HTTPCallOption {
+	return func(o *HTTPCallOptions) {
+		o.Query = q
+	}
+}
+
+func HTTPOptionContentType(contentType string) HTTPCallOption {
+	return func(o *HTTPCallOptions) {
+		o.ContentType = contentType
+	}
+}
+
+func HTTPOptionRequestTimeout(timeout time.Duration) HTTPCallOption {
+	return func(o *HTTPCallOptions) {
+		o.RequestTimeout = timeout
+	}
+}
+
+func HTTPOptionRetries(retries int) HTTPCallOption {
+	return func(o *HTTPCallOptions) {
+		o.Retries = retries
+	}
+}
+
+// HTTPOptionIdempotent - mark the request as idempotent to allow retries of POST and PATCH requests,
+// GET, HEAD, PUT, DELETE and OPTIONS requests are retried by default
+func HTTPOptionIdempotent() HTTPCallOption {
+	return func(o *HTTPCallOptions) {
+		o.Idempotent = true
+	}
 }
@@ -0,0 +1,119 @@
+/*
+Copyright [2014] - [2023] The Last.Backend authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package grpc
+
+import (
+	"context"
+	"errors"
+
+	"github.com/lastbackend/toolkit/pkg/client"
+	"github.com/lastbackend/toolkit/pkg/client/grpc/breaker"
+	"github.com/lastbackend/toolkit/pkg/tools/metrics"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	metricBreakerState = "grpc_client_circuit_breaker_state"
+)
+
+// errCallTimeout - cause of the call context expired by the client request timeout
+var errCallTimeout = errors.New("request timeout exceeded")
+
+// breakerOpenError - call rejected by the open circuit breaker without reaching the address
+type breakerOpenError struct {
+	*status.Status
+}
+
+func (e breakerOpenError) Error() string {
+	return e.Err().Error()
+}
+
+func (e breakerOpenError) GRPCStatus() *status.Status {
+	return e.Status
+}
+
+// guard - call fn if circuit breaker of the address allows it and record the result,
+// the result is not recorded when the caller context is done before the address responded
+func (c *grpcClient) guard(ctx context.Context, service, addr string, opts client.GRPCCallOptions, fn func() error) error {
+	if c.breakers == nil || opts.BypassBreaker {
+		return fn()
+	}
+
+	generation, ok := c.breakers.Allow(service, addr)
+	if !ok {
+		return breakerOpenError{status.Newf(codes.Unavailable, "circuit breaker is open for %s address %s", service, addr)}
+	}
+
+	err := fn()
+	if ctx.Err() != nil && context.Cause(ctx) != errCallTimeout {
+		c.breakers.Cancel(service, addr, generation)
+		return err
+	}
+	c.breakers.Done(service, addr, generation, !isBreakerFailure(err))
+	return err
+}
+
+// isBreakerOpen - check if the call was rejected by the open circuit breaker
+func isBreakerOpen(err error) bool {
+	var e breakerOpenError
+	return errors.As(err, &e)
+}
+
+// isBreakerFailure - check if error means that upstream address is not healthy
+func isBreakerFailure(err error) bool {
+	switch status.Code(err) {
+	case codes.Unavailable, codes.DeadlineExceeded, codes.ResourceExhausted, codes.Internal, codes.Unknown:
+		return true
+	}
+	return false
+}
+
+// breakerStateChanged - log circuit breaker state and expose it through metrics
+func (c *grpcClient) breakerStateChanged(service, addr string, state breaker.State) {
+	c.runtime.Log().V(5).Infof("grpc client: circuit breaker for %s address %s is %s", service, addr, state)
+
+	if g := c.breakerGauge(); g != nil {
+		g.Set(float64(state), service, addr)
+	}
+}
+
+// breakerGauge - get circuit breaker state gauge, metrics are registered lazily
+// because the client is created before runtime tools
+func (c *grpcClient) breakerGauge() metrics.Gauge {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+
+	if c.breakerState != nil {
+		return c.breakerState
+	}
+
+	if c.runtime.Tools() == nil || c.runtime.Tools().Metrics() == nil {
+		return nil
+	}
+
+	g, err := c.runtime.Tools().Metrics().RegisterGauge(metricBreakerState,
+		"State of GRPC client circuit breaker per service address (0 - closed, 1 - open, 2 - half-open).",
+		"service", "address")
+	if err != nil {
+		c.runtime.Log().Errorf("grpc client: can not register circuit breaker metrics: %v", err)
+		return nil
+	}
+
+	c.breakerState = g
+	return g
+}
@@ -0,0 +1,248 @@
+/*
+Copyright [2014] - [2023] The Last.Backend authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package breaker
+
+import (
+	"sync"
+	"time"
+)
+
+type State int
+
+const (
+	StateClosed State = iota
+	StateOpen
+	StateHalfOpen
+)
+
+func (s State) String() string {
+	switch s {
+	case StateOpen:
+		return "open"
+	case StateHalfOpen:
+		return "half-open"
+	default:
+		return "closed"
+	}
+}
+
+type Options struct {
+	// FailureRatio is a ratio of failed requests in the window to open the breaker
+	FailureRatio float64
+	// MinRequests is a minimal number of requests in the window to calculate failure ratio
+	MinRequests int
+	// Window is a duration of requests counting window
+	Window time.Duration
+	// CoolDown is a duration the breaker stays open before half-open
+	CoolDown time.Duration
+	// HalfOpenRequests is a number of probe requests allowed in half-open state
+	HalfOpenRequests int
+}
+
+// StateFunc - called on every breaker state change
+type StateFunc func(service, address string, state State)
+
+// Breakers - circuit breakers keyed by service and address
+type Breakers struct {
+	mtx      sync.Mutex
+	opts     Options
+	items    map[string]*breaker
+	onChange StateFunc
+}
+
+type breaker struct {
+	state      State
+	generation uint64
+	started    time.Time
+	opened     time.Time
+	requests   int
+	failures   int
+	probes     int
+	successes  int
+}
+
+func New(opts Options, onChange StateFunc) *Breakers {
+	if opts.HalfOpenRequests <= 0 {
+		opts.HalfOpenRequests = 1
+	}
+	return &Breakers{
+		opts:     opts,
+		items:    make(map[string]*breaker, 0),
+		onChange: onChange,
+	}
+}
+
+// Available - check if requests can be sent to the address, does not reserve half-open probes
+func (b *Breakers) Available(service, address string) bool {
+	b.mtx.Lock()
+	defer b.mtx.Unlock()
+
+	item, ok := b.items[key(service, address)]
+	if !ok {
+		return true
+	}
+
+	switch item.state {
+	case StateOpen:
+		return time.Since(item.opened) >= b.opts.CoolDown
+	case StateHalfOpen:
+		return item.probes < b.opts.HalfOpenRequests
+	}
+
+	return true
+}
+
+// Allow - check if request can be sent to the address and reserve half-open probe,
+// every allowed request must be finished with Done or Cancel call with the returned generation
+func (b *Breakers) Allow(service, address string) (uint64, bool) {
+	b.mtx.Lock()
+
+	item := b.get(service, address)
+
+	switch item.state {
+	case StateOpen:
+		if time.Since(item.opened) < b.opts.CoolDown {
+			b.mtx.Unlock()
+			return 0, false
+		}
+		item.reset(StateHalfOpen)
+		item.probes = 1
+		generation := item.generation
+		b.mtx.Unlock()
+		b.notify(service, address, StateHalfOpen)
+		return generation, true
+	case StateHalfOpen:
+		if item.probes >= b.opts.HalfOpenRequests {
+			b.mtx.Unlock()
+			return 0, false
+		}
+		item.probes++
+	}
+
+	generation := item.generation
+	b.mtx.Unlock()
+	return generation, true
+}
+
+// Done - record result of the request allowed by Allow call, results of requests
+// allowed before the last state change are ignored
+func (b *Breakers) Done(service, address string, generation uint64, success bool) {
+	b.mtx.Lock()
+
+	var (
+		item    = b.get(service, address)
+		changed = false
+	)
+
+	if item.generation != generation {
+		b.mtx.Unlock()
+		return
+	}
+
+	switch item.state {
+	case StateHalfOpen:
+		// breaker is closed when every probe succeeded and opened on the first failed probe
+		item.probes--
+		if !success {
+			item.reset(StateOpen)
+			changed = true
+			break
+		}
+		item.successes++
+		if item.successes >= b.opts.HalfOpenRequests {
+			item.reset(StateClosed)
+			changed = true
+		}
+	case StateClosed:
+		if time.Since(item.started) > b.opts.Window {
+			item.reset(StateClosed)
+		}
+		item.requests++
+		if !success {
+			item.failures++
+		}
+		if item.requests >= b.opts.MinRequests &&
+			float64(item.failures)/float64(item.requests) >= b.opts.FailureRatio {
+			item.reset(StateOpen)
+			changed = true
+		}
+	}
+
+	state := item.state
+	b.mtx.Unlock()
+
+	if changed {
+		b.notify(service, address, state)
+	}
+}
+
+// Cancel - release the request allowed by Allow call without recording the result
+func (b *Breakers) Cancel(service, address string, generation uint64) {
+	b.mtx.Lock()
+	defer b.mtx.Unlock()
+
+	if item, ok := b.items[key(service, address)]; ok && item.generation == generation &&
+		item.state == StateHalfOpen && item.probes > 0 {
+		item.probes--
+	}
+}
+
+// State - get breaker state of the address
+func (b *Breakers) State(service, address string) State {
+	b.mtx.Lock()
+	defer b.mtx.Unlock()
+	if item, ok := b.items[key(service, address)]; ok {
+		return item.state
+	}
+	return StateClosed
+}
+
+func (b *Breakers) get(service, address string) *breaker {
+	k := key(service, address)
+	item, ok := b.items[k]
+	if !ok {
+		item = &breaker{state: StateClosed, started: time.Now()}
+		b.items[k] = item
+	}
+	return item
+}
+
+func (b *Breakers) notify(service, address string, state State) {
+	if b.onChange != nil {
+		b.onChange(service, address, state)
+	}
+}
+
+// reset - reset counters of the state, state change starts new generation of requests
+func (i *breaker) reset(state State) {
+	if i.state != state {
+		i.generation++
+	}
+	i.state = state
+	i.started = time.Now()
+	i.requests = 0
+	i.failures = 0
+	i.probes = 0
+	i.successes = 0
+	if state == StateOpen {
+		i.opened = i.started
+	}
+}
+
+func key(service, address string) string {
+	return service + "|" + address
+}
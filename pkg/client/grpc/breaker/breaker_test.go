@@ -0,0 +1,244 @@
+package breaker
+
+import (
+	"testing"
+	"time"
+)
+
+const (
+	testService = "test"
+	testAddress = "127.0.0.1:9000"
+)
+
+// result - finished request, cancel releases the request without result
+type result struct {
+	success bool
+	cancel  bool
+}
+
+func TestBreakers_Done(t *testing.T) {
+	tests := []struct {
+		name    string
+		opts    Options
+		results []result
+		state   State
+		changes []State
+	}{
+		{
+			"successful requests keep breaker closed",
+			Options{FailureRatio: 0.5, MinRequests: 2, Window: time.Minute, CoolDown: time.Minute},
+			[]result{{success: true}, {success: true}, {success: true}},
+			StateClosed,
+			nil,
+		},
+		{
+			"failures below min requests",
+			Options{FailureRatio: 0.5, MinRequests: 3, Window: time.Minute, CoolDown: time.Minute},
+			[]result{{success: false}, {success: false}},
+			StateClosed,
+			nil,
+		},
+		{
+			"failure ratio opens breaker",
+			Options{FailureRatio: 0.5, MinRequests: 2, Window: time.Minute, CoolDown: time.Minute},
+			[]result{{success: true}, {success: false}},
+			StateOpen,
+			[]State{StateOpen},
+		},
+		{
+			"window reset forgets failures",
+			Options{FailureRatio: 0.5, MinRequests: 2, Window: 0, CoolDown: time.Minute},
+			[]result{{success: false}, {success: false}},
+			StateClosed,
+			nil,
+		},
+		{
+			"canceled requests are not counted",
+			Options{FailureRatio: 0.5, MinRequests: 2, Window: time.Minute, CoolDown: time.Minute},
+			[]result{{cancel: true}, {cancel: true}, {success: false}},
+			StateClosed,
+			nil,
+		},
+		{
+			"half-open probe success closes breaker",
+			Options{FailureRatio: 0.5, MinRequests: 1, Window: time.Minute, CoolDown: 0},
+			[]result{{success: false}, {success: true}},
+			StateClosed,
+			[]State{StateOpen, StateHalfOpen, StateClosed},
+		},
+		{
+			"half-open probe failure opens breaker",
+			Options{FailureRatio: 0.5, MinRequests: 1, Window: time.Minute, CoolDown: 0},
+			[]result{{success: false}, {success: false}},
+			StateOpen,
+			[]State{StateOpen, StateHalfOpen, StateOpen},
+		},
+		{
+			"canceled half-open probe keeps breaker half-open",
+			Options{FailureRatio: 0.5, MinRequests: 1, Window: time.Minute, CoolDown: 0},
+			[]result{{success: false}, {cancel: true}},
+			StateHalfOpen,
+			[]State{StateOpen, StateHalfOpen},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			changes := make([]State, 0)
+			b := New(tt.opts, func(service, address string, state State) {
+				changes = append(changes, state)
+			})
+
+			for i, r := range tt.results {
+				generation, ok := b.Allow(testService, testAddress)
+				if !ok {
+					t.Fatal("allow: request", i, "rejected")
+				}
+				if r.cancel {
+					b.Cancel(testService, testAddress, generation)
+					continue
+				}
+				b.Done(testService, testAddress, generation, r.success)
+			}
+
+			if state := b.State(testService, testAddress); state != tt.state {
+				t.Error("state: expected", tt.state, "received", state)
+			}
+
+			if len(changes) != len(tt.changes) {
+				t.Fatal("changes: expected", tt.changes, "received", changes)
+			}
+			for i := range changes {
+				if changes[i] != tt.changes[i] {
+					t.Error("changes: expected", tt.changes, "received", changes)
+					break
+				}
+			}
+		})
+	}
+}
+
+func TestBreakers_Allow(t *testing.T) {
+	tests := []struct {
+		name      string
+		opts      Options
+		open      bool
+		probes    int
+		available bool
+		allowed   []bool
+	}{
+		{
+			"closed breaker",
+			Options{FailureRatio: 0.5, MinRequests: 1, Window: time.Minute, CoolDown: time.Minute},
+			false,
+			0,
+			true,
+			[]bool{true, true, true},
+		},
+		{
+			"open breaker in cool down",
+			Options{FailureRatio: 0.5, MinRequests: 1, Window: time.Minute, CoolDown: time.Minute},
+			true,
+			0,
+			false,
+			[]bool{false, false},
+		},
+		{
+			"single half-open probe",
+			Options{FailureRatio: 0.5, MinRequests: 1, Window: time.Minute, CoolDown: 0},
+			true,
+			0,
+			true,
+			[]bool{true, false, false},
+		},
+		{
+			"multiple half-open probes",
+			Options{FailureRatio: 0.5, MinRequests: 1, Window: time.Minute, CoolDown: 0, HalfOpenRequests: 2},
+			true,
+			0,
+			true,
+			[]bool{true, true, false},
+		},
+		{
+			"released half-open probe",
+			Options{FailureRatio: 0.5, MinRequests: 1, Window: time.Minute, CoolDown: 0},
+			true,
+			1,
+			true,
+			[]bool{true},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := New(tt.opts, nil)
+
+			if tt.open {
+				generation, _ := b.Allow(testService, testAddress)
+				b.Done(testService, testAddress, generation, false)
+			}
+
+			for i := 0; i < tt.probes; i++ {
+				generation, _ := b.Allow(testService, testAddress)
+				b.Cancel(testService, testAddress, generation)
+			}
+
+			if available := b.Available(testService, testAddress); available != tt.available {
+				t.Error("available: expected", tt.available, "received", available)
+			}
+
+			for i, expected := range tt.allowed {
+				if _, allowed := b.Allow(testService, testAddress); allowed != expected {
+					t.Error("allow", i, ": expected", expected, "received", allowed)
+				}
+			}
+		})
+	}
+}
+
+func TestBreakers_HalfOpen(t *testing.T) {
+	tests := []struct {
+		name string
+		// results of probes allowed at once in half-open state
+		probes []bool
+		// success of the request allowed before the breaker opened is reported after probes
+		stale bool
+		state State
+	}{
+		{"every probe succeeded", []bool{true, true, true}, false, StateClosed},
+		{"first probe success waits for other probes", []bool{true}, false, StateHalfOpen},
+		{"last probe failed", []bool{true, true, false}, false, StateOpen},
+		{"results after failed probe ignored", []bool{false, true, true}, false, StateOpen},
+		{"stale result of request before open ignored", []bool{true}, true, StateHalfOpen},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := New(Options{FailureRatio: 0.5, MinRequests: 1, Window: time.Minute, CoolDown: 0, HalfOpenRequests: 3}, nil)
+
+			stale, _ := b.Allow(testService, testAddress)
+			generation, _ := b.Allow(testService, testAddress)
+			b.Done(testService, testAddress, generation, false)
+
+			probes := make([]uint64, 0, 3)
+			for i := 0; i < 3; i++ {
+				generation, ok := b.Allow(testService, testAddress)
+				if !ok {
+					t.Fatal("allow: probe", i, "rejected")
+				}
+				probes = append(probes, generation)
+			}
+
+			for i, success := range tt.probes {
+				b.Done(testService, testAddress, probes[i], success)
+			}
+			if tt.stale {
+				b.Done(testService, testAddress, stale, true)
+			}
+
+			if state := b.State(testService, testAddress); state != tt.state {
+				t.Error("state: expected", tt.state, "received", state)
+			}
+		})
+	}
+}
@@ -0,0 +1,100 @@
+package grpc
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/lastbackend/toolkit/pkg/client"
+	"github.com/lastbackend/toolkit/pkg/client/grpc/breaker"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestGrpcClient_Guard(t *testing.T) {
+	tests := []struct {
+		name   string
+		handle func(ctx context.Context) error
+		ctx    func() (context.Context, context.CancelFunc)
+		opts   []client.GRPCCallOption
+		code   codes.Code
+		state  breaker.State
+	}{
+		{
+			"successful call",
+			nil,
+			func() (context.Context, context.CancelFunc) { return context.WithCancel(context.Background()) },
+			nil,
+			codes.OK,
+			breaker.StateClosed,
+		},
+		{
+			"upstream unavailable",
+			func(ctx context.Context) error { return status.Error(codes.Unavailable, "unavailable") },
+			func() (context.Context, context.CancelFunc) { return context.WithCancel(context.Background()) },
+			nil,
+			codes.Unavailable,
+			breaker.StateOpen,
+		},
+		{
+			"upstream business error",
+			func(ctx context.Context) error { return status.Error(codes.NotFound, "not found") },
+			func() (context.Context, context.CancelFunc) { return context.WithCancel(context.Background()) },
+			nil,
+			codes.NotFound,
+			breaker.StateClosed,
+		},
+		{
+			"request timeout exceeded",
+			func(ctx context.Context) error { <-ctx.Done(); return ctx.Err() },
+			func() (context.Context, context.CancelFunc) { return context.WithCancel(context.Background()) },
+			[]client.GRPCCallOption{client.GRPCOptionRequestTimeout(50 * time.Millisecond)},
+			codes.DeadlineExceeded,
+			breaker.StateOpen,
+		},
+		{
+			"caller deadline exceeded",
+			func(ctx context.Context) error { <-ctx.Done(); return ctx.Err() },
+			func() (context.Context, context.CancelFunc) {
+				return context.WithTimeout(context.Background(), 50*time.Millisecond)
+			},
+			nil,
+			codes.DeadlineExceeded,
+			breaker.StateClosed,
+		},
+		{
+			"caller canceled",
+			func(ctx context.Context) error { <-ctx.Done(); return ctx.Err() },
+			func() (context.Context, context.CancelFunc) {
+				ctx, cancel := context.WithCancel(context.Background())
+				time.AfterFunc(50*time.Millisecond, cancel)
+				return ctx, cancel
+			},
+			nil,
+			codes.Canceled,
+			breaker.StateClosed,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newTestServer(t, tt.handle)
+			c := newTestClient(t, map[string]string{
+				"GRPC_CLIENT_BREAKER_ENABLED":      "true",
+				"GRPC_CLIENT_BREAKER_MIN_REQUESTS": "1",
+			}, s)
+
+			ctx, cancel := tt.ctx()
+			defer cancel()
+
+			err := check(ctx, c, tt.opts...)
+			if status.Code(err) != tt.code {
+				t.Error("code: expected", tt.code, "received", status.Code(err))
+			}
+
+			if state := c.breakers.State(testService, s.addr); state != tt.state {
+				t.Error("breaker state: expected", tt.state, "received", state)
+			}
+		})
+	}
+}
@@ -0,0 +1,134 @@
+/*
+Copyright [2014] - [2023] The Last.Backend authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package grpc
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/lastbackend/toolkit/pkg/client/grpc/resolver"
+	"github.com/lastbackend/toolkit/pkg/client/grpc/resolver/route"
+)
+
+// routeCache - service routes cache, entries are invalidated by resolver watch events
+// and expire after resolver TTL if resolver notices changes only on lookup
+type routeCache struct {
+	mtx      sync.Mutex
+	routes   map[string]*cachedRoutes
+	versions map[string]uint64
+	watchers map[string]resolver.Watcher
+}
+
+// cachedRoutes - cached service routes, zero expires means routes are valid until resolver event
+type cachedRoutes struct {
+	routes  route.List
+	expires time.Time
+}
+
+func newRouteCache() *routeCache {
+	return &routeCache{
+		routes:   make(map[string]*cachedRoutes, 0),
+		versions: make(map[string]uint64, 0),
+		watchers: make(map[string]resolver.Watcher, 0),
+	}
+}
+
+// resolve - get service routes from cache or lookup them with resolver,
+// routes are cached only if resolver supports watching the service
+func (c *grpcClient) resolve(service string) (route.List, error) {
+	r := c.getResolver()
+
+	c.cache.mtx.Lock()
+	if e, ok := c.cache.routes[service]; ok && (e.expires.IsZero() || time.Now().Before(e.expires)) {
+		c.cache.mtx.Unlock()
+		return e.routes, nil
+	}
+
+	watched := c.cache.watchers[service] != nil
+	if !watched {
+		if w, err := r.Watch(service); err == nil && w != nil {
+			c.cache.watchers[service] = w
+			watched = true
+			go c.watch(service, w)
+		}
+	}
+	version := c.cache.versions[service]
+	c.cache.mtx.Unlock()
+
+	routes, err := r.Lookup(service)
+	if err == nil {
+		c.prune(service, routes)
+	}
+	if err != nil || !watched {
+		return routes, err
+	}
+
+	e := &cachedRoutes{routes: routes}
+	// resolver with TTL emits events only when routes are looked up again, e.g. DNS resolver
+	if t, ok := r.(interface{ TTL() time.Duration }); ok && t.TTL() > 0 {
+		e.expires = time.Now().Add(t.TTL())
+	}
+
+	c.cache.mtx.Lock()
+	// skip routes which could be changed during lookup
+	if c.cache.versions[service] == version {
+		c.cache.routes[service] = e
+	}
+	c.cache.mtx.Unlock()
+
+	return routes, nil
+}
+
+// watch - invalidate cached service routes on every resolver event
+func (c *grpcClient) watch(service string, w resolver.Watcher) {
+	stop := context.AfterFunc(c.ctx, w.Stop)
+	defer stop()
+
+	for {
+		e, err := w.Next()
+		if err != nil {
+			c.cache.mtx.Lock()
+			if c.cache.watchers[service] == w {
+				delete(c.cache.watchers, service)
+				delete(c.cache.routes, service)
+			}
+			c.cache.mtx.Unlock()
+			return
+		}
+
+		c.runtime.Log().V(7).Infof("grpc client: resolver %s event for %s address %s", e.Type, service, e.Route.Address)
+
+		c.cache.mtx.Lock()
+		c.cache.versions[service]++
+		delete(c.cache.routes, service)
+		c.cache.mtx.Unlock()
+	}
+}
+
+// reset - drop cached routes and stop watchers
+func (rc *routeCache) reset() {
+	rc.mtx.Lock()
+	defer rc.mtx.Unlock()
+
+	for service, w := range rc.watchers {
+		w.Stop()
+		rc.versions[service]++
+		delete(rc.watchers, service)
+	}
+	rc.routes = make(map[string]*cachedRoutes, 0)
+}
@@ -0,0 +1,216 @@
+package grpc
+
+import (
+	"errors"
+	"net"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/lastbackend/toolkit/pkg/client/grpc/resolver"
+	"github.com/lastbackend/toolkit/pkg/client/grpc/resolver/dns"
+	"github.com/lastbackend/toolkit/pkg/client/grpc/resolver/route"
+	"golang.org/x/net/dns/dnsmessage"
+)
+
+// watchResolver - resolver with watch support counting lookups
+type watchResolver struct {
+	resolver.Resolver
+	watchers *resolver.Watchers
+	watch    bool
+	lookups  int32
+}
+
+func (r *watchResolver) Lookup(service string, _ ...resolver.LookupOption) (route.List, error) {
+	atomic.AddInt32(&r.lookups, 1)
+	return route.List{{Service: service, Address: "127.0.0.1:9000"}}, nil
+}
+
+func (r *watchResolver) Watch(service string) (resolver.Watcher, error) {
+	if !r.watch {
+		return nil, errors.New("not supported")
+	}
+	return r.watchers.Watch(service), nil
+}
+
+// ttlResolver - resolver noticing route changes only on lookup
+type ttlResolver struct {
+	*watchResolver
+	ttl time.Duration
+}
+
+func (r *ttlResolver) TTL() time.Duration {
+	return r.ttl
+}
+
+func TestGrpcClient_Resolve(t *testing.T) {
+	tests := []struct {
+		name    string
+		watch   bool
+		ttl     time.Duration
+		change  func(r *watchResolver)
+		lookups int
+	}{
+		{
+			"routes cached until resolver event",
+			true, 0,
+			func(*watchResolver) { time.Sleep(50 * time.Millisecond) },
+			1,
+		},
+		{
+			"resolver event invalidates cached routes",
+			true, 0,
+			func(r *watchResolver) {
+				r.watchers.Emit(resolver.EventAdd, route.Route{Service: testService, Address: "127.0.0.1:9001"})
+				time.Sleep(50 * time.Millisecond)
+			},
+			2,
+		},
+		{
+			"routes cached within resolver ttl",
+			true, time.Hour,
+			func(*watchResolver) { time.Sleep(50 * time.Millisecond) },
+			1,
+		},
+		{
+			"routes expire after resolver ttl",
+			true, 20 * time.Millisecond,
+			func(*watchResolver) { time.Sleep(50 * time.Millisecond) },
+			2,
+		},
+		{
+			"routes not cached without watch",
+			false, 0,
+			func(*watchResolver) {},
+			3,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := newTestClient(t, nil)
+
+			wr := &watchResolver{watchers: resolver.NewWatchers(), watch: tt.watch}
+			if tt.ttl > 0 {
+				c.SetResolver(&ttlResolver{watchResolver: wr, ttl: tt.ttl})
+			} else {
+				c.SetResolver(wr)
+			}
+
+			if _, err := c.resolve(testService); err != nil {
+				t.Fatal(err)
+			}
+			tt.change(wr)
+			for i := 0; i < 2; i++ {
+				if _, err := c.resolve(testService); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			if lookups := int(atomic.LoadInt32(&wr.lookups)); lookups != tt.lookups {
+				t.Error("lookups: expected", tt.lookups, "received", lookups)
+			}
+		})
+	}
+}
+
+// newTestDNSServer - in-process DNS server answering A queries from the records
+func newTestDNSServer(t *testing.T, records *sync.Map) string {
+	t.Helper()
+
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = conn.Close() })
+
+	go func() {
+		for {
+			buf := make([]byte, 1500)
+			n, addr, err := conn.ReadFrom(buf)
+			if err != nil {
+				return
+			}
+
+			var p dnsmessage.Parser
+			h, err := p.Start(buf[:n])
+			if err != nil {
+				continue
+			}
+			q, err := p.Question()
+			if err != nil {
+				continue
+			}
+
+			ip, ok := records.Load(q.Name.String())
+
+			rcode := dnsmessage.RCodeSuccess
+			if !ok {
+				rcode = dnsmessage.RCodeNameError
+			}
+
+			b := dnsmessage.NewBuilder(nil, dnsmessage.Header{ID: h.ID, Response: true, Authoritative: true, RCode: rcode})
+			_ = b.StartQuestions()
+			_ = b.Question(q)
+			_ = b.StartAnswers()
+			if ok && q.Type == dnsmessage.TypeA {
+				var a [4]byte
+				copy(a[:], net.ParseIP(ip.(string)).To4())
+				_ = b.AResource(dnsmessage.ResourceHeader{Name: q.Name, Class: dnsmessage.ClassINET, TTL: 60}, dnsmessage.AResource{A: a})
+			}
+			if out, err := b.Finish(); err == nil {
+				_, _ = conn.WriteTo(out, addr)
+			}
+		}
+	}()
+
+	return conn.LocalAddr().String()
+}
+
+func TestGrpcClient_ResolveDNS(t *testing.T) {
+	const name = testService + ".svc.local."
+
+	tests := []struct {
+		name    string
+		ttl     string
+		address string
+	}{
+		{"changed record used after dns ttl", "20ms", "10.0.0.2:9000"},
+		{"cached record used within dns ttl", "1h", "10.0.0.1:9000"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			records := new(sync.Map)
+			records.Store(name, "10.0.0.1")
+
+			c := newTestClient(t, nil)
+			// resolver is not started, routes are refreshed only on lookup
+			c.SetResolver(dns.NewResolver(testRuntime{environment: map[string]string{
+				"RESOLVER_DNS_SERVER":   newTestDNSServer(t, records),
+				"RESOLVER_DNS_TEMPLATE": "{service}.svc.local.",
+				"RESOLVER_DNS_TTL":      tt.ttl,
+			}}))
+
+			// routes added by the first lookup invalidate the cache, the next lookup caches them
+			for i := 0; i < 2; i++ {
+				if _, err := c.resolve(testService); err != nil {
+					t.Fatal(err)
+				}
+				time.Sleep(50 * time.Millisecond)
+			}
+
+			records.Store(name, "10.0.0.2")
+			time.Sleep(50 * time.Millisecond)
+
+			routes, err := c.resolve(testService)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(routes) != 1 || routes[0].Address != tt.address {
+				t.Error("routes: expected", tt.address, "received", routes)
+			}
+		})
+	}
+}
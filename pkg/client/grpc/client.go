@@ -18,20 +18,31 @@ package grpc
 
 import (
 	"context"
+	"crypto/tls"
 	"fmt"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/lastbackend/toolkit/pkg/client"
+	"github.com/lastbackend/toolkit/pkg/client/grpc/breaker"
+	"github.com/lastbackend/toolkit/pkg/client/grpc/limiter"
 	"github.com/lastbackend/toolkit/pkg/client/grpc/resolver"
+	"github.com/lastbackend/toolkit/pkg/client/grpc/resolver/dns"
+	"github.com/lastbackend/toolkit/pkg/client/grpc/resolver/endpoints"
 	"github.com/lastbackend/toolkit/pkg/client/grpc/resolver/file"
 	"github.com/lastbackend/toolkit/pkg/client/grpc/resolver/local"
+	"github.com/lastbackend/toolkit/pkg/client/grpc/resolver/route"
+	"github.com/lastbackend/toolkit/pkg/client/grpc/routing"
+	"github.com/lastbackend/toolkit/pkg/client/grpc/selector"
+	"github.com/lastbackend/toolkit/pkg/context/deadline"
 	"github.com/lastbackend/toolkit/pkg/context/metadata"
 	"github.com/lastbackend/toolkit/pkg/runtime"
-	"github.com/lastbackend/toolkit/pkg/util/backoff"
+	"github.com/lastbackend/toolkit/pkg/tools/metrics"
+	"github.com/lastbackend/toolkit/pkg/tools/traces"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
-	"google.golang.org/grpc/credentials/insecure"
 	"google.golang.org/grpc/encoding"
 	grpc_md "google.golang.org/grpc/metadata"
 	"google.golang.org/grpc/status"
@@ -44,18 +55,29 @@ func init() {
 const (
 	// default prefix
 	defaultPrefix = "GRPC_CLIENT"
-	// default pool name
-	defaultPoolName = ""
 	// default GRPC port
 	defaultPort = 9000
-	// The default number of times a request is tried
-	defaultRetries = 0 * time.Second
+	// The default number of times a request is retried
+	defaultRetries = 0
 	// The default request timeout
 	defaultRequestTimeout = 15 * time.Second
-	// The connection pool size
+	// The default margin subtracted from remaining deadline of the incoming request
+	defaultDeadlineMargin = 5 * time.Millisecond
+	// The default max fraction of hedged calls
+	defaultHedgingMaxRatio = 0.1
+	// The default delays between retries
+	defaultBackoffMin = 100 * time.Millisecond
+	defaultBackoffMax = 5 * time.Second
+	// The connection pool size per address
 	defaultPoolSize = 100
 	// The connection pool ttl
 	defaultPoolTTL = time.Minute
+	// The max number of concurrent streams per pooled connection
+	defaultPoolMaxStreams = 100
+	// The max number of idle connections per address
+	defaultPoolMaxIdle = 10
+	// The idle connection timeout
+	defaultPoolIdleTimeout = 5 * time.Minute
 	// DefaultMaxRecvMsgSize maximum message that client can receive (16 MB).
 	defaultMaxRecvMsgSize = 1024 * 1024 * 16
 	// DefaultMaxSendMsgSize maximum message that client can send (16 MB).
@@ -63,25 +85,96 @@ const (
 )
 
 type grpcClient struct {
+	mtx sync.RWMutex
+
 	ctx      context.Context
 	runtime  runtime.Runtime
 	resolver resolver.Resolver
 
 	opts Options
-	pool map[string]*pool
+	pool *pool
+
+	interceptors       []grpc.UnaryClientInterceptor
+	streamInterceptors []grpc.StreamClientInterceptor
+
+	tls    *tls.Config
+	cache  *routeCache
+	router *routing.Router
+
+	breakers     *breaker.Breakers
+	breakerState metrics.Gauge
+	poolMetrics  atomic.Bool // pool metrics are registered
+
+	hedging     *hedging
+	hedgedCalls metrics.Counter
+
+	limiters map[string]*limiter.Limiter
 }
 
-func NewClient(ctx context.Context, runtime runtime.Runtime) client.GRPCClient {
+func NewClient(ctx context.Context, runtime runtime.Runtime) (client.GRPCClient, error) {
 
 	client := &grpcClient{
 		ctx:     ctx,
 		runtime: runtime,
 		opts:    defaultOptions(),
-		pool:    make(map[string]*pool, 0),
+		cache:   newRouteCache(),
+	}
+
+	if err := runtime.Config().Parse(&client.opts, defaultPrefix); err != nil {
+		return nil, fmt.Errorf("can not parse config %s: %v", defaultPrefix, err)
+	}
+
+	t, err := selector.Parse(client.opts.SelectorType)
+	if err != nil {
+		return nil, fmt.Errorf("can not parse config %s: selector %q: %v", defaultPrefix, client.opts.SelectorType, err)
+	}
+	client.opts.Selector, _ = selector.New(t)
+
+	retryCodes, err := parseCodes(client.opts.RetryCodes)
+	if err != nil {
+		return nil, fmt.Errorf("can not parse config %s: %v", defaultPrefix, err)
 	}
+	client.opts.CallOptions.Retries = client.opts.Retries
+	client.opts.CallOptions.RequestTimeout = client.opts.RequestTimeout
+	client.opts.CallOptions.Backoff = newBackoff(client.opts.BackoffMin, client.opts.BackoffMax)
+	client.opts.CallOptions.RetryCodes = retryCodes
 
-	client.pool[defaultPoolName] = newPool()
-	runtime.Config().Parse(&client.opts, defaultPrefix)
+	if client.opts.TLS.Enabled || len(client.opts.TLS.Services) > 0 {
+		cfg, err := newTLSConfig(client.opts.TLS)
+		if err != nil {
+			return nil, fmt.Errorf("can not create tls config %s: %v", defaultPrefix, err)
+		}
+		client.tls = cfg
+	}
+
+	limiters, err := newLimiters(client.opts.Limits)
+	if err != nil {
+		return nil, fmt.Errorf("can not parse config %s: limits: %v", defaultPrefix, err)
+	}
+	client.limiters = limiters
+
+	if client.opts.Routing.Rules != "" || client.opts.Routing.RulesFile != "" {
+		rules, err := newRoutingRules(client.opts.Routing)
+		if err != nil {
+			return nil, fmt.Errorf("can not parse config %s: routing rules: %v", defaultPrefix, err)
+		}
+		client.router = routing.New(rules)
+	}
+
+	if client.opts.Breaker.Enabled {
+		client.breakers = breaker.New(breaker.Options{
+			FailureRatio:     client.opts.Breaker.FailureRatio,
+			MinRequests:      client.opts.Breaker.MinRequests,
+			Window:           client.opts.Breaker.Window,
+			CoolDown:         client.opts.Breaker.CoolDown,
+			HalfOpenRequests: client.opts.Breaker.HalfOpenRequests,
+		}, client.breakerStateChanged)
+	}
+
+	// pool is created when the config is valid, pool is closed with the client context
+	client.pool = newPool(client.opts.Pool)
+	context.AfterFunc(ctx, client.pool.Close)
+	client.hedging = newHedging(client.opts.HedgingMaxRatio)
 
 	if client.opts.Resolver == "local" {
 		client.resolver = local.NewResolver(runtime)
@@ -91,35 +184,85 @@ func NewClient(ctx context.Context, runtime runtime.Runtime) client.GRPCClient {
 		client.resolver = file.NewResolver(runtime)
 	}
 
-	return client
+	if client.opts.Resolver == "dns" {
+		client.resolver = dns.NewResolver(runtime)
+	}
+
+	if client.opts.Resolver == "endpoints" {
+		client.resolver = endpoints.NewResolver(runtime)
+	}
+
+	return client, nil
 }
 
 func (c *grpcClient) Conn(service string) (grpc.ClientConnInterface, error) {
-	var p *pool
-	p, ok := c.pool[service]
-	if !ok {
-		p = newPool()
-		c.pool[service] = p
+	return &serviceConn{client: c, service: service}, nil
+}
 
-	}
+// lookup - resolve service routes and get selector over addresses available for the call
+func (c *grpcClient) lookup(service string, headers map[string]string, opts client.GRPCCallOptions) (selector.Next, int, error) {
 
-	routes, err := c.getResolver().Lookup(service)
+	routes, err := c.resolve(service)
 	if err != nil && !strings.HasSuffix(err.Error(), "route not found") {
-		return nil, status.Error(codes.Unavailable, err.Error())
+		return nil, 0, status.Error(codes.Unavailable, err.Error())
+	}
+
+	if len(routes) == 0 {
+		if opts.ResolvedOnly {
+			return nil, 0, status.Errorf(codes.Unimplemented, "unknown service %s", service)
+		}
+		routes = route.List{{Service: service, Address: fmt.Sprintf(":%d", defaultPort)}}
+	}
+
+	if len(opts.Lookup) > 0 {
+		routes = resolver.Filter(routes, resolver.NewLookup(opts.Lookup...))
+		if len(routes) == 0 {
+			return nil, 0, status.Errorf(codes.Unavailable, "no %s addresses match lookup options", service)
+		}
+	}
+
+	if c.router != nil {
+		routes = c.router.Route(service, routes, headers, headers[c.opts.SelectorHashHeader])
 	}
 
-	addresses := routes.Addresses()
-	if len(addresses) == 0 {
-		addresses = []string{fmt.Sprintf(":%d", defaultPort)}
+	if c.breakers != nil && !opts.BypassBreaker {
+		available := make(route.List, 0, len(routes))
+		for _, r := range routes {
+			if c.breakers.Available(service, r.Address) {
+				available = append(available, r)
+			}
+		}
+		if len(available) == 0 {
+			return nil, 0, status.Errorf(codes.Unavailable, "circuit breaker is open for all %s addresses", service)
+		}
+		routes = available
 	}
 
-	next, err := c.opts.Selector.Select(addresses)
+	next, err := c.opts.Selector.Select(service, routes, selector.WithKey(headers[c.opts.SelectorHashHeader]))
 	if err != nil {
-		return nil, err
+		return nil, 0, err
 	}
 
-	return p.getConn(c.ctx, next(), c.makeGrpcDialOptions()...)
+	return next, len(routes), nil
+}
 
+// track - count outstanding call to the address if selector tracks them, returned func finishes the call
+func (c *grpcClient) track(service, addr string) func() {
+	t, ok := c.opts.Selector.(selector.Tracker)
+	if !ok {
+		return func() {}
+	}
+	t.Start(service, addr)
+	return func() {
+		t.Done(service, addr)
+	}
+}
+
+// prune - remove selector state of addresses which are not resolved anymore
+func (c *grpcClient) prune(service string, routes route.List) {
+	if p, ok := c.opts.Selector.(selector.Pruner); ok {
+		p.Prune(service, routes)
+	}
 }
 
 func (c *grpcClient) GetResolver() resolver.Resolver {
@@ -128,9 +271,10 @@ func (c *grpcClient) GetResolver() resolver.Resolver {
 
 func (c *grpcClient) SetResolver(resolver resolver.Resolver) {
 	c.resolver = resolver
+	c.cache.reset()
 }
 
-func (c *grpcClient) Call(ctx context.Context, service, method string, body, resp interface{}, opts ...client.GRPCCallOption) error {
+func (c *grpcClient) Call(ctx context.Context, service, method string, body, resp interface{}, opts ...client.GRPCCallOption) (err error) {
 	if body == nil {
 		return status.Error(codes.Internal, "request is nil")
 	}
@@ -143,125 +287,125 @@ func (c *grpcClient) Call(ctx context.Context, service, method string, body, res
 		opt(&callOpts)
 	}
 
-	ctx, cancel := context.WithTimeout(ctx, callOpts.RequestTimeout)
-	defer cancel()
+	timeout, err := deadline.Budget(ctx, callOpts.RequestTimeout, c.opts.DeadlineMargin)
+	if err != nil {
+		return status.Error(codes.DeadlineExceeded, err.Error())
+	}
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		if timeout == callOpts.RequestTimeout {
+			ctx, cancel = context.WithTimeoutCause(ctx, timeout, errCallTimeout)
+		} else {
+			ctx, cancel = context.WithTimeout(ctx, timeout)
+		}
+		defer cancel()
+	}
+
+	ctx, finish := c.startSpan(ctx, service, method)
+	defer func() {
+		finish(err)
+	}()
 
 	headers := c.makeHeaders(ctx, service, callOpts)
+	c.authorize(ctx, service, headers, &callOpts)
 	req := client.NewGRPCRequest(service, method, body, headers)
 
-	routes, err := c.getResolver().Lookup(req.Service())
-	if err != nil && !strings.HasSuffix(err.Error(), "route not found") {
-		return status.Error(codes.Unavailable, err.Error())
-	}
-
-	addresses := routes.Addresses()
-	if len(addresses) == 0 {
-		addresses = []string{fmt.Sprintf(":%d", defaultPort)}
-	}
-
-	next, err := c.opts.Selector.Select(addresses)
+	next, count, err := c.lookup(req.Service(), req.Headers(), callOpts)
 	if err != nil {
 		return err
 	}
 
-	invokeFunc := c.invoke
-
-	b := &backoff.Backoff{
-		Max: callOpts.Retries,
-	}
-
-	for {
-		select {
-		case <-ctx.Done():
-			return status.Error(codes.Canceled, ctx.Err().Error())
-		default:
-			err := invokeFunc(ctx, next(), req, resp, callOpts)
-			if err != nil {
-				d := b.Duration()
-				if d.Seconds() >= callOpts.Retries.Seconds() {
-					return err
-				}
-				time.Sleep(d)
-				continue
-			}
-			b.Reset()
-			return nil
+	return c.retry(ctx, req, next, count, callOpts, func(addr string) error {
+		if callOpts.Hedging != nil {
+			return c.hedge(ctx, req, addr, next, count, resp, callOpts)
 		}
-	}
-
+		return c.limit(ctx, service, func() error {
+			return c.guard(ctx, service, addr, callOpts, func() error {
+				defer c.track(service, addr)()
+				return c.invoke(ctx, addr, req, resp, callOpts)
+			})
+		})
+	})
 }
 
-func (c *grpcClient) Stream(ctx context.Context, service, method string, body interface{}, opts ...client.GRPCCallOption) (grpc.ClientStream, error) {
+func (c *grpcClient) Stream(ctx context.Context, service, method string, body interface{}, opts ...client.GRPCCallOption) (_ grpc.ClientStream, err error) {
 
 	callOpts := c.opts.CallOptions
 	for _, opt := range opts {
 		opt(&callOpts)
 	}
 
-	streamFunc := c.stream
+	// stream lifetime is not limited, but streams are not opened with exhausted budget
+	if _, err := deadline.Budget(ctx, 0, c.opts.DeadlineMargin); err != nil {
+		return nil, status.Error(codes.DeadlineExceeded, err.Error())
+	}
+
+	ctx, finish := c.startSpan(ctx, service, method)
+	defer func() {
+		if err != nil {
+			finish(err)
+		}
+	}()
 
 	headers := c.makeHeaders(ctx, service, callOpts)
+	c.authorize(ctx, service, headers, &callOpts)
 	req := client.NewGRPCRequest(service, method, body, headers)
 
-	routes, err := c.getResolver().Lookup(req.Service())
-	if err != nil && !strings.HasSuffix(err.Error(), "route not found") {
-		return nil, status.Error(codes.Unavailable, err.Error())
+	next, count, err := c.lookup(req.Service(), req.Headers(), callOpts)
+	if err != nil {
+		return nil, err
 	}
 
-	addresses := routes.Addresses()
-	if len(addresses) == 0 {
-		addresses = []string{fmt.Sprintf(":%d", defaultPort)}
-	}
+	var s grpc.ClientStream
 
-	next, err := c.opts.Selector.Select(addresses)
+	err = c.retry(ctx, req, next, count, callOpts, func(addr string) error {
+		return c.limitStream(ctx, service, func() error {
+			return c.guard(ctx, service, addr, callOpts, func() (err error) {
+				defer c.track(service, addr)()
+				s, err = c.stream(ctx, addr, req, callOpts)
+				return err
+			})
+		})
+	})
 	if err != nil {
 		return nil, err
 	}
 
-	b := &backoff.Backoff{
-		Max: callOpts.Retries,
-	}
-
-	for {
-		select {
-		case <-ctx.Done():
-			return nil, status.Error(codes.Canceled, ctx.Err().Error())
-		default:
-			s, err := streamFunc(ctx, next(), req, callOpts)
-			if err != nil {
-				d := b.Duration()
-				if d.Seconds() >= callOpts.Retries.Seconds() {
-					return nil, err
-				}
-				time.Sleep(d)
-				continue
-			}
-			b.Reset()
-			return s, nil
+	if st, ok := s.(*stream); ok {
+		release := st.finish
+		st.finish = func(err error) {
+			release(err)
+			finish(err)
 		}
+	} else {
+		finish(nil)
 	}
+
+	return s, nil
 }
 
 func (c *grpcClient) invoke(ctx context.Context, addr string, req *client.GRPCRequest, rsp interface{}, opts client.GRPCCallOptions) error {
 
-	md := grpc_md.New(req.Headers())
-	ctx = grpc_md.NewOutgoingContext(ctx, md)
+	ctx = c.outgoingContext(ctx, req.Headers())
 
 	var headers grpc_md.MD
 
 	var gErr error
-	conn, err := c.pool[defaultPoolName].getConn(ctx, addr, c.makeGrpcDialOptions()...)
+	conn, err := c.getConn(ctx, req.Service(), addr)
 	if err != nil {
 		return status.Error(codes.Internal, fmt.Sprintf("Failed sending request: %v", err))
 	}
-	defer conn.pool.release(addr, conn, gErr)
+	defer func() {
+		c.pool.release(conn, gErr)
+	}()
 
 	grpcOpts := c.makeGrpcCallOptions(opts)
 	grpcOpts = append(grpcOpts, grpc.Header(&headers))
 
 	ch := make(chan error, 1)
 	go func() {
-		ch <- conn.Invoke(ctx, req.Method(), req.Body(), rsp, grpcOpts...)
+		invoke := chainUnaryInterceptors(c.callInterceptors(opts), invoker)
+		ch <- invoke(ctx, req.Method(), req.Body(), rsp, conn.ClientConn, grpcOpts...)
 		for k, v := range headers {
 			if len(v) > 0 && opts.Headers != nil {
 				opts.Headers[k] = v[0]
@@ -273,7 +417,7 @@ func (c *grpcClient) invoke(ctx context.Context, addr string, req *client.GRPCRe
 	case err := <-ch:
 		gErr = err
 	case <-ctx.Done():
-		gErr = status.Error(codes.Canceled, ctx.Err().Error())
+		gErr = status.FromContextError(ctx.Err()).Err()
 	}
 
 	return gErr
@@ -281,42 +425,49 @@ func (c *grpcClient) invoke(ctx context.Context, addr string, req *client.GRPCRe
 
 func (c *grpcClient) stream(ctx context.Context, addr string, req *client.GRPCRequest, opts client.GRPCCallOptions) (grpc.ClientStream, error) {
 
-	md := grpc_md.New(req.Headers())
-	ctx = grpc_md.NewOutgoingContext(ctx, md)
+	ctx = c.outgoingContext(ctx, req.Headers())
 	ctx, cancel := context.WithCancel(ctx)
 
-	cc, err := c.pool[defaultPoolName].getConn(ctx, addr, c.makeGrpcDialOptions()...)
+	cc, err := c.getConn(ctx, req.Service(), addr)
 	if err != nil {
 		cancel()
 		return nil, status.Error(codes.Internal, err.Error())
 	}
 
+	// grpc finishes stream of the method with a single response when the response is received
 	desc := &grpc.StreamDesc{
 		StreamName:    req.Method(),
 		ClientStreams: true,
-		ServerStreams: true,
+		ServerStreams: serverStreams(req.Method()),
 	}
 
-	st, err := cc.NewStream(ctx, desc, req.Method(), c.makeGrpcCallOptions(opts)...)
+	newStream := chainStreamInterceptors(c.callStreamInterceptors(opts), streamer)
+	st, err := newStream(ctx, desc, cc.ClientConn, req.Method(), c.makeGrpcCallOptions(opts)...)
 	if err != nil {
 		cancel()
-		c.pool[defaultPoolName].release(addr, cc, err)
+		c.pool.release(cc, err)
 		return nil, status.Error(codes.Canceled, err.Error())
 	}
 
+	release := c.releaser(ctx, cc)
+
 	s := &stream{
-		ClientStream: st,
-		context:      ctx,
-		request:      req,
-		conn:         cc,
-		close: func(err error) {
-			if err != nil {
-				cancel()
-			}
-			c.pool[defaultPoolName].release(addr, cc, err)
+		ClientStream:  st,
+		context:       ctx,
+		request:       req,
+		conn:          cc,
+		serverStreams: desc.ServerStreams,
+		finish: func(err error) {
+			release(err)
+			cancel()
 		},
 	}
 
+	// stream is opened without the first message if there is no body
+	if req.Body() == nil {
+		return s, nil
+	}
+
 	// wait for error response
 	ch := make(chan error, 1)
 
@@ -336,6 +487,7 @@ func (c *grpcClient) stream(ctx context.Context, addr string, req *client.GRPCRe
 
 	if grr != nil {
 		_ = st.CloseSend()
+		s.done(grr)
 		return nil, grr
 	}
 
@@ -362,15 +514,31 @@ func (c *grpcClient) makeGrpcCallOptions(opts client.GRPCCallOptions) []grpc.Cal
 	if opts.CallContentSubtype != "" {
 		grpcCallOptions = append(grpcCallOptions, grpc.CallContentSubtype(opts.CallContentSubtype))
 	}
+	if opts.Credentials != nil {
+		grpcCallOptions = append(grpcCallOptions, grpc.PerRPCCredentials(opts.Credentials))
+	}
+	if opts.Codec != nil {
+		grpcCallOptions = append(grpcCallOptions, grpc.ForceCodec(opts.Codec))
+	}
 
 	return grpcCallOptions
 }
 
-func (c *grpcClient) makeGrpcDialOptions() []grpc.DialOption {
+// getConn - get pooled connection to the service address,
+// connections with different transport security are pooled separately
+func (c *grpcClient) getConn(ctx context.Context, service, addr string) (*poolConn, error) {
+	key := addr
+	if c.secure(service) {
+		key = "tls://" + addr
+	}
+	c.registerPoolMetrics()
+	return c.pool.getConn(ctx, key, addr, c.makeGrpcDialOptions(service)...)
+}
+
+func (c *grpcClient) makeGrpcDialOptions(service string) []grpc.DialOption {
 	grpcDialOptions := make([]grpc.DialOption, 0)
 
-	// TODO: implement auths
-	grpcDialOptions = append(grpcDialOptions, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	grpcDialOptions = append(grpcDialOptions, grpc.WithTransportCredentials(c.transportCredentials(service)))
 
 	if c.opts.MaxRecvMsgSize != nil || c.opts.MaxSendMsgSize != nil {
 		var defaultCallOpts = make([]grpc.CallOption, 0)
@@ -404,6 +572,20 @@ func (c *grpcClient) makeGrpcDialOptions() []grpc.DialOption {
 	return grpcDialOptions
 }
 
+// outgoingContext - add request headers to outgoing metadata of the context, headers replace metadata values
+// of the same keys and token metadata is sent only as call credentials
+func (c *grpcClient) outgoingContext(ctx context.Context, headers map[string]string) context.Context {
+	md, _ := grpc_md.FromOutgoingContext(ctx)
+	md = md.Copy()
+	for k, v := range headers {
+		md.Set(k, v)
+	}
+	if key := c.opts.Auth.TokenMetadata; key != "" {
+		md.Delete(key)
+	}
+	return grpc_md.NewOutgoingContext(ctx, md)
+}
+
 func (c *grpcClient) makeHeaders(ctx context.Context, service string, opts client.GRPCCallOptions) map[string]string {
 	var headers = make(map[string]string, 0)
 
@@ -424,9 +606,49 @@ func (c *grpcClient) makeHeaders(ctx context.Context, service string, opts clien
 
 	headers["x-service-name"] = service
 
+	if t := c.tracer(); t != nil {
+		t.Inject(ctx, headers)
+	}
+
 	return headers
 }
 
+func (c *grpcClient) tracer() traces.Traces {
+	if c.runtime.Tools() == nil {
+		return nil
+	}
+	if t := c.runtime.Tools().Traces(); t != nil && t.Enabled() {
+		return t
+	}
+	return nil
+}
+
+// startSpan - start client span for the call, returned func finishes the span with the call result
+func (c *grpcClient) startSpan(ctx context.Context, service, method string) (context.Context, func(err error)) {
+	t := c.tracer()
+	if t == nil {
+		return ctx, func(error) {}
+	}
+
+	ctx, span := t.StartSpan(ctx, method, traces.SpanKindClient)
+	span.SetAttribute("rpc.system", "grpc")
+	span.SetAttribute("rpc.service", service)
+	span.SetAttribute("rpc.method", method)
+
+	var once sync.Once
+	return ctx, func(err error) {
+		once.Do(func() {
+			code := status.Code(err)
+			span.SetAttribute("rpc.grpc.status_code", int(code))
+			if code != codes.OK {
+				span.RecordError(err)
+				span.SetStatus(traces.StatusError, code.String())
+			}
+			span.End()
+		})
+	}
+}
+
 func (c *grpcClient) getResolver() resolver.Resolver {
 	if c.resolver == nil {
 		c.resolver = local.NewResolver(c.runtime)
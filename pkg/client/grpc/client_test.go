@@ -0,0 +1,216 @@
+package grpc
+
+import (
+	"context"
+	"errors"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/caarlos0/env/v7"
+	"github.com/lastbackend/toolkit/pkg/client"
+	"github.com/lastbackend/toolkit/pkg/client/grpc/resolver"
+	"github.com/lastbackend/toolkit/pkg/client/grpc/resolver/route"
+	"github.com/lastbackend/toolkit/pkg/runtime"
+	"github.com/lastbackend/toolkit/pkg/runtime/logger"
+	"github.com/lastbackend/toolkit/pkg/runtime/logger/empty"
+	"github.com/lastbackend/toolkit/pkg/runtime/meta"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/health"
+	health_pb "google.golang.org/grpc/health/grpc_health_v1"
+)
+
+const (
+	testService = "test"
+	testMethod  = "/grpc.health.v1.Health/Check"
+)
+
+type testConfig struct {
+	runtime.Config
+	environment map[string]string
+}
+
+func (c testConfig) Parse(v interface{}, prefix string, opts ...env.Options) error {
+	opts = append(opts, env.Options{Prefix: strings.ToUpper(prefix) + "_", Environment: c.environment})
+	return env.Parse(v, opts...)
+}
+
+type testRuntime struct {
+	runtime.Runtime
+	environment map[string]string
+}
+
+func (testRuntime) Meta() *meta.Meta {
+	return new(meta.Meta).SetName("test")
+}
+
+func (testRuntime) Log() logger.Logger {
+	return empty.NewLogger()
+}
+
+func (r testRuntime) Config() runtime.Config {
+	return testConfig{environment: r.environment}
+}
+
+func (testRuntime) Tools() runtime.Tools {
+	return nil
+}
+
+// testResolver - resolve services from the static routes table
+type testResolver struct {
+	resolver.Resolver
+	mtx    sync.Mutex
+	routes map[string]route.List
+}
+
+func (r *testResolver) Lookup(service string, _ ...resolver.LookupOption) (route.List, error) {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+	if routes, ok := r.routes[service]; ok {
+		return routes, nil
+	}
+	return nil, route.ErrRouteNotFound
+}
+
+func (r *testResolver) Watch(_ string) (resolver.Watcher, error) {
+	return nil, errors.New("not supported")
+}
+
+// testServer - health server calling handle before every unary call
+type testServer struct {
+	addr   string
+	calls  int32
+	handle func(ctx context.Context) error
+}
+
+func (s *testServer) Calls() int {
+	return int(atomic.LoadInt32(&s.calls))
+}
+
+func newTestServer(t *testing.T, handle func(ctx context.Context) error, opts ...grpc.ServerOption) *testServer {
+	t.Helper()
+
+	s := &testServer{handle: handle}
+
+	opts = append(opts, grpc.UnaryInterceptor(func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		atomic.AddInt32(&s.calls, 1)
+		if s.handle != nil {
+			if err := s.handle(ctx); err != nil {
+				return nil, err
+			}
+		}
+		return handler(ctx, req)
+	}))
+
+	srv := grpc.NewServer(opts...)
+	health_pb.RegisterHealthServer(srv, health.NewServer())
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	go func() {
+		_ = srv.Serve(listener)
+	}()
+	t.Cleanup(srv.Stop)
+
+	s.addr = listener.Addr().String()
+	return s
+}
+
+// newTestClient - create client with env config resolving test service to the servers
+func newTestClient(t *testing.T, environment map[string]string, servers ...*testServer) *grpcClient {
+	t.Helper()
+
+	e := map[string]string{
+		"GRPC_CLIENT_RESOLVER":    "static",
+		"GRPC_CLIENT_BACKOFF_MIN": "1ms",
+		"GRPC_CLIENT_BACKOFF_MAX": "2ms",
+	}
+	for k, v := range environment {
+		e[k] = v
+	}
+
+	cli, err := NewClient(context.Background(), testRuntime{environment: e})
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := cli.(*grpcClient)
+
+	routes := make(route.List, 0, len(servers))
+	for _, s := range servers {
+		routes = append(routes, route.Route{Service: testService, Address: s.addr})
+	}
+	c.SetResolver(&testResolver{routes: map[string]route.List{testService: routes}})
+
+	t.Cleanup(c.pool.Close)
+
+	return c
+}
+
+func check(ctx context.Context, c *grpcClient, opts ...client.GRPCCallOption) error {
+	return c.Call(ctx, testService, testMethod, &health_pb.HealthCheckRequest{}, new(health_pb.HealthCheckResponse), opts...)
+}
+
+func TestNewClient(t *testing.T) {
+	tests := []struct {
+		name        string
+		environment map[string]string
+		// rules are written to the routing rules file
+		rules  string
+		router bool
+		err    bool
+	}{
+		{"default config", nil, "", false, false},
+		{"routing rules", map[string]string{"GRPC_CLIENT_ROUTING_RULES": "billing:version=v2:60%"}, "", true, false},
+		{"invalid config value", map[string]string{"GRPC_CLIENT_RETRIES": "many"}, "", false, true},
+		{"unknown selector", map[string]string{"GRPC_CLIENT_SELECTOR": "fastest"}, "", false, true},
+		{"invalid retry codes", map[string]string{"GRPC_CLIENT_RETRY_CODES": "SOMETIMES"}, "", false, true},
+		{"invalid limits", map[string]string{"GRPC_CLIENT_LIMITS": "billing=xrps"}, "", false, true},
+		{"invalid routing rules", map[string]string{"GRPC_CLIENT_ROUTING_RULES": "billing"}, "", false, true},
+		{
+			"routing rules file exceeds percents total",
+			map[string]string{"GRPC_CLIENT_ROUTING_RULES": "billing:version=v2:60%"},
+			`{"rules":[{"service":"billing","labels":{"version":"v3"},"percent":50}]}`,
+			false,
+			true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			environment := map[string]string{"GRPC_CLIENT_RESOLVER": "static"}
+			for k, v := range tt.environment {
+				environment[k] = v
+			}
+			if tt.rules != "" {
+				file := filepath.Join(t.TempDir(), "rules.json")
+				if err := os.WriteFile(file, []byte(tt.rules), 0600); err != nil {
+					t.Fatal(err)
+				}
+				environment["GRPC_CLIENT_ROUTING_RULES_FILE"] = file
+			}
+
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			cli, err := NewClient(ctx, testRuntime{environment: environment})
+			if (err != nil) != tt.err {
+				t.Fatal("error: expected", tt.err, "received", err)
+			}
+			if tt.err {
+				if cli != nil {
+					t.Error("client: expected", nil, "received", cli)
+				}
+				return
+			}
+			if router := cli.(*grpcClient).router != nil; router != tt.router {
+				t.Error("router: expected", tt.router, "received", router)
+			}
+		})
+	}
+}
@@ -0,0 +1,94 @@
+/*
+Copyright [2014] - [2023] The Last.Backend authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package grpc
+
+import (
+	"context"
+	"sync"
+
+	"github.com/lastbackend/toolkit/pkg/client"
+	"google.golang.org/grpc"
+)
+
+// serviceConn - client connection to the service, every call is sent
+// through the connections pool to the address selected for the service
+type serviceConn struct {
+	client  *grpcClient
+	service string
+}
+
+func (s *serviceConn) Invoke(ctx context.Context, method string, args, reply interface{}, opts ...grpc.CallOption) error {
+	conn, err := s.conn(ctx)
+	if err != nil {
+		return err
+	}
+
+	err = conn.Invoke(ctx, method, args, reply, opts...)
+	s.client.pool.release(conn, err)
+	return err
+}
+
+func (s *serviceConn) NewStream(ctx context.Context, desc *grpc.StreamDesc, method string, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+	conn, err := s.conn(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	st, err := conn.NewStream(ctx, desc, method, opts...)
+	if err != nil {
+		s.client.pool.release(conn, err)
+		return nil, err
+	}
+
+	return &stream{
+		ClientStream:  st,
+		context:       ctx,
+		request:       client.NewGRPCRequest(s.service, method, nil, nil),
+		conn:          conn,
+		finish:        s.client.releaser(ctx, conn),
+		serverStreams: desc.ServerStreams,
+	}, nil
+}
+
+func (s *serviceConn) conn(ctx context.Context) (*poolConn, error) {
+	next, _, err := s.client.lookup(s.service, nil, s.client.opts.CallOptions)
+	if err != nil {
+		return nil, err
+	}
+	return s.client.getConn(ctx, s.service, next())
+}
+
+// releaser - get func returning stream connection to the pool,
+// connection is returned once when stream is finished or context is done
+func (c *grpcClient) releaser(ctx context.Context, conn *poolConn) func(err error) {
+	var once sync.Once
+
+	release := func(err error) {
+		once.Do(func() {
+			c.pool.release(conn, err)
+		})
+	}
+
+	stop := context.AfterFunc(ctx, func() {
+		release(ctx.Err())
+	})
+
+	return func(err error) {
+		stop()
+		release(err)
+	}
+}
@@ -0,0 +1,288 @@
+/*
+Copyright [2014] - [2023] The Last.Backend authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package grpc
+
+import (
+	"context"
+	"reflect"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/lastbackend/toolkit/pkg/client"
+	"github.com/lastbackend/toolkit/pkg/client/grpc/selector"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+)
+
+const (
+	metricHedgedCalls = "grpc_client_hedged_calls_total"
+
+	// number of tracked latencies per service
+	hedgingSamples = 1000
+	// min number of tracked latencies to use percentile delay
+	hedgingMinSamples = 20
+	// max number of hedging tokens per service, allows short bursts of hedged calls
+	hedgingMaxTokens = 10
+)
+
+// hedging - per service latency tracking and hedged calls budget
+type hedging struct {
+	mtx      sync.Mutex
+	ratio    float64
+	services map[string]*hedgingService
+}
+
+type hedgingService struct {
+	tokens    float64
+	latencies []time.Duration
+	next      int
+	// cached percentiles are recalculated after every hedgingMinSamples observations
+	cached  map[float64]time.Duration
+	changes int
+}
+
+func newHedging(ratio float64) *hedging {
+	return &hedging{
+		ratio:    ratio,
+		services: make(map[string]*hedgingService, 0),
+	}
+}
+
+func (h *hedging) service(name string) *hedgingService {
+	s, ok := h.services[name]
+	if !ok {
+		s = &hedgingService{
+			latencies: make([]time.Duration, 0, hedgingSamples),
+			cached:    make(map[float64]time.Duration, 0),
+		}
+		h.services[name] = s
+	}
+	return s
+}
+
+// call - count the call, every call adds a fraction of hedging token
+func (h *hedging) call(service string) {
+	h.mtx.Lock()
+	defer h.mtx.Unlock()
+
+	s := h.service(service)
+	if s.tokens += h.ratio; s.tokens > hedgingMaxTokens {
+		s.tokens = hedgingMaxTokens
+	}
+}
+
+// allow - take hedging token if hedged calls fraction is not exceeded
+func (h *hedging) allow(service string) bool {
+	h.mtx.Lock()
+	defer h.mtx.Unlock()
+
+	s := h.service(service)
+	if s.tokens < 1 {
+		return false
+	}
+	s.tokens--
+	return true
+}
+
+// observe - track latency of successful call
+func (h *hedging) observe(service string, latency time.Duration) {
+	h.mtx.Lock()
+	defer h.mtx.Unlock()
+
+	s := h.service(service)
+	if len(s.latencies) < hedgingSamples {
+		s.latencies = append(s.latencies, latency)
+	} else {
+		s.latencies[s.next] = latency
+		s.next = (s.next + 1) % hedgingSamples
+	}
+
+	if s.changes++; s.changes >= hedgingMinSamples {
+		s.changes = 0
+		s.cached = make(map[float64]time.Duration, 0)
+	}
+}
+
+// delay - get delay before hedged attempt
+func (h *hedging) delay(service string, policy *client.GRPCHedging) time.Duration {
+	if policy.Percentile <= 0 || policy.Percentile >= 1 {
+		return policy.Delay
+	}
+
+	h.mtx.Lock()
+	defer h.mtx.Unlock()
+
+	s := h.service(service)
+	if len(s.latencies) < hedgingMinSamples {
+		return policy.Delay
+	}
+
+	if d, ok := s.cached[policy.Percentile]; ok {
+		return d
+	}
+
+	sorted := make([]time.Duration, len(s.latencies))
+	copy(sorted, s.latencies)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+
+	d := sorted[int(policy.Percentile*float64(len(sorted)-1))]
+	s.cached[policy.Percentile] = d
+	return d
+}
+
+type hedgingResult struct {
+	rsp     interface{}
+	headers map[string]string
+	err     error
+}
+
+// hedge - call the address and send hedged attempts to other addresses if there is no response after delay,
+// the first successful response is used and other attempts are canceled
+func (c *grpcClient) hedge(ctx context.Context, req *client.GRPCRequest, addr string, next selector.Next, count int,
+	rsp interface{}, opts client.GRPCCallOptions) error {
+
+	service := req.Service()
+	policy := opts.Hedging
+
+	attempts := policy.MaxAttempts
+	if attempts <= 0 {
+		attempts = 2
+	}
+	if attempts > count {
+		attempts = count
+	}
+
+	c.hedging.call(service)
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	results := make(chan hedgingResult, attempts)
+	send := func(addr string) {
+		o := opts
+		if opts.Headers != nil {
+			o.Headers = make(map[string]string, 0)
+		}
+		r := newResponse(rsp)
+
+		go func() {
+			start := time.Now()
+			err := c.limit(ctx, service, func() error {
+				return c.guard(ctx, service, addr, o, func() error {
+					defer c.track(service, addr)()
+					return c.invoke(ctx, addr, req, r, o)
+				})
+			})
+			if err == nil {
+				c.hedging.observe(service, time.Since(start))
+			}
+			results <- hedgingResult{rsp: r, headers: o.Headers, err: err}
+		}()
+	}
+
+	tried := map[string]bool{addr: true}
+	send(addr)
+	sent := 1
+
+	t := time.NewTimer(c.hedging.delay(service, policy))
+	defer t.Stop()
+
+	// timer is disabled when all attempts are sent
+	timer := t.C
+	if sent >= attempts {
+		timer = nil
+	}
+
+	var err error
+	for done := 0; done < sent; {
+		select {
+		case res := <-results:
+			done++
+			if res.err == nil {
+				for k, v := range res.headers {
+					opts.Headers[k] = v
+				}
+				setResponse(rsp, res.rsp)
+				return nil
+			}
+			if err == nil {
+				err = res.err
+			}
+		case <-timer:
+			timer = nil
+
+			hedged := ""
+			for i := 0; i < count && hedged == ""; i++ {
+				if a := next(); !tried[a] {
+					hedged = a
+				}
+			}
+			if hedged == "" || !c.hedging.allow(service) {
+				continue
+			}
+
+			c.runtime.Log().V(7).Infof("grpc client: hedge %s call to %s", req.Method(), hedged)
+			c.hedgedCall(service)
+
+			tried[hedged] = true
+			send(hedged)
+			if sent++; sent < attempts {
+				t.Reset(c.hedging.delay(service, policy))
+				timer = t.C
+			}
+		case <-ctx.Done():
+			return status.FromContextError(ctx.Err()).Err()
+		}
+	}
+
+	return err
+}
+
+// newResponse - create empty response of the same type, every hedged attempt decodes its own response
+func newResponse(rsp interface{}) interface{} {
+	return reflect.New(reflect.TypeOf(rsp).Elem()).Interface()
+}
+
+// setResponse - copy response of the successful attempt
+func setResponse(dst, src interface{}) {
+	if m, ok := dst.(proto.Message); ok {
+		proto.Reset(m)
+		proto.Merge(m, src.(proto.Message))
+		return
+	}
+	reflect.ValueOf(dst).Elem().Set(reflect.ValueOf(src).Elem())
+}
+
+// hedgedCall - count hedged call, metrics are registered lazily because the client is created before runtime tools
+func (c *grpcClient) hedgedCall(service string) {
+	c.mtx.Lock()
+	if c.hedgedCalls == nil && c.runtime.Tools() != nil && c.runtime.Tools().Metrics() != nil {
+		m, err := c.runtime.Tools().Metrics().RegisterCounter(metricHedgedCalls,
+			"Total number of hedged GRPC client calls sent to a different address.", "service")
+		if err != nil {
+			c.runtime.Log().Errorf("grpc client: can not register hedging metrics: %v", err)
+		}
+		c.hedgedCalls = m
+	}
+	counter := c.hedgedCalls
+	c.mtx.Unlock()
+
+	if counter != nil {
+		counter.Inc(service)
+	}
+}
@@ -0,0 +1,145 @@
+package grpc
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/lastbackend/toolkit/pkg/client"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestHedging_Allow(t *testing.T) {
+	tests := []struct {
+		name    string
+		ratio   float64
+		calls   int
+		allowed int
+	}{
+		{"fraction of calls hedged", 0.1, 25, 2},
+		{"not enough calls", 0.1, 5, 0},
+		{"burst capped by max tokens", 1, 100, hedgingMaxTokens},
+		{"hedging disabled", 0, 100, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := newHedging(tt.ratio)
+			for i := 0; i < tt.calls; i++ {
+				h.call(testService)
+			}
+
+			allowed := 0
+			for i := 0; i < tt.calls; i++ {
+				if h.allow(testService) {
+					allowed++
+				}
+			}
+			if allowed != tt.allowed {
+				t.Error("allowed: expected", tt.allowed, "received", allowed)
+			}
+			if h.allow("other") {
+				t.Error("other service allowed: expected", false, "received", true)
+			}
+		})
+	}
+}
+
+func TestHedging_Delay(t *testing.T) {
+	tests := []struct {
+		name    string
+		samples int
+		policy  client.GRPCHedging
+		delay   time.Duration
+	}{
+		{"fixed delay", 100, client.GRPCHedging{Delay: time.Second}, time.Second},
+		{"not enough samples for percentile", hedgingMinSamples - 1, client.GRPCHedging{Delay: time.Second, Percentile: 0.5}, time.Second},
+		{"median latency", 100, client.GRPCHedging{Delay: time.Second, Percentile: 0.5}, 50 * time.Millisecond},
+		{"high percentile latency", 100, client.GRPCHedging{Delay: time.Second, Percentile: 0.95}, 95 * time.Millisecond},
+		{"samples window", 2 * hedgingSamples, client.GRPCHedging{Delay: time.Second, Percentile: 0.5}, 1500 * time.Millisecond},
+		{"invalid percentile", 100, client.GRPCHedging{Delay: time.Second, Percentile: 1}, time.Second},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := newHedging(0.1)
+			for i := 1; i <= tt.samples; i++ {
+				h.observe(testService, time.Duration(i)*time.Millisecond)
+			}
+
+			if delay := h.delay(testService, &tt.policy); delay != tt.delay {
+				t.Error("delay: expected", tt.delay, "received", delay)
+			}
+		})
+	}
+}
+
+func TestGrpcClient_Hedge(t *testing.T) {
+	const slow = 300 * time.Millisecond
+
+	tests := []struct {
+		name    string
+		servers int
+		ratio   string
+		delay   time.Duration
+		calls   int
+		slow    bool
+	}{
+		{"slow call hedged", 2, "1", slow, 2, false},
+		{"fast call not hedged", 2, "1", 0, 1, false},
+		{"hedging budget exhausted", 2, "0", slow, 1, true},
+		{"single address not hedged", 1, "1", slow, 1, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// the first call is delayed, hedged attempt is served immediately
+			var (
+				first int32
+				delay = tt.delay
+			)
+			handle := func(ctx context.Context) error {
+				if atomic.AddInt32(&first, 1) != 1 {
+					return nil
+				}
+				select {
+				case <-time.After(delay):
+					return nil
+				case <-ctx.Done():
+					return ctx.Err()
+				}
+			}
+
+			servers := make([]*testServer, 0, tt.servers)
+			for i := 0; i < tt.servers; i++ {
+				servers = append(servers, newTestServer(t, handle))
+			}
+
+			c := newTestClient(t, map[string]string{"GRPC_CLIENT_HEDGING_MAX_RATIO": tt.ratio}, servers...)
+
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+
+			start := time.Now()
+			err := check(ctx, c, client.GRPCOptionHedging(20*time.Millisecond))
+			elapsed := time.Since(start)
+
+			if status.Code(err) != codes.OK {
+				t.Fatal("code: expected", codes.OK, "received", status.Code(err), err)
+			}
+
+			calls := 0
+			for _, s := range servers {
+				calls += s.Calls()
+			}
+			if calls != tt.calls {
+				t.Error("server calls: expected", tt.calls, "received", calls)
+			}
+			if (elapsed >= slow) != tt.slow {
+				t.Error("slow response: expected", tt.slow, "received", elapsed)
+			}
+		})
+	}
+}
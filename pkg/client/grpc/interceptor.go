@@ -0,0 +1,86 @@
+/*
+Copyright [2014] - [2023] The Last.Backend authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package grpc
+
+import (
+	"context"
+
+	"github.com/lastbackend/toolkit/pkg/client"
+	"google.golang.org/grpc"
+)
+
+// chainUnaryInterceptors - wrap invoker with interceptors, the first interceptor is the outermost
+func chainUnaryInterceptors(interceptors []grpc.UnaryClientInterceptor, invoker grpc.UnaryInvoker) grpc.UnaryInvoker {
+	for n := len(interceptors) - 1; n >= 0; n-- {
+		interceptor, next := interceptors[n], invoker
+		invoker = func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, opts ...grpc.CallOption) error {
+			return interceptor(ctx, method, req, reply, cc, next, opts...)
+		}
+	}
+	return invoker
+}
+
+// chainStreamInterceptors - wrap streamer with interceptors, the first interceptor is the outermost
+func chainStreamInterceptors(interceptors []grpc.StreamClientInterceptor, streamer grpc.Streamer) grpc.Streamer {
+	for n := len(interceptors) - 1; n >= 0; n-- {
+		interceptor, next := interceptors[n], streamer
+		streamer = func(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+			return interceptor(ctx, desc, cc, method, next, opts...)
+		}
+	}
+	return streamer
+}
+
+func invoker(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, opts ...grpc.CallOption) error {
+	return cc.Invoke(ctx, method, req, reply, opts...)
+}
+
+func streamer(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+	return cc.NewStream(ctx, desc, method, opts...)
+}
+
+// UseInterceptor - add unary interceptors applied to every call of the client
+func (c *grpcClient) UseInterceptor(interceptors ...grpc.UnaryClientInterceptor) {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+	c.interceptors = append(c.interceptors, interceptors...)
+}
+
+// UseStreamInterceptor - add stream interceptors applied to every stream of the client
+func (c *grpcClient) UseStreamInterceptor(interceptors ...grpc.StreamClientInterceptor) {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+	c.streamInterceptors = append(c.streamInterceptors, interceptors...)
+}
+
+// callInterceptors - get global interceptors followed by per-call interceptors
+func (c *grpcClient) callInterceptors(opts client.GRPCCallOptions) []grpc.UnaryClientInterceptor {
+	c.mtx.RLock()
+	defer c.mtx.RUnlock()
+	interceptors := make([]grpc.UnaryClientInterceptor, 0, len(c.interceptors)+len(opts.Interceptors))
+	interceptors = append(interceptors, c.interceptors...)
+	return append(interceptors, opts.Interceptors...)
+}
+
+// callStreamInterceptors - get global stream interceptors followed by per-call stream interceptors
+func (c *grpcClient) callStreamInterceptors(opts client.GRPCCallOptions) []grpc.StreamClientInterceptor {
+	c.mtx.RLock()
+	defer c.mtx.RUnlock()
+	interceptors := make([]grpc.StreamClientInterceptor, 0, len(c.streamInterceptors)+len(opts.StreamInterceptors))
+	interceptors = append(interceptors, c.streamInterceptors...)
+	return append(interceptors, opts.StreamInterceptors...)
+}
@@ -0,0 +1,167 @@
+package grpc
+
+import (
+	"context"
+	"testing"
+
+	"github.com/lastbackend/toolkit/pkg/client"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	grpc_md "google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+func recordInterceptor(name string, calls *[]string) grpc.UnaryClientInterceptor {
+	return func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		*calls = append(*calls, name)
+		return invoker(ctx, method, req, reply, cc, opts...)
+	}
+}
+
+func TestGrpcClient_Interceptors(t *testing.T) {
+	tests := []struct {
+		name   string
+		global []string
+		call   []string
+		abort  bool
+		calls  []string
+		code   codes.Code
+		header string
+	}{
+		{
+			"no interceptors",
+			nil,
+			nil,
+			false,
+			[]string{},
+			codes.OK,
+			"",
+		},
+		{
+			"global interceptors called before per-call interceptors",
+			[]string{"global-1", "global-2"},
+			[]string{"call-1"},
+			false,
+			[]string{"global-1", "global-2", "call-1"},
+			codes.OK,
+			"",
+		},
+		{
+			"interceptor aborts call",
+			[]string{"global-1"},
+			nil,
+			true,
+			[]string{"global-1"},
+			codes.PermissionDenied,
+			"",
+		},
+		{
+			"interceptor adds metadata",
+			nil,
+			[]string{"call-1"},
+			false,
+			[]string{"call-1"},
+			codes.OK,
+			"intercepted",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var received string
+			srv := newTestServer(t, func(ctx context.Context) error {
+				if md, ok := grpc_md.FromIncomingContext(ctx); ok && len(md.Get("x-test")) > 0 {
+					received = md.Get("x-test")[0]
+				}
+				return nil
+			})
+			c := newTestClient(t, nil, srv)
+
+			calls := make([]string, 0)
+			for _, name := range tt.global {
+				c.UseInterceptor(recordInterceptor(name, &calls))
+			}
+			if tt.abort {
+				c.UseInterceptor(func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+					return status.Error(codes.PermissionDenied, "denied")
+				})
+			}
+
+			interceptors := make([]grpc.UnaryClientInterceptor, 0)
+			for _, name := range tt.call {
+				interceptors = append(interceptors, recordInterceptor(name, &calls))
+			}
+			if tt.header != "" {
+				interceptors = append(interceptors, func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+					return invoker(grpc_md.AppendToOutgoingContext(ctx, "x-test", tt.header), method, req, reply, cc, opts...)
+				})
+			}
+
+			err := check(context.Background(), c, client.GRPCOptionInterceptors(interceptors...))
+			if status.Code(err) != tt.code {
+				t.Fatal("code: expected", tt.code, "received", status.Code(err), err)
+			}
+
+			if len(calls) != len(tt.calls) {
+				t.Fatal("calls: expected", tt.calls, "received", calls)
+			}
+			for i := range calls {
+				if calls[i] != tt.calls[i] {
+					t.Error("calls: expected", tt.calls, "received", calls)
+				}
+			}
+			if received != tt.header {
+				t.Error("header: expected", tt.header, "received", received)
+			}
+			if tt.abort && srv.Calls() != 0 {
+				t.Error("server calls: expected", 0, "received", srv.Calls())
+			}
+		})
+	}
+}
+
+func TestGrpcClient_StreamInterceptors(t *testing.T) {
+	tests := []struct {
+		name   string
+		global int
+		call   int
+	}{
+		{"no interceptors", 0, 0},
+		{"global interceptors", 2, 0},
+		{"global and per-call interceptors", 1, 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := newTestServer(t, nil)
+			c := newTestClient(t, nil, srv)
+
+			var calls int
+			interceptor := func(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, streamer grpc.Streamer, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+				calls++
+				return streamer(ctx, desc, cc, method, opts...)
+			}
+
+			for i := 0; i < tt.global; i++ {
+				c.UseStreamInterceptor(interceptor)
+			}
+			interceptors := make([]grpc.StreamClientInterceptor, 0)
+			for i := 0; i < tt.call; i++ {
+				interceptors = append(interceptors, interceptor)
+			}
+
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			stream, err := c.Stream(ctx, testService, "/grpc.health.v1.Health/Watch", nil, client.GRPCOptionStreamInterceptors(interceptors...))
+			if err != nil {
+				t.Fatal(err)
+			}
+			_ = stream.CloseSend()
+
+			if calls != tt.global+tt.call {
+				t.Error("calls: expected", tt.global+tt.call, "received", calls)
+			}
+		})
+	}
+}
@@ -0,0 +1,274 @@
+/*
+Copyright [2014] - [2023] The Last.Backend authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package limiter
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	// min fraction of configured limits kept by adaptive mode
+	minRatio = 0.05
+	// additive increase of limits fraction per successful call
+	increaseStep = 0.01
+	// min interval between multiplicative decreases, bursts of failures decrease limits once
+	decreaseInterval = 100 * time.Millisecond
+)
+
+var (
+	ErrLimited = errors.New("client limit exceeded")
+)
+
+type Options struct {
+	// Rate is a max number of requests per second, zero means no limit
+	Rate float64
+	// Burst is a max number of requests sent at once, default is one second of rate
+	Burst int
+	// InFlight is a max number of concurrent requests, zero means no limit
+	InFlight int
+	// Adaptive enables AIMD adjustment of limits on upstream overload
+	Adaptive bool
+}
+
+// Limiter - token bucket and max in-flight limiter of the service
+type Limiter struct {
+	mtx  sync.Mutex
+	opts Options
+
+	tokens float64
+	last   time.Time
+
+	inflight int
+	released chan struct{}
+
+	// ratio is a fraction of configured limits used in adaptive mode
+	ratio     float64
+	decreased time.Time
+}
+
+func New(opts Options) *Limiter {
+	if opts.Burst <= 0 {
+		opts.Burst = int(math.Max(1, math.Ceil(opts.Rate)))
+	}
+	return &Limiter{
+		opts:     opts,
+		tokens:   float64(opts.Burst),
+		last:     time.Now(),
+		released: make(chan struct{}),
+		ratio:    1,
+	}
+}
+
+// Acquire - take rate token and in-flight slot, if wait is false ErrLimited is returned when limit is exceeded,
+// otherwise it waits until the request is allowed or ctx is done, acquired slot must be released with Done call
+func (l *Limiter) Acquire(ctx context.Context, wait bool) error {
+	if err := l.take(ctx, wait); err != nil {
+		return err
+	}
+	if err := l.enter(ctx, wait); err != nil {
+		// request is not sent, so it does not consume the rate
+		l.refund()
+		return err
+	}
+	return nil
+}
+
+// Take - take rate token only, used for requests not counted as in-flight
+func (l *Limiter) Take(ctx context.Context, wait bool) error {
+	return l.take(ctx, wait)
+}
+
+// Done - release in-flight slot, overloaded reports upstream overload for adaptive mode
+func (l *Limiter) Done(overloaded bool) {
+	l.mtx.Lock()
+	defer l.mtx.Unlock()
+
+	l.release()
+	l.observe(overloaded)
+}
+
+// Release - release in-flight slot without reporting the result, used for requests which did not reach the upstream
+func (l *Limiter) Release() {
+	l.mtx.Lock()
+	defer l.mtx.Unlock()
+	l.release()
+}
+
+func (l *Limiter) release() {
+	if l.opts.InFlight > 0 {
+		l.inflight--
+		// wake up waiters
+		close(l.released)
+		l.released = make(chan struct{})
+	}
+}
+
+// Observe - report result of request not counted as in-flight for adaptive mode
+func (l *Limiter) Observe(overloaded bool) {
+	l.mtx.Lock()
+	defer l.mtx.Unlock()
+	l.observe(overloaded)
+}
+
+func (l *Limiter) observe(overloaded bool) {
+	if !l.opts.Adaptive {
+		return
+	}
+
+	if overloaded {
+		if time.Since(l.decreased) >= decreaseInterval {
+			l.decreased = time.Now()
+			l.ratio = math.Max(minRatio, l.ratio/2)
+		}
+		return
+	}
+
+	l.ratio = math.Min(1, l.ratio+increaseStep)
+}
+
+// take - take rate token, waiting reserves the token in advance
+func (l *Limiter) take(ctx context.Context, wait bool) error {
+	if l.opts.Rate <= 0 {
+		return nil
+	}
+
+	l.mtx.Lock()
+
+	now := time.Now()
+	rate := l.opts.Rate * l.ratio
+	l.tokens = math.Min(float64(l.opts.Burst), l.tokens+now.Sub(l.last).Seconds()*rate)
+	l.last = now
+
+	if l.tokens >= 1 {
+		l.tokens--
+		l.mtx.Unlock()
+		return nil
+	}
+
+	if !wait {
+		l.mtx.Unlock()
+		return ErrLimited
+	}
+
+	delay := time.Duration((1 - l.tokens) / rate * float64(time.Second))
+	if dl, ok := ctx.Deadline(); ok && dl.Before(now.Add(delay)) {
+		l.mtx.Unlock()
+		return ErrLimited
+	}
+
+	l.tokens--
+	l.mtx.Unlock()
+
+	t := time.NewTimer(delay)
+	defer t.Stop()
+
+	select {
+	case <-t.C:
+		return nil
+	case <-ctx.Done():
+		// return reserved token
+		l.refund()
+		return ctx.Err()
+	}
+}
+
+// refund - return taken rate token, tokens are capped at burst
+func (l *Limiter) refund() {
+	if l.opts.Rate <= 0 {
+		return
+	}
+
+	l.mtx.Lock()
+	defer l.mtx.Unlock()
+	l.tokens = math.Min(float64(l.opts.Burst), l.tokens+1)
+}
+
+// enter - take in-flight slot
+func (l *Limiter) enter(ctx context.Context, wait bool) error {
+	if l.opts.InFlight <= 0 {
+		return nil
+	}
+
+	for {
+		l.mtx.Lock()
+		limit := int(math.Max(1, math.Floor(float64(l.opts.InFlight)*l.ratio)))
+		if l.inflight < limit {
+			l.inflight++
+			l.mtx.Unlock()
+			return nil
+		}
+		released := l.released
+		l.mtx.Unlock()
+
+		if !wait {
+			return ErrLimited
+		}
+
+		select {
+		case <-released:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// Parse - parse per service limits separated by comma in format <service>=<limit>[/<limit>],
+// where limit is <n>rps, <n>burst or <n>inflight, e.g. billing=100rps/20inflight,users=50rps
+func Parse(s string) (map[string]Options, error) {
+	result := make(map[string]Options, 0)
+
+	for _, item := range strings.Split(s, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+
+		service, limits, ok := strings.Cut(item, "=")
+		if !ok || service == "" || limits == "" {
+			return nil, fmt.Errorf("invalid limit %q: expected <service>=<limits>", item)
+		}
+
+		opts := result[service]
+		for _, limit := range strings.Split(limits, "/") {
+			var err error
+			switch {
+			case strings.HasSuffix(limit, "rps"):
+				opts.Rate, err = strconv.ParseFloat(strings.TrimSuffix(limit, "rps"), 64)
+			case strings.HasSuffix(limit, "burst"):
+				opts.Burst, err = strconv.Atoi(strings.TrimSuffix(limit, "burst"))
+			case strings.HasSuffix(limit, "inflight"):
+				opts.InFlight, err = strconv.Atoi(strings.TrimSuffix(limit, "inflight"))
+			default:
+				err = errors.New("unknown unit")
+			}
+			if err != nil {
+				return nil, fmt.Errorf("invalid limit %q of service %s: %v", limit, service, err)
+			}
+		}
+		result[service] = opts
+	}
+
+	return result, nil
+}
@@ -0,0 +1,193 @@
+package limiter
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name     string
+		limits   string
+		services int
+		opts     Options
+		err      bool
+	}{
+		{"rate and in-flight", "billing=100rps/20inflight", 1, Options{Rate: 100, InFlight: 20}, false},
+		{"rate and burst", "billing=0.5rps/5burst", 1, Options{Rate: 0.5, Burst: 5}, false},
+		{"several services", "billing=100rps, users=50rps", 2, Options{Rate: 100}, false},
+		{"empty limits", " , ", 0, Options{}, false},
+		{"missing limits", "billing=", 0, Options{}, true},
+		{"missing service", "=100rps", 0, Options{}, true},
+		{"unknown unit", "billing=100qps", 0, Options{}, true},
+		{"invalid value", "billing=xrps", 0, Options{}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			limits, err := Parse(tt.limits)
+			if (err != nil) != tt.err {
+				t.Fatal("error: expected", tt.err, "received", err)
+			}
+			if len(limits) != tt.services {
+				t.Error("services: expected", tt.services, "received", len(limits))
+			}
+			if opts := limits["billing"]; tt.services > 0 && opts != tt.opts {
+				t.Error("options: expected", tt.opts, "received", opts)
+			}
+		})
+	}
+}
+
+func TestLimiter_Take(t *testing.T) {
+	tests := []struct {
+		name    string
+		opts    Options
+		calls   int
+		allowed int
+	}{
+		{"no rate limit", Options{}, 100, 100},
+		{"burst of rate", Options{Rate: 1, Burst: 3}, 10, 3},
+		{"default burst is one second of rate", Options{Rate: 5}, 10, 5},
+		{"default burst of low rate", Options{Rate: 0.1}, 10, 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l := New(tt.opts)
+
+			allowed := 0
+			for i := 0; i < tt.calls; i++ {
+				err := l.Take(context.Background(), false)
+				switch err {
+				case nil:
+					allowed++
+				case ErrLimited:
+				default:
+					t.Fatal("error: expected", ErrLimited, "received", err)
+				}
+			}
+			if allowed != tt.allowed {
+				t.Error("allowed: expected", tt.allowed, "received", allowed)
+			}
+		})
+	}
+}
+
+func TestLimiter_Wait(t *testing.T) {
+	tests := []struct {
+		name    string
+		opts    Options
+		timeout time.Duration
+		err     error
+	}{
+		{"token refilled", Options{Rate: 50, Burst: 1}, time.Second, nil},
+		{"deadline before token refill", Options{Rate: 1, Burst: 1}, 50 * time.Millisecond, ErrLimited},
+		{"in-flight slot released", Options{InFlight: 1}, time.Second, nil},
+		{"deadline before slot release", Options{InFlight: 1}, 10 * time.Millisecond, context.DeadlineExceeded},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l := New(tt.opts)
+			if err := l.Acquire(context.Background(), false); err != nil {
+				t.Fatal(err)
+			}
+			time.AfterFunc(50*time.Millisecond, func() { l.Done(false) })
+
+			ctx, cancel := context.WithTimeout(context.Background(), tt.timeout)
+			defer cancel()
+
+			if err := l.Acquire(ctx, true); err != tt.err {
+				t.Error("error: expected", tt.err, "received", err)
+			}
+		})
+	}
+}
+
+func TestLimiter_Acquire(t *testing.T) {
+	tests := []struct {
+		name    string
+		opts    Options
+		limited int
+		// calls acquired after the limited calls and release of previous slots
+		after int
+	}{
+		{"in-flight limit", Options{InFlight: 2}, 1, 2},
+		{"rate token refunded when in-flight limit exceeded", Options{Rate: 1, Burst: 2, InFlight: 1}, 3, 1},
+		{"refund capped at burst", Options{Rate: 1, Burst: 1, InFlight: 1}, 3, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l := New(tt.opts)
+
+			acquired := 0
+			for l.Acquire(context.Background(), false) == nil {
+				acquired++
+			}
+			for i := 0; i < tt.limited; i++ {
+				if err := l.Acquire(context.Background(), false); err != ErrLimited {
+					t.Fatal("error: expected", ErrLimited, "received", err)
+				}
+			}
+
+			for i := 0; i < acquired; i++ {
+				l.Done(false)
+			}
+
+			after := 0
+			for l.Acquire(context.Background(), false) == nil {
+				after++
+			}
+			if after != tt.after {
+				t.Error("acquired after release: expected", tt.after, "received", after)
+			}
+			if l.tokens > float64(l.opts.Burst) {
+				t.Error("tokens: expected at most", l.opts.Burst, "received", l.tokens)
+			}
+		})
+	}
+}
+
+func TestLimiter_Adaptive(t *testing.T) {
+	tests := []struct {
+		name       string
+		adaptive   bool
+		overloaded int
+		pause      time.Duration
+		successes  int
+		inflight   int
+	}{
+		{"limits kept without adaptive mode", false, 1, 0, 0, 10},
+		{"limits halved on overload", true, 1, 0, 0, 5},
+		{"burst of overloads decreases once", true, 5, 0, 0, 5},
+		{"separate overloads decrease again", true, 2, decreaseInterval, 0, 2},
+		{"limits restored by successful calls", true, 1, 0, 50, 10},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l := New(Options{InFlight: 10, Adaptive: tt.adaptive})
+
+			for i := 0; i < tt.overloaded; i++ {
+				if i > 0 {
+					time.Sleep(tt.pause)
+				}
+				l.Observe(true)
+			}
+			for i := 0; i < tt.successes; i++ {
+				l.Observe(false)
+			}
+
+			inflight := 0
+			for l.Acquire(context.Background(), false) == nil {
+				inflight++
+			}
+			if inflight != tt.inflight {
+				t.Error("in-flight: expected", tt.inflight, "received", inflight)
+			}
+		})
+	}
+}
@@ -0,0 +1,102 @@
+/*
+Copyright [2014] - [2023] The Last.Backend authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package grpc
+
+import (
+	"context"
+	"errors"
+
+	"github.com/lastbackend/toolkit/pkg/client/grpc/limiter"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+type LimitsOptions struct {
+	Services string `env:"LIMITS" comment:"Set rate and concurrency limits per service <service>=<n>rps/<n>burst/<n>inflight separated by comma, e.g. billing=100rps/20inflight"`
+	Wait     bool   `env:"LIMITS_WAIT" envDefault:"false" comment:"Wait until the call is allowed by limits instead of failing with RESOURCE_EXHAUSTED"`
+	Adaptive bool   `env:"LIMITS_ADAPTIVE" envDefault:"false" comment:"Decrease limits when the service returns RESOURCE_EXHAUSTED or UNAVAILABLE and restore them on success (AIMD)"`
+}
+
+// newLimiters - create limiters of services from options
+func newLimiters(opts LimitsOptions) (map[string]*limiter.Limiter, error) {
+	limits, err := limiter.Parse(opts.Services)
+	if err != nil {
+		return nil, err
+	}
+
+	limiters := make(map[string]*limiter.Limiter, len(limits))
+	for service, o := range limits {
+		o.Adaptive = opts.Adaptive
+		limiters[service] = limiter.New(o)
+	}
+	return limiters, nil
+}
+
+// limit - call fn if limiter of the service allows it and report the result to adaptive limiter,
+// calls rejected by the circuit breaker do not reach the service and are not reported
+func (c *grpcClient) limit(ctx context.Context, service string, fn func() error) error {
+	l, ok := c.limiters[service]
+	if !ok {
+		return fn()
+	}
+
+	if err := l.Acquire(ctx, c.opts.Limits.Wait); err != nil {
+		return limitError(service, err)
+	}
+
+	err := fn()
+	if isBreakerOpen(err) {
+		l.Release()
+		return err
+	}
+	l.Done(isOverloaded(err))
+	return err
+}
+
+// limitStream - open stream if rate limiter of the service allows it, streams are not counted as in-flight calls
+func (c *grpcClient) limitStream(ctx context.Context, service string, fn func() error) error {
+	l, ok := c.limiters[service]
+	if !ok {
+		return fn()
+	}
+
+	if err := l.Take(ctx, c.opts.Limits.Wait); err != nil {
+		return limitError(service, err)
+	}
+
+	err := fn()
+	if !isBreakerOpen(err) {
+		l.Observe(isOverloaded(err))
+	}
+	return err
+}
+
+func limitError(service string, err error) error {
+	if errors.Is(err, limiter.ErrLimited) {
+		return status.Errorf(codes.ResourceExhausted, "client limit exceeded for %s", service)
+	}
+	return status.FromContextError(err).Err()
+}
+
+// isOverloaded - check if error means that service is overloaded
+func isOverloaded(err error) bool {
+	switch status.Code(err) {
+	case codes.ResourceExhausted, codes.Unavailable:
+		return true
+	}
+	return false
+}
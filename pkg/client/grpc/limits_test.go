@@ -0,0 +1,62 @@
+package grpc
+
+import (
+	"context"
+	"testing"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestGrpcClient_Limit(t *testing.T) {
+	const addr = "127.0.0.1:9000"
+
+	tests := []struct {
+		name string
+		// breaker of the address is opened before the call
+		open     bool
+		err      error
+		code     codes.Code
+		inflight int
+	}{
+		{"successful call", false, nil, codes.OK, 10},
+		{"upstream overload decreases limits", false, status.Error(codes.Unavailable, "unavailable"), codes.Unavailable, 5},
+		{"breaker rejection is not reported as overload", true, nil, codes.Unavailable, 10},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := newTestClient(t, map[string]string{
+				"GRPC_CLIENT_LIMITS":               testService + "=10inflight",
+				"GRPC_CLIENT_LIMITS_ADAPTIVE":      "true",
+				"GRPC_CLIENT_BREAKER_ENABLED":      "true",
+				"GRPC_CLIENT_BREAKER_MIN_REQUESTS": "1",
+			})
+
+			if tt.open {
+				generation, _ := c.breakers.Allow(testService, addr)
+				c.breakers.Done(testService, addr, generation, false)
+			}
+
+			ctx := context.Background()
+			upstream := tt.err
+			err := c.limit(ctx, testService, func() error {
+				return c.guard(ctx, testService, addr, c.opts.CallOptions, func() error {
+					return upstream
+				})
+			})
+			if status.Code(err) != tt.code {
+				t.Error("code: expected", tt.code, "received", status.Code(err))
+			}
+
+			l := c.limiters[testService]
+			inflight := 0
+			for l.Acquire(ctx, false) == nil {
+				inflight++
+			}
+			if inflight != tt.inflight {
+				t.Error("in-flight: expected", tt.inflight, "received", inflight)
+			}
+		})
+	}
+}
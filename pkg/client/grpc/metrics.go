@@ -0,0 +1,80 @@
+/*
+Copyright [2014] - [2023] The Last.Backend authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package grpc
+
+import (
+	"sync/atomic"
+)
+
+const (
+	metricPoolConnections = "grpc_client_pool_connections"
+	metricPoolStreams     = "grpc_client_pool_streams"
+	metricPoolDials       = "grpc_client_pool_dials_total"
+)
+
+// registerPoolMetrics - expose connections pool statistics through metrics updated on collection,
+// metrics are registered lazily because the client is created before runtime tools
+func (c *grpcClient) registerPoolMetrics() {
+	if c.poolMetrics.Load() {
+		return
+	}
+
+	if c.runtime.Tools() == nil || c.runtime.Tools().Metrics() == nil {
+		return
+	}
+
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+
+	if c.poolMetrics.Load() {
+		return
+	}
+
+	mtr := c.runtime.Tools().Metrics()
+
+	connections, err := mtr.RegisterGauge(metricPoolConnections,
+		"Number of GRPC client pooled connections by state.", "state")
+	if err != nil {
+		c.runtime.Log().Errorf("grpc client: can not register pool metrics: %v", err)
+		return
+	}
+	streams, err := mtr.RegisterGauge(metricPoolStreams,
+		"Number of GRPC client active streams over pooled connections.")
+	if err != nil {
+		c.runtime.Log().Errorf("grpc client: can not register pool metrics: %v", err)
+		return
+	}
+	dials, err := mtr.RegisterCounter(metricPoolDials,
+		"Total number of GRPC client dialed connections.")
+	if err != nil {
+		c.runtime.Log().Errorf("grpc client: can not register pool metrics: %v", err)
+		return
+	}
+
+	var lastDials atomic.Uint64
+	mtr.OnCollect(func() {
+		stats := c.pool.Stats()
+		connections.Set(float64(stats.Active), "active")
+		connections.Set(float64(stats.Idle), "idle")
+		streams.Set(float64(stats.Streams))
+		if last := lastDials.Swap(stats.Dials); stats.Dials > last {
+			dials.Add(float64(stats.Dials - last))
+		}
+	})
+
+	c.poolMetrics.Store(true)
+}
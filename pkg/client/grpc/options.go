@@ -18,26 +18,14 @@ package grpc
 
 import (
 	"github.com/lastbackend/toolkit/pkg/client"
+	"github.com/lastbackend/toolkit/pkg/client/grpc/routing"
 	"github.com/lastbackend/toolkit/pkg/client/grpc/selector"
-	"github.com/lastbackend/toolkit/pkg/util/converter"
+	"google.golang.org/grpc/codes"
 
 	"context"
-	"math"
 	"time"
 )
 
-func exponentialBackoff(ctx context.Context, req *client.GRPCRequest, attempts int) (time.Duration, error) {
-	if attempts > 10 {
-		return 2 * time.Minute, nil
-	}
-	return time.Duration(math.Pow(float64(attempts), math.E)) * time.Millisecond * 100, nil
-}
-
-type CallFunc func(ctx context.Context, addr string, req *client.GRPCRequest, rsp interface{}, opts client.GRPCCallOptions) error
-type CallMiddlewareFunc func(CallFunc) CallFunc
-type MiddlewareFunc func(client client.GRPCClient) client.GRPCClient
-type LookupFunc func(context.Context, *client.GRPCRequest, client.GRPCCallOptions) ([]string, error)
-
 type Options struct {
 	Context context.Context
 
@@ -52,27 +40,93 @@ type Options struct {
 	MaxRecvMsgSize        *int    `env:"MAX_RECV_MSG_SIZE" comment:"Sets the maximum message size in bytes the client can receive (default 16 MB)"`
 	MaxSendMsgSize        *int    `env:"MAX_SEND_MSG_SIZE" comment:"Sets the maximum message size in bytes the client can send (default 16 MB)"`
 	UserAgent             *string `env:"USER_AGENT"  envDefault:"application/protobuf" comment:"Sets the specifies a user agent string for all the RPCs"`
-	Resolver              string  `env:"RESOLVER" envDefault:"local" comment:"Define resolver used as service registry [local, file, plugin]. "`
+	Resolver              string  `env:"RESOLVER" envDefault:"local" comment:"Define resolver used as service registry [local, file, dns, endpoints, plugin]. "`
+
+	Retries    int           `env:"RETRIES" envDefault:"0" comment:"Set max number of GRPC client call retries"`
+	RetryCodes []string      `env:"RETRY_CODES" envSeparator:"," envDefault:"UNAVAILABLE" comment:"Set GRPC status codes the call is retried on (UNAVAILABLE,RESOURCE_EXHAUSTED,...)"`
+	BackoffMin time.Duration `env:"BACKOFF_MIN" envDefault:"100ms" comment:"Set minimal delay between GRPC client call retries"`
+	BackoffMax time.Duration `env:"BACKOFF_MAX" envDefault:"5s" comment:"Set maximal delay between GRPC client call retries"`
+
+	RequestTimeout time.Duration `env:"REQUEST_TIMEOUT" envDefault:"15s" comment:"Set GRPC client call timeout, shorter remaining deadline of the incoming request is used instead"`
+	DeadlineMargin time.Duration `env:"DEADLINE_MARGIN" envDefault:"5ms" comment:"Set safety margin subtracted from remaining deadline of the incoming request for outgoing calls"`
+
+	TLS  TLSOptions
+	Auth AuthOptions
+
+	Breaker BreakerOptions
+	Routing RoutingOptions
+	Limits  LimitsOptions
+
+	HedgingMaxRatio float64 `env:"HEDGING_MAX_RATIO" envDefault:"0.1" comment:"Set max fraction of hedged calls per service to avoid load amplification"`
+
+	SelectorType       string `env:"SELECTOR" envDefault:"round_robin" comment:"Define selector used to balance calls between service addresses [random, round_robin, least_outstanding, weighted, consistent_hash]"`
+	SelectorHashHeader string `env:"SELECTOR_HASH_HEADER" envDefault:"x-session-id" comment:"Set request header used as a key by consistent_hash selector"`
 
 	Selector    selector.Selector
 	Pool        PoolOptions
 	CallOptions client.GRPCCallOptions
 }
 
+type BreakerOptions struct {
+	Enabled          bool          `env:"BREAKER_ENABLED" envDefault:"false" comment:"Enable circuit breaker per service address"`
+	FailureRatio     float64       `env:"BREAKER_FAILURE_RATIO" envDefault:"0.5" comment:"Set ratio of failed calls to open circuit breaker"`
+	MinRequests      int           `env:"BREAKER_MIN_REQUESTS" envDefault:"10" comment:"Set minimal number of calls in window to calculate failure ratio"`
+	Window           time.Duration `env:"BREAKER_WINDOW" envDefault:"10s" comment:"Set duration of calls counting window"`
+	CoolDown         time.Duration `env:"BREAKER_COOLDOWN" envDefault:"30s" comment:"Set duration of open state before circuit breaker becomes half-open"`
+	HalfOpenRequests int           `env:"BREAKER_HALF_OPEN_REQUESTS" envDefault:"1" comment:"Set number of probe calls allowed in half-open state"`
+}
+
+type RoutingOptions struct {
+	Rules     string `env:"ROUTING_RULES" comment:"Set traffic routing rules <service>:<labels>:<condition> separated by semicolon, condition is a percent of calls or a header value, e.g. billing:canary=true:x-canary=true;billing:version=v2:10%"`
+	RulesFile string `env:"ROUTING_RULES_FILE" comment:"Filepath to JSON or YAML document with traffic routing rules"`
+}
+
+// newRoutingRules - get rules from env value followed by rules from file
+func newRoutingRules(opts RoutingOptions) ([]routing.Rule, error) {
+	rules, err := routing.Parse(opts.Rules)
+	if err != nil {
+		return nil, err
+	}
+
+	if opts.RulesFile != "" {
+		list, err := routing.Load(opts.RulesFile)
+		if err != nil {
+			return nil, err
+		}
+		rules = append(rules, list...)
+
+		if err := routing.Validate(rules); err != nil {
+			return nil, err
+		}
+	}
+
+	return rules, nil
+}
+
 func defaultOptions() Options {
 	slc, _ := selector.New(selector.RoundRobin)
 	return Options{
 		Context:     context.Background(),
 		ContentType: "application/protobuf",
 		Selector:    slc,
+		Retries:     defaultRetries,
+		RetryCodes:  []string{codes.Unavailable.String()},
+		BackoffMin:  defaultBackoffMin,
+		BackoffMax:  defaultBackoffMax,
+
+		RequestTimeout: defaultRequestTimeout,
+		DeadlineMargin: defaultDeadlineMargin,
+
+		HedgingMaxRatio: defaultHedgingMaxRatio,
+
+		SelectorType:       "round_robin",
+		SelectorHashHeader: "x-session-id",
+
 		CallOptions: client.GRPCCallOptions{
-			Backoff:        exponentialBackoff,
+			Backoff:        newBackoff(defaultBackoffMin, defaultBackoffMax),
 			Retries:        defaultRetries,
+			RetryCodes:     []codes.Code{codes.Unavailable},
 			RequestTimeout: defaultRequestTimeout,
 		},
-		Pool: PoolOptions{
-			Size: converter.ToIntPointer(defaultPoolSize),
-			TTL:  converter.ToDurationPointer(defaultPoolTTL),
-		},
 	}
 }
@@ -19,209 +19,337 @@ package grpc
 import (
 	"context"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/connectivity"
+	"google.golang.org/grpc/status"
 )
 
 type PoolOptions struct {
-	Size *int           `env:"POOL_SIZE"  envDefault:"" comment:"Set pool size"`
-	TTL  *time.Duration `env:"POOL_TTL"  envDefault:"" comment:"Set pool ttl"`
+	Size        *int           `env:"POOL_SIZE"  envDefault:"" comment:"Set max number of pooled connections per address"`
+	TTL         *time.Duration `env:"POOL_TTL"  envDefault:"" comment:"Set pooled connection ttl, connection is closed after ttl when it has no active streams"`
+	MaxStreams  *int           `env:"POOL_MAX_STREAMS" envDefault:"" comment:"Set max number of concurrent streams per connection"`
+	MaxIdle     *int           `env:"POOL_MAX_IDLE" envDefault:"" comment:"Set max number of idle connections per address"`
+	IdleTimeout *time.Duration `env:"POOL_IDLE_TIMEOUT" envDefault:"" comment:"Set duration after which idle connection is closed"`
 }
 
+// PoolStats - connection pool statistics
+type PoolStats struct {
+	// Active is a number of connections with active streams
+	Active int
+	// Idle is a number of connections without active streams
+	Idle int
+	// Streams is a number of active streams
+	Streams int
+	// Dials is a total number of dialed connections
+	Dials uint64
+	// Closed is a total number of closed connections
+	Closed uint64
+}
+
+// pool - connections pool keyed by address and transport security, connection is shared between
+// concurrent calls up to max streams limit
 type pool struct {
-	sync.Mutex
+	mtx sync.Mutex
 
-	size       int
-	ttl        int64
-	maxStreams int
-	maxIdle    int
-	conns      map[string]*streamsPool
-}
+	size        int
+	ttl         time.Duration
+	maxStreams  int
+	maxIdle     int
+	idleTimeout time.Duration
+
+	conns map[string][]*poolConn
+
+	// statistics are counted on every connection change to be read without the pool lock
+	active  atomic.Int64
+	idle    atomic.Int64
+	streams atomic.Int64
+	dials   atomic.Uint64
+	closed  atomic.Uint64
 
-type streamsPool struct {
-	head  *poolConn
-	busy  *poolConn
-	count int
-	idle  int
+	done     chan struct{}
+	doneOnce sync.Once
 }
 
 type poolConn struct {
 	*grpc.ClientConn
 
-	err  error
-	addr string
+	key  string
+	pool *pool
 
-	pool    *pool
-	sp      *streamsPool
 	streams int
-	created int64
-
-	pre  *poolConn
-	next *poolConn
-	in   bool
+	created time.Time
+	used    time.Time
+	// pooled is false for connections removed from the pool or dialed over the pool size,
+	// such connections are closed after the last stream is released
+	pooled bool
 }
 
-func newPool() *pool {
-	return &pool{
-		size:       1,
-		ttl:        int64(0),
-		maxStreams: 1,
-		maxIdle:    0,
-		conns:      make(map[string]*streamsPool),
+func newPool(opts PoolOptions) *pool {
+	p := &pool{
+		size:        defaultPoolSize,
+		ttl:         defaultPoolTTL,
+		maxStreams:  defaultPoolMaxStreams,
+		maxIdle:     defaultPoolMaxIdle,
+		idleTimeout: defaultPoolIdleTimeout,
+		conns:       make(map[string][]*poolConn, 0),
+		done:        make(chan struct{}),
 	}
-}
-
-func (p *pool) Init(opts PoolOptions) {
-	p.Lock()
-	defer p.Unlock()
-	if opts.Size != nil {
+	if opts.Size != nil && *opts.Size > 0 {
 		p.size = *opts.Size
 	}
 	if opts.TTL != nil {
-		p.ttl = int64(opts.TTL.Seconds())
+		p.ttl = *opts.TTL
+	}
+	if opts.MaxStreams != nil && *opts.MaxStreams > 0 {
+		p.maxStreams = *opts.MaxStreams
 	}
+	if opts.MaxIdle != nil {
+		p.maxIdle = *opts.MaxIdle
+	}
+	if opts.IdleTimeout != nil {
+		p.idleTimeout = *opts.IdleTimeout
+	}
+	if interval := p.reapInterval(); interval > 0 {
+		go p.reap(interval)
+	}
+	return p
 }
 
-func (p *pool) getConn(ctx context.Context, addr string, opts ...grpc.DialOption) (*poolConn, error) {
-	now := time.Now().Unix()
-	p.Lock()
-	sp, ok := p.conns[addr]
-	if !ok {
-		sp = &streamsPool{head: &poolConn{}, busy: &poolConn{}, count: 0, idle: 0}
-		p.conns[addr] = sp
-	}
+// getConn - get connection to the address with a free stream slot or dial a new one,
+// connections are grouped by key, every connection must be returned with release call
+func (p *pool) getConn(ctx context.Context, key, addr string, opts ...grpc.DialOption) (*poolConn, error) {
+	now := time.Now()
 
-	conn := sp.head.next
+	p.mtx.Lock()
 
-	for conn != nil {
-		switch conn.GetState() {
-		case connectivity.Connecting:
-			conn = conn.next
-			continue
-		case connectivity.Shutdown:
-			next := conn.next
-			if conn.streams == 0 {
-				removeConn(conn)
-				sp.idle--
-			}
-			conn = next
-			continue
-		case connectivity.TransientFailure:
-			next := conn.next
-			if conn.streams == 0 {
-				removeConn(conn)
-				conn.ClientConn.Close()
-				sp.idle--
-			}
-			conn = next
-			continue
-		case connectivity.Ready:
-		case connectivity.Idle:
-		}
+	var conn *poolConn
 
-		if now-conn.created > p.ttl {
-			next := conn.next
-			if conn.streams == 0 {
-				removeConn(conn)
-				conn.ClientConn.Close()
-				sp.idle--
+	conns := p.conns[key][:0]
+	for _, c := range p.conns[key] {
+		if p.expired(c, now) {
+			c.pooled = false
+			if c.streams == 0 {
+				p.close(c)
 			}
-			conn = next
 			continue
 		}
-
-		if conn.streams >= p.maxStreams {
-			next := conn.next
-			removeConn(conn)
-			addConnAfter(conn, sp.busy)
-			conn = next
-			continue
-		}
-
-		if conn.streams == 0 {
-			sp.idle--
+		conns = append(conns, c)
+		if c.streams < p.maxStreams && (conn == nil || c.streams < conn.streams) {
+			conn = c
 		}
+	}
+	p.conns[key] = conns
 
-		conn.streams++
-		p.Unlock()
+	if conn != nil {
+		p.acquire(conn)
+		conn.used = now
+		p.mtx.Unlock()
 		return conn, nil
 	}
 
-	p.Unlock()
+	p.mtx.Unlock()
 
 	cc, err := grpc.DialContext(ctx, addr, opts...)
 	if err != nil {
 		return nil, err
 	}
-	conn = &poolConn{cc, nil, addr, p, sp, 1, time.Now().Unix(), nil, nil, false}
 
-	p.Lock()
-	if sp.count < p.size {
-		addConnAfter(conn, sp.head)
+	conn = &poolConn{
+		ClientConn: cc,
+		key:        key,
+		pool:       p,
+		streams:    1,
+		created:    now,
+		used:       now,
 	}
-	p.Unlock()
+
+	p.dials.Add(1)
+	p.active.Add(1)
+	p.streams.Add(1)
+
+	p.mtx.Lock()
+	if len(p.conns[key]) < p.size && !p.stopped() {
+		conn.pooled = true
+		p.conns[key] = append(p.conns[key], conn)
+	}
+	p.mtx.Unlock()
 
 	return conn, nil
 }
 
-func (p *pool) release(addr string, conn *poolConn, err error) {
-	p.Lock()
-	p, sp, created := conn.pool, conn.sp, conn.created
+// release - return connection stream slot to the pool
+func (p *pool) release(conn *poolConn, err error) {
+	p.mtx.Lock()
+	p.put(conn, err)
+	p.mtx.Unlock()
+}
+
+// acquire - take connection stream slot
+func (p *pool) acquire(conn *poolConn) {
+	if conn.streams == 0 {
+		p.idle.Add(-1)
+		p.active.Add(1)
+	}
+	conn.streams++
+	p.streams.Add(1)
+}
+
+func (p *pool) put(conn *poolConn, err error) {
+	conn.streams--
+	conn.used = time.Now()
+	p.streams.Add(-1)
+
+	if conn.streams > 0 {
+		return
+	}
 
-	if !conn.in && sp.count < p.size {
-		addConnAfter(conn, sp.head)
+	p.active.Add(-1)
+	p.idle.Add(1)
+
+	if conn.pooled && status.Code(err) == codes.Unavailable {
+		p.remove(conn)
 	}
-	if !conn.in {
-		p.Unlock()
-		conn.ClientConn.Close()
+
+	if !conn.pooled {
+		p.close(conn)
 		return
 	}
 
-	if conn.streams >= p.maxStreams {
-		removeConn(conn)
-		addConnAfter(conn, sp.head)
+	idle := 0
+	for _, c := range p.conns[conn.key] {
+		if c.streams == 0 {
+			idle++
+		}
 	}
-	conn.streams--
 
-	if conn.streams == 0 {
-		now := time.Now().Unix()
-		if err != nil || sp.idle >= p.maxIdle || now-created > p.ttl {
-			removeConn(conn)
-			p.Unlock()
-			conn.ClientConn.Close()
+	if idle > p.maxIdle {
+		p.remove(conn)
+		p.close(conn)
+	}
+}
+
+// Stats - get pool statistics, connections dialed over the pool size are counted until closed
+func (p *pool) Stats() PoolStats {
+	return PoolStats{
+		Active:  int(p.active.Load()),
+		Idle:    int(p.idle.Load()),
+		Streams: int(p.streams.Load()),
+		Dials:   p.dials.Load(),
+		Closed:  p.closed.Load(),
+	}
+}
+
+// Close - stop idle connections reaper and close all pooled connections,
+// connections with active streams are closed on release
+func (p *pool) Close() {
+	p.doneOnce.Do(func() {
+		close(p.done)
+	})
+
+	p.mtx.Lock()
+	defer p.mtx.Unlock()
+
+	for key, conns := range p.conns {
+		for _, c := range conns {
+			c.pooled = false
+			if c.streams == 0 {
+				p.close(c)
+			}
+		}
+		delete(p.conns, key)
+	}
+}
+
+// reap - close expired idle connections in background until the pool is closed
+func (p *pool) reap(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-p.done:
 			return
+		case now := <-ticker.C:
+			p.mtx.Lock()
+			for key, conns := range p.conns {
+				alive := conns[:0]
+				for _, c := range conns {
+					if c.streams == 0 && p.expired(c, now) {
+						c.pooled = false
+						p.close(c)
+						continue
+					}
+					alive = append(alive, c)
+				}
+				if len(alive) == 0 {
+					delete(p.conns, key)
+					continue
+				}
+				p.conns[key] = alive
+			}
+			p.mtx.Unlock()
 		}
-		sp.idle++
 	}
-	p.Unlock()
 }
 
-func (conn *poolConn) Close() {
-	conn.pool.release(conn.addr, conn, conn.err)
+// reapInterval - get idle connections check interval, reaper is disabled without idle timeout and ttl
+func (p *pool) reapInterval() time.Duration {
+	interval := p.idleTimeout
+	if p.ttl > 0 && (interval <= 0 || p.ttl < interval) {
+		interval = p.ttl
+	}
+	return interval
+}
+
+func (p *pool) stopped() bool {
+	select {
+	case <-p.done:
+		return true
+	default:
+		return false
+	}
 }
 
-func removeConn(conn *poolConn) {
-	if conn.pre != nil {
-		conn.pre.next = conn.next
+func (p *pool) expired(conn *poolConn, now time.Time) bool {
+	switch conn.GetState() {
+	case connectivity.Shutdown, connectivity.TransientFailure:
+		return true
 	}
-	if conn.next != nil {
-		conn.next.pre = conn.pre
+	if p.ttl > 0 && now.Sub(conn.created) > p.ttl {
+		return true
 	}
-	conn.pre = nil
-	conn.next = nil
-	conn.in = false
-	conn.sp.count--
+	if p.idleTimeout > 0 && conn.streams == 0 && now.Sub(conn.used) > p.idleTimeout {
+		return true
+	}
+	return false
 }
 
-func addConnAfter(conn *poolConn, after *poolConn) {
-	conn.next = after.next
-	conn.pre = after
-	if after.next != nil {
-		after.next.pre = conn
+func (p *pool) remove(conn *poolConn) {
+	conn.pooled = false
+	conns := p.conns[conn.key]
+	for i, c := range conns {
+		if c == conn {
+			p.conns[conn.key] = append(conns[:i], conns[i+1:]...)
+			break
+		}
 	}
-	after.next = conn
-	conn.in = true
-	conn.sp.count++
+	if len(p.conns[conn.key]) == 0 {
+		delete(p.conns, conn.key)
+	}
+}
+
+// close - close connection without active streams
+func (p *pool) close(conn *poolConn) {
+	p.idle.Add(-1)
+	p.closed.Add(1)
+	_ = conn.ClientConn.Close()
+}
+
+// Close - release connection stream slot
+func (conn *poolConn) Close() {
+	conn.pool.release(conn, nil)
 }
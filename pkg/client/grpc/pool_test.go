@@ -0,0 +1,254 @@
+package grpc
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	health_pb "google.golang.org/grpc/health/grpc_health_v1"
+)
+
+func newTestPool(t *testing.T, size, maxStreams, maxIdle int, idleTimeout, ttl time.Duration) *pool {
+	t.Helper()
+
+	p := newPool(PoolOptions{
+		Size:        &size,
+		MaxStreams:  &maxStreams,
+		MaxIdle:     &maxIdle,
+		IdleTimeout: &idleTimeout,
+		TTL:         &ttl,
+	})
+	t.Cleanup(p.Close)
+
+	return p
+}
+
+func getConns(t *testing.T, p *pool, addr string, n int) []*poolConn {
+	t.Helper()
+
+	conns := make([]*poolConn, 0, n)
+	for i := 0; i < n; i++ {
+		conn, err := p.getConn(context.Background(), addr, addr, grpc.WithTransportCredentials(insecure.NewCredentials()))
+		if err != nil {
+			t.Fatal(err)
+		}
+		conns = append(conns, conn)
+	}
+	return conns
+}
+
+// waitStats - wait until pool statistics match or timeout is reached
+func waitStats(p *pool, expected PoolStats, timeout time.Duration) PoolStats {
+	deadline := time.Now().Add(timeout)
+	for {
+		stats := p.Stats()
+		if stats == expected || time.Now().After(deadline) {
+			return stats
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestPool_GetConn(t *testing.T) {
+	tests := []struct {
+		name       string
+		size       int
+		maxStreams int
+		maxIdle    int
+		gets       int
+		releases   int
+		stats      PoolStats
+	}{
+		{
+			"connection shared between streams",
+			10, 10, 10,
+			3, 0,
+			PoolStats{Active: 1, Streams: 3, Dials: 1},
+		},
+		{
+			"max streams dials new connection",
+			10, 1, 10,
+			2, 0,
+			PoolStats{Active: 2, Streams: 2, Dials: 2},
+		},
+		{
+			"released connection is idle",
+			10, 10, 10,
+			2, 2,
+			PoolStats{Idle: 1, Dials: 1},
+		},
+		{
+			"connection over pool size closed on release",
+			1, 1, 10,
+			2, 2,
+			PoolStats{Idle: 1, Dials: 2, Closed: 1},
+		},
+		{
+			"idle connections over max idle closed",
+			10, 1, 1,
+			3, 3,
+			PoolStats{Idle: 1, Dials: 3, Closed: 2},
+		},
+	}
+
+	s := newTestServer(t, nil)
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := newTestPool(t, tt.size, tt.maxStreams, tt.maxIdle, time.Minute, time.Minute)
+
+			conns := getConns(t, p, s.addr, tt.gets)
+			for _, conn := range conns[:tt.releases] {
+				conn.Close()
+			}
+
+			if stats := p.Stats(); stats != tt.stats {
+				t.Error("stats: expected", tt.stats, "received", stats)
+			}
+		})
+	}
+}
+
+func TestPool_Reap(t *testing.T) {
+	tests := []struct {
+		name        string
+		idleTimeout time.Duration
+		ttl         time.Duration
+		release     bool
+		stats       PoolStats
+	}{
+		{
+			"idle connection closed after idle timeout",
+			20 * time.Millisecond, 0,
+			true,
+			PoolStats{Dials: 1, Closed: 1},
+		},
+		{
+			"idle connection closed after ttl",
+			0, 20 * time.Millisecond,
+			true,
+			PoolStats{Dials: 1, Closed: 1},
+		},
+		{
+			"active connection kept",
+			20 * time.Millisecond, 20 * time.Millisecond,
+			false,
+			PoolStats{Active: 1, Streams: 1, Dials: 1},
+		},
+		{
+			"reaper disabled",
+			0, 0,
+			true,
+			PoolStats{Idle: 1, Dials: 1},
+		},
+	}
+
+	s := newTestServer(t, nil)
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := newTestPool(t, 10, 10, 10, tt.idleTimeout, tt.ttl)
+
+			conns := getConns(t, p, s.addr, 1)
+			if tt.release {
+				conns[0].Close()
+			}
+
+			if stats := waitStats(p, tt.stats, 200*time.Millisecond); stats != tt.stats {
+				t.Error("stats: expected", tt.stats, "received", stats)
+			}
+
+			p.mtx.Lock()
+			pooled := len(p.conns[s.addr])
+			p.mtx.Unlock()
+
+			if expected := tt.stats.Active + tt.stats.Idle; pooled != expected {
+				t.Error("pooled connections: expected", expected, "received", pooled)
+			}
+		})
+	}
+}
+
+func TestPool_Close(t *testing.T) {
+	tests := []struct {
+		name   string
+		before int
+		after  int
+		stats  PoolStats
+	}{
+		{"idle connections closed", 0, 0, PoolStats{Dials: 1, Closed: 1}},
+		{"active connection closed on release", 1, 0, PoolStats{Dials: 1, Closed: 1}},
+		{"connection dialed after close is not pooled", 0, 1, PoolStats{Dials: 2, Closed: 2}},
+	}
+
+	s := newTestServer(t, nil)
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := newTestPool(t, 10, 10, 10, time.Minute, time.Minute)
+
+			conns := getConns(t, p, s.addr, 1)
+			for _, conn := range conns[tt.before:] {
+				conn.Close()
+			}
+
+			p.Close()
+			p.Close()
+
+			if !p.stopped() {
+				t.Error("reaper stopped: expected", true, "received", false)
+			}
+
+			conns = append(conns[:tt.before], getConns(t, p, s.addr, tt.after)...)
+			for _, conn := range conns {
+				conn.Close()
+			}
+
+			if stats := p.Stats(); stats != tt.stats {
+				t.Error("stats: expected", tt.stats, "received", stats)
+			}
+		})
+	}
+}
+
+func TestGrpcClient_StreamRelease(t *testing.T) {
+	tests := []struct {
+		name   string
+		method string
+		cancel bool
+		stats  PoolStats
+	}{
+		{"stream released after single response", testMethod, false, PoolStats{Idle: 1, Dials: 1}},
+		{"server stream kept until finished", "/grpc.health.v1.Health/Watch", false, PoolStats{Active: 1, Streams: 1, Dials: 1}},
+		{"server stream released on cancel", "/grpc.health.v1.Health/Watch", true, PoolStats{Idle: 1, Dials: 1}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := newTestClient(t, nil, newTestServer(t, nil))
+
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+
+			stream, err := c.Stream(ctx, testService, tt.method, &health_pb.HealthCheckRequest{})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := stream.CloseSend(); err != nil {
+				t.Fatal(err)
+			}
+			if err := stream.RecvMsg(new(health_pb.HealthCheckResponse)); err != nil {
+				t.Fatal(err)
+			}
+			if tt.cancel {
+				cancel()
+			}
+
+			if stats := waitStats(c.pool, tt.stats, time.Second); stats != tt.stats {
+				t.Error("stats: expected", tt.stats, "received", stats)
+			}
+		})
+	}
+}
@@ -0,0 +1,358 @@
+/*
+Copyright [2014] - [2023] The Last.Backend authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package dns
+
+import (
+	"context"
+	"net"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/lastbackend/toolkit/pkg/client/grpc/resolver"
+	"github.com/lastbackend/toolkit/pkg/client/grpc/resolver/route"
+	"github.com/lastbackend/toolkit/pkg/runtime"
+	"github.com/pkg/errors"
+	"golang.org/x/sync/singleflight"
+)
+
+const (
+	prefix string = "resolver"
+	// placeholder replaced with service name in templates
+	servicePlaceholder = "{service}"
+)
+
+var (
+	ErrReadOnly = errors.New("dns resolver table is read only")
+)
+
+type Config struct {
+	Template string        `env:"DNS_TEMPLATE" envDefault:"{service}" comment:"Set template of DNS name resolved for service, e.g. {service}.default.svc.cluster.local or _grpc._tcp.{service}.default.svc.cluster.local for SRV records"`
+	SRV      bool          `env:"DNS_SRV" envDefault:"false" comment:"Resolve SRV records to get service addresses with ports and weights"`
+	Port     int           `env:"DNS_PORT" envDefault:"9000" comment:"Set port of service addresses resolved from A/AAAA records"`
+	TTL      time.Duration `env:"DNS_TTL" envDefault:"30s" comment:"Set duration of resolved addresses cache, cached services are refreshed in background"`
+	Server   string        `env:"DNS_SERVER" comment:"Set DNS server address host:port (default system resolver)"`
+	Timeout  time.Duration `env:"DNS_TIMEOUT" envDefault:"5s" comment:"Set DNS query timeout"`
+}
+
+// Resolver - resolve services through DNS A/AAAA or SRV records
+type Resolver struct {
+	runtime  runtime.Runtime
+	opts     Config
+	resolver *net.Resolver
+	watchers *resolver.Watchers
+	table    *table
+	cancel   context.CancelFunc
+	// group - concurrent resolves of the same service share one DNS query
+	group singleflight.Group
+}
+
+type entry struct {
+	routes  route.List
+	expires time.Time
+}
+
+func NewResolver(runtime runtime.Runtime) resolver.Resolver {
+
+	r := &Resolver{
+		runtime:  runtime,
+		watchers: resolver.NewWatchers(),
+		opts: Config{
+			Template: servicePlaceholder,
+			Port:     9000,
+			TTL:      30 * time.Second,
+			Timeout:  5 * time.Second,
+		},
+	}
+
+	if err := runtime.Config().Parse(&r.opts, prefix); err != nil {
+		runtime.Log().Errorf("Can not parse config %s: %s", prefix, err.Error())
+	}
+
+	r.resolver = newNetResolver(r.opts.Server)
+	r.table = &table{entries: make(map[string]*entry, 0)}
+
+	return r
+}
+
+func newNetResolver(server string) *net.Resolver {
+	if server == "" {
+		return net.DefaultResolver
+	}
+	return &net.Resolver{
+		PreferGo: true,
+		Dial: func(ctx context.Context, network, _ string) (net.Conn, error) {
+			d := net.Dialer{}
+			return d.DialContext(ctx, network, server)
+		},
+	}
+}
+
+// OnStart - start background refresh of resolved services
+func (c *Resolver) OnStart(ctx context.Context) error {
+	ctx, c.cancel = context.WithCancel(ctx)
+	go c.refresh(ctx)
+	return nil
+}
+
+// OnStop - stop background refresh of resolved services
+func (c *Resolver) OnStop(context.Context) error {
+	if c.cancel != nil {
+		c.cancel()
+	}
+	return nil
+}
+
+func (c *Resolver) Lookup(service string, opts ...resolver.LookupOption) (route.List, error) {
+	q := resolver.NewLookup(opts...)
+
+	routes, err := c.lookup(service)
+	if err != nil {
+		return nil, err
+	}
+
+	routes = resolver.Filter(routes, q)
+	if len(routes) == 0 {
+		return nil, route.ErrRouteNotFound
+	}
+	return routes, nil
+}
+
+// TTL - resolved routes are checked for changes only on lookup after TTL,
+// so clients caching routes expire them after TTL
+func (c *Resolver) TTL() time.Duration {
+	return c.opts.TTL
+}
+
+func (c *Resolver) Table() resolver.Table {
+	return c.table
+}
+
+func (c *Resolver) Watch(service string) (resolver.Watcher, error) {
+	return c.watchers.Watch(service), nil
+}
+
+// lookup - get service routes from cache or resolve them if cache is expired
+func (c *Resolver) lookup(service string) (route.List, error) {
+	c.table.RLock()
+	e, ok := c.table.entries[service]
+	c.table.RUnlock()
+
+	if ok && time.Now().Before(e.expires) {
+		return e.routes, nil
+	}
+
+	routes, err := c.resolveOnce(service)
+	if err != nil {
+		// keep serving stale routes while DNS is not available
+		if ok && !errors.Is(err, route.ErrRouteNotFound) {
+			c.runtime.Log().Errorf("dns resolver: can not resolve %s, use cached routes: %s", service, err.Error())
+			return e.routes, nil
+		}
+		return nil, err
+	}
+
+	return routes, nil
+}
+
+// resolveOnce - resolve the service, concurrent calls wait for the result of the first one
+func (c *Resolver) resolveOnce(service string) (route.List, error) {
+	v, err, _ := c.group.Do(service, func() (interface{}, error) {
+		return c.resolve(service)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return v.(route.List), nil
+}
+
+// resolve - query DNS and update the cache, watchers are notified about changed routes
+func (c *Resolver) resolve(service string) (route.List, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), c.opts.Timeout)
+	defer cancel()
+
+	name := strings.ReplaceAll(c.opts.Template, servicePlaceholder, service)
+
+	var (
+		routes route.List
+		err    error
+	)
+
+	if c.opts.SRV {
+		routes, err = c.resolveSRV(ctx, service, name)
+	} else {
+		routes, err = c.resolveIP(ctx, service, name)
+	}
+
+	if dnsErr := new(net.DNSError); errors.As(err, &dnsErr) && dnsErr.IsNotFound {
+		err = route.ErrRouteNotFound
+	}
+	if err != nil {
+		if err == route.ErrRouteNotFound {
+			c.table.set(c.watchers, service, nil, time.Time{})
+		}
+		return nil, err
+	}
+
+	c.table.set(c.watchers, service, routes, time.Now().Add(c.opts.TTL))
+	return routes, nil
+}
+
+func (c *Resolver) resolveIP(ctx context.Context, service, name string) (route.List, error) {
+	addrs, err := c.resolver.LookupIPAddr(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+
+	routes := make(route.List, 0, len(addrs))
+	for _, addr := range addrs {
+		routes = append(routes, route.Route{
+			Service: service,
+			Address: net.JoinHostPort(addr.IP.String(), strconv.Itoa(c.opts.Port)),
+		})
+	}
+	return routes, nil
+}
+
+func (c *Resolver) resolveSRV(ctx context.Context, service, name string) (route.List, error) {
+	_, records, err := c.resolver.LookupSRV(ctx, "", "", name)
+	if err != nil {
+		return nil, err
+	}
+
+	routes := make(route.List, 0, len(records))
+	for _, r := range records {
+		routes = append(routes, route.Route{
+			Service: service,
+			Address: net.JoinHostPort(strings.TrimSuffix(r.Target, "."), strconv.Itoa(int(r.Port))),
+			Weight:  int(r.Weight),
+		})
+	}
+	return routes, nil
+}
+
+// refresh - resolve cached services again before their cache expires
+func (c *Resolver) refresh(ctx context.Context) {
+	interval := c.opts.TTL / 2
+	if interval <= 0 {
+		interval = time.Second
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		for _, service := range c.table.services() {
+			if _, err := c.resolveOnce(service); err != nil && err != route.ErrRouteNotFound {
+				c.runtime.Log().Errorf("dns resolver: can not refresh %s: %s", service, err.Error())
+			}
+		}
+	}
+}
+
+// table - cache of resolved services, table is read only
+type table struct {
+	sync.RWMutex
+	entries map[string]*entry
+}
+
+func (t *table) set(watchers *resolver.Watchers, service string, routes route.List, expires time.Time) {
+	sort.Slice(routes, func(i, j int) bool { return routes[i].Address < routes[j].Address })
+
+	t.Lock()
+	defer t.Unlock()
+
+	prev := make(map[string]route.Route, 0)
+	if e, ok := t.entries[service]; ok {
+		for _, r := range e.routes {
+			prev[r.Address] = r
+		}
+	}
+
+	for _, r := range routes {
+		p, ok := prev[r.Address]
+		switch {
+		case !ok:
+			watchers.Emit(resolver.EventAdd, r)
+		case !reflect.DeepEqual(p, r):
+			watchers.Emit(resolver.EventUpdate, r)
+		}
+		delete(prev, r.Address)
+	}
+
+	for _, r := range prev {
+		watchers.Emit(resolver.EventDelete, r)
+	}
+
+	if len(routes) == 0 {
+		delete(t.entries, service)
+		return
+	}
+
+	t.entries[service] = &entry{routes: routes, expires: expires}
+}
+
+func (t *table) services() []string {
+	t.RLock()
+	defer t.RUnlock()
+
+	services := make([]string, 0, len(t.entries))
+	for service := range t.entries {
+		services = append(services, service)
+	}
+	return services
+}
+
+func (t *table) Find(service string) ([]route.Route, error) {
+	t.RLock()
+	defer t.RUnlock()
+
+	if len(service) > 0 {
+		e, ok := t.entries[service]
+		if !ok {
+			return nil, route.ErrRouteNotFound
+		}
+		return e.routes, nil
+	}
+
+	var routes []route.Route
+	for _, e := range t.entries {
+		routes = append(routes, e.routes...)
+	}
+	return routes, nil
+}
+
+func (t *table) Create(route.Route) error {
+	return ErrReadOnly
+}
+
+func (t *table) Delete(route.Route) error {
+	return ErrReadOnly
+}
+
+func (t *table) Update(route.Route) error {
+	return ErrReadOnly
+}
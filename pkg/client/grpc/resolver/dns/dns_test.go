@@ -0,0 +1,394 @@
+package dns
+
+import (
+	"net"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/caarlos0/env/v7"
+	"github.com/lastbackend/toolkit/pkg/client/grpc/resolver"
+	"github.com/lastbackend/toolkit/pkg/client/grpc/resolver/route"
+	"github.com/lastbackend/toolkit/pkg/runtime"
+	"github.com/lastbackend/toolkit/pkg/runtime/logger"
+	"github.com/lastbackend/toolkit/pkg/runtime/logger/empty"
+	"golang.org/x/net/dns/dnsmessage"
+)
+
+const testTemplate = "{service}.svc.local."
+
+type testConfig struct {
+	runtime.Config
+	environment map[string]string
+}
+
+func (c testConfig) Parse(v interface{}, prefix string, opts ...env.Options) error {
+	opts = append(opts, env.Options{Prefix: strings.ToUpper(prefix) + "_", Environment: c.environment})
+	return env.Parse(v, opts...)
+}
+
+type testRuntime struct {
+	runtime.Runtime
+	environment map[string]string
+}
+
+func (testRuntime) Log() logger.Logger {
+	return empty.NewLogger()
+}
+
+func (r testRuntime) Config() runtime.Config {
+	return testConfig{environment: r.environment}
+}
+
+// testServer - in-process DNS server answering A and SRV queries from static records
+type testServer struct {
+	addr string
+
+	mtx     sync.Mutex
+	a       map[string][]string
+	srv     map[string][]net.SRV
+	fail    bool
+	delay   time.Duration
+	queries int32
+}
+
+func newTestServer(t *testing.T) *testServer {
+	t.Helper()
+
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = conn.Close() })
+
+	s := &testServer{
+		addr: conn.LocalAddr().String(),
+		a:    make(map[string][]string, 0),
+		srv:  make(map[string][]net.SRV, 0),
+	}
+
+	go func() {
+		for {
+			buf := make([]byte, 1500)
+			n, addr, err := conn.ReadFrom(buf)
+			if err != nil {
+				return
+			}
+			go s.handle(conn, addr, buf[:n])
+		}
+	}()
+
+	return s
+}
+
+func (s *testServer) set(fn func(s *testServer)) {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	fn(s)
+}
+
+// Queries - number of A and SRV queries, AAAA queries are not counted
+func (s *testServer) Queries() int {
+	return int(atomic.LoadInt32(&s.queries))
+}
+
+func (s *testServer) handle(conn net.PacketConn, addr net.Addr, msg []byte) {
+	var p dnsmessage.Parser
+
+	h, err := p.Start(msg)
+	if err != nil {
+		return
+	}
+	q, err := p.Question()
+	if err != nil {
+		return
+	}
+
+	if q.Type == dnsmessage.TypeA || q.Type == dnsmessage.TypeSRV {
+		atomic.AddInt32(&s.queries, 1)
+	}
+
+	s.mtx.Lock()
+	var (
+		name  = q.Name.String()
+		ips   = s.a[name]
+		srvs  = s.srv[name]
+		fail  = s.fail
+		delay = s.delay
+	)
+	s.mtx.Unlock()
+
+	time.Sleep(delay)
+
+	rcode := dnsmessage.RCodeSuccess
+	switch {
+	case fail:
+		rcode = dnsmessage.RCodeServerFailure
+	case len(ips) == 0 && len(srvs) == 0:
+		rcode = dnsmessage.RCodeNameError
+	}
+
+	b := dnsmessage.NewBuilder(nil, dnsmessage.Header{ID: h.ID, Response: true, Authoritative: true, RCode: rcode})
+	_ = b.StartQuestions()
+	_ = b.Question(q)
+	_ = b.StartAnswers()
+
+	hdr := dnsmessage.ResourceHeader{Name: q.Name, Class: dnsmessage.ClassINET, TTL: 60}
+	switch {
+	case fail:
+	case q.Type == dnsmessage.TypeA:
+		for _, ip := range ips {
+			var a [4]byte
+			copy(a[:], net.ParseIP(ip).To4())
+			_ = b.AResource(hdr, dnsmessage.AResource{A: a})
+		}
+	case q.Type == dnsmessage.TypeSRV:
+		for _, r := range srvs {
+			_ = b.SRVResource(hdr, dnsmessage.SRVResource{
+				Priority: r.Priority,
+				Weight:   r.Weight,
+				Port:     r.Port,
+				Target:   dnsmessage.MustNewName(r.Target),
+			})
+		}
+	}
+
+	out, err := b.Finish()
+	if err != nil {
+		return
+	}
+	_, _ = conn.WriteTo(out, addr)
+}
+
+func newTestResolver(t *testing.T, s *testServer, environment map[string]string) *Resolver {
+	t.Helper()
+
+	e := map[string]string{
+		"RESOLVER_DNS_SERVER":   s.addr,
+		"RESOLVER_DNS_TEMPLATE": testTemplate,
+		"RESOLVER_DNS_TIMEOUT":  "2s",
+	}
+	for k, v := range environment {
+		e[k] = v
+	}
+
+	return NewResolver(testRuntime{environment: e}).(*Resolver)
+}
+
+func addresses(routes route.List) string {
+	list := make([]string, 0, len(routes))
+	for _, r := range routes {
+		list = append(list, r.Address)
+	}
+	return strings.Join(list, ",")
+}
+
+func TestResolver_Lookup(t *testing.T) {
+	tests := []struct {
+		name    string
+		env     map[string]string
+		service string
+		routes  string
+		weight  int
+		err     error
+	}{
+		{
+			"a records",
+			map[string]string{"RESOLVER_DNS_PORT": "9100"},
+			"billing",
+			"10.0.0.1:9100,10.0.0.2:9100",
+			0,
+			nil,
+		},
+		{
+			"srv records",
+			map[string]string{"RESOLVER_DNS_SRV": "true", "RESOLVER_DNS_TEMPLATE": "_grpc._tcp." + testTemplate},
+			"billing",
+			"billing-0.svc.local:9001,billing-1.svc.local:9002",
+			10,
+			nil,
+		},
+		{
+			"unknown service",
+			nil,
+			"unknown",
+			"",
+			0,
+			route.ErrRouteNotFound,
+		},
+	}
+
+	s := newTestServer(t)
+	s.set(func(s *testServer) {
+		s.a["billing.svc.local."] = []string{"10.0.0.2", "10.0.0.1"}
+		s.srv["_grpc._tcp.billing.svc.local."] = []net.SRV{
+			{Target: "billing-1.svc.local.", Port: 9002, Weight: 10},
+			{Target: "billing-0.svc.local.", Port: 9001, Weight: 10},
+		}
+	})
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := newTestResolver(t, s, tt.env)
+
+			routes, err := r.Lookup(tt.service)
+			if err != tt.err {
+				t.Fatal("error: expected", tt.err, "received", err)
+			}
+			if addrs := addresses(routes); addrs != tt.routes {
+				t.Error("routes: expected", tt.routes, "received", addrs)
+			}
+			for _, rt := range routes {
+				if rt.Weight != tt.weight {
+					t.Error("weight: expected", tt.weight, "received", rt.Weight)
+				}
+				if rt.Service != tt.service {
+					t.Error("service: expected", tt.service, "received", rt.Service)
+				}
+			}
+		})
+	}
+}
+
+func TestResolver_Cache(t *testing.T) {
+	tests := []struct {
+		name    string
+		ttl     string
+		change  func(s *testServer)
+		routes  string
+		err     error
+		queries int
+		events  []resolver.EventType
+	}{
+		{
+			"cached routes within ttl",
+			"1h",
+			func(s *testServer) { s.a["billing.svc.local."] = []string{"10.0.0.2"} },
+			"10.0.0.1:9000",
+			nil,
+			1,
+			[]resolver.EventType{resolver.EventAdd},
+		},
+		{
+			"routes resolved again after ttl",
+			"1ns",
+			func(s *testServer) { s.a["billing.svc.local."] = []string{"10.0.0.2"} },
+			"10.0.0.2:9000",
+			nil,
+			2,
+			[]resolver.EventType{resolver.EventAdd, resolver.EventAdd, resolver.EventDelete},
+		},
+		{
+			"stale routes used when dns fails",
+			"1ns",
+			func(s *testServer) { s.fail = true },
+			"10.0.0.1:9000",
+			nil,
+			-1,
+			[]resolver.EventType{resolver.EventAdd},
+		},
+		{
+			"removed service is not found",
+			"1ns",
+			func(s *testServer) { delete(s.a, "billing.svc.local.") },
+			"",
+			route.ErrRouteNotFound,
+			2,
+			[]resolver.EventType{resolver.EventAdd, resolver.EventDelete},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newTestServer(t)
+			s.set(func(s *testServer) { s.a["billing.svc.local."] = []string{"10.0.0.1"} })
+
+			r := newTestResolver(t, s, map[string]string{"RESOLVER_DNS_TTL": tt.ttl})
+
+			w, err := r.Watch("billing")
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer w.Stop()
+
+			if _, err := r.Lookup("billing"); err != nil {
+				t.Fatal(err)
+			}
+
+			s.set(tt.change)
+
+			routes, err := r.Lookup("billing")
+			if err != tt.err {
+				t.Fatal("error: expected", tt.err, "received", err)
+			}
+			if addrs := addresses(routes); addrs != tt.routes {
+				t.Error("routes: expected", tt.routes, "received", addrs)
+			}
+			// failed queries are retried by the system resolver
+			if tt.queries >= 0 && s.Queries() != tt.queries {
+				t.Error("queries: expected", tt.queries, "received", s.Queries())
+			}
+
+			w.Stop()
+			events := make([]resolver.EventType, 0)
+			for {
+				e, err := w.Next()
+				if err != nil {
+					break
+				}
+				events = append(events, e.Type)
+			}
+			if len(events) != len(tt.events) {
+				t.Fatal("events: expected", tt.events, "received", events)
+			}
+			for i := range events {
+				if events[i] != tt.events[i] {
+					t.Error("events: expected", tt.events, "received", events)
+					break
+				}
+			}
+		})
+	}
+}
+
+func TestResolver_ConcurrentLookup(t *testing.T) {
+	tests := []struct {
+		name    string
+		calls   int
+		queries int
+	}{
+		{"single lookup", 1, 1},
+		{"concurrent lookups share query", 10, 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newTestServer(t)
+			s.set(func(s *testServer) {
+				s.srv["billing.svc.local."] = []net.SRV{{Target: "billing-0.svc.local.", Port: 9000}}
+				s.delay = 100 * time.Millisecond
+			})
+
+			// SRV queries are not shared by the system resolver as IP lookups are
+			r := newTestResolver(t, s, map[string]string{"RESOLVER_DNS_SRV": "true"})
+
+			var wg sync.WaitGroup
+			for i := 0; i < tt.calls; i++ {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					if _, err := r.Lookup("billing"); err != nil {
+						t.Error("error: expected", nil, "received", err)
+					}
+				}()
+			}
+			wg.Wait()
+
+			if s.Queries() != tt.queries {
+				t.Error("queries: expected", tt.queries, "received", s.Queries())
+			}
+		})
+	}
+}
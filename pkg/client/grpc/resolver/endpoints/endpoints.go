@@ -0,0 +1,260 @@
+/*
+Copyright [2014] - [2023] The Last.Backend authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package endpoints
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/lastbackend/toolkit/pkg/client/grpc/resolver"
+	"github.com/lastbackend/toolkit/pkg/client/grpc/resolver/route"
+	"github.com/lastbackend/toolkit/pkg/runtime"
+	"github.com/pkg/errors"
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	prefix string = "resolver"
+	// default interval of endpoints file modification checks
+	defaultWatchInterval = 5 * time.Second
+)
+
+var (
+	ErrReadOnly = errors.New("endpoints resolver table is read only")
+)
+
+type Config struct {
+	File          string        `env:"ENDPOINTS_FILE" comment:"Filepath to JSON or YAML document with service endpoints, e.g. mounted ConfigMap"`
+	WatchInterval time.Duration `env:"ENDPOINTS_WATCH_INTERVAL" envDefault:"5s" comment:"Set interval of endpoints file modification checks, modified file is reloaded"`
+	Zone          string        `env:"ENDPOINTS_ZONE" comment:"Set zone of the service instance, endpoints from the same zone are preferred"`
+}
+
+// Document - declarative list of endpoints per service
+//
+//	services:
+//	  billing:
+//	    - address: 10.0.0.1:9000
+//	      zone: eu-west-1a
+//	      version: v2
+//	      weight: 2
+//	      metadata:
+//	        canary: "true"
+type Document struct {
+	Services map[string][]Endpoint `json:"services" yaml:"services"`
+}
+
+type Endpoint struct {
+	Address  string            `json:"address" yaml:"address"`
+	Zone     string            `json:"zone,omitempty" yaml:"zone,omitempty"`
+	Version  string            `json:"version,omitempty" yaml:"version,omitempty"`
+	Weight   int               `json:"weight,omitempty" yaml:"weight,omitempty"`
+	Metadata map[string]string `json:"metadata,omitempty" yaml:"metadata,omitempty"`
+}
+
+// Resolver - resolve services through endpoints file, file is reloaded on change
+type Resolver struct {
+	runtime  runtime.Runtime
+	opts     Config
+	watchers *resolver.Watchers
+	table    *table
+	version  string
+	cancel   context.CancelFunc
+}
+
+func NewResolver(runtime runtime.Runtime) resolver.Resolver {
+
+	r := &Resolver{
+		runtime:  runtime,
+		watchers: resolver.NewWatchers(),
+		opts: Config{
+			WatchInterval: defaultWatchInterval,
+		},
+	}
+
+	if err := runtime.Config().Parse(&r.opts, prefix); err != nil {
+		runtime.Log().Errorf("Can not parse config %s: %s", prefix, err.Error())
+	}
+
+	r.table = &table{routes: make(map[string]route.List, 0)}
+
+	if r.opts.File == "" {
+		runtime.Log().Errorf("endpoints resolver: endpoints file is not set")
+		return r
+	}
+
+	if err := r.reload(); err != nil {
+		runtime.Log().Errorf("endpoints resolver: can not load %s: %s", r.opts.File, err.Error())
+	}
+
+	return r
+}
+
+// OnStart - start endpoints file watching
+func (c *Resolver) OnStart(ctx context.Context) error {
+	if c.opts.File == "" {
+		return nil
+	}
+	ctx, c.cancel = context.WithCancel(ctx)
+	go c.watch(ctx)
+	return nil
+}
+
+// OnStop - stop endpoints file watching
+func (c *Resolver) OnStop(context.Context) error {
+	if c.cancel != nil {
+		c.cancel()
+	}
+	return nil
+}
+
+func (c *Resolver) Lookup(service string, opts ...resolver.LookupOption) (route.List, error) {
+	if c.opts.Zone != "" {
+		opts = append([]resolver.LookupOption{resolver.PreferZone(c.opts.Zone)}, opts...)
+	}
+	q := resolver.NewLookup(opts...)
+
+	routes, err := c.table.Find(service)
+	if err != nil {
+		return nil, err
+	}
+
+	routes = resolver.Filter(routes, q)
+	if len(routes) == 0 {
+		return nil, route.ErrRouteNotFound
+	}
+	return routes, nil
+}
+
+func (c *Resolver) Table() resolver.Table {
+	return c.table
+}
+
+func (c *Resolver) Watch(service string) (resolver.Watcher, error) {
+	return c.watchers.Watch(service), nil
+}
+
+// watch - reload endpoints when the file is modified
+func (c *Resolver) watch(ctx context.Context) {
+	interval := c.opts.WatchInterval
+	if interval <= 0 {
+		interval = defaultWatchInterval
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		if fingerprint(c.opts.File) == c.version {
+			continue
+		}
+
+		if err := c.reload(); err != nil {
+			c.runtime.Log().Errorf("endpoints resolver: can not reload %s, keep previous endpoints: %s", c.opts.File, err.Error())
+		}
+	}
+}
+
+// reload - read and decode the whole document, routes are replaced only if document is valid
+func (c *Resolver) reload() error {
+	version := fingerprint(c.opts.File)
+
+	doc, err := decode(c.opts.File)
+	if err != nil {
+		// do not retry the same broken file on every tick
+		c.version = version
+		return err
+	}
+
+	routes := make(map[string]route.List, len(doc.Services))
+	for service, endpoints := range doc.Services {
+		for i, e := range endpoints {
+			if e.Address == "" {
+				c.version = version
+				return fmt.Errorf("service %s endpoint %d: address is empty", service, i)
+			}
+			routes[service] = append(routes[service], e.route(service))
+		}
+	}
+
+	c.table.replace(c.watchers, routes)
+	c.version = version
+	return nil
+}
+
+func (e Endpoint) route(service string) route.Route {
+	r := route.Route{
+		Service: service,
+		Address: e.Address,
+		Weight:  e.Weight,
+	}
+
+	if len(e.Metadata) > 0 || e.Zone != "" || e.Version != "" {
+		r.Metadata = make(map[string]string, len(e.Metadata)+2)
+		for k, v := range e.Metadata {
+			r.Metadata[k] = v
+		}
+		if e.Zone != "" {
+			r.Metadata[route.MetadataZone] = e.Zone
+		}
+		if e.Version != "" {
+			r.Metadata[route.MetadataVersion] = e.Version
+		}
+	}
+
+	return r
+}
+
+// decode - decode JSON or YAML document depending on file extension
+func decode(file string) (*Document, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+
+	doc := new(Document)
+	switch strings.ToLower(filepath.Ext(file)) {
+	case ".json":
+		err = json.Unmarshal(data, doc)
+	default:
+		err = yaml.Unmarshal(data, doc)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("can not decode endpoints document: %s", err.Error())
+	}
+
+	return doc, nil
+}
+
+// fingerprint - get file modification state, symlinks are followed to detect ConfigMap updates
+func fingerprint(file string) string {
+	info, err := os.Stat(file)
+	if err != nil {
+		return ""
+	}
+	return fmt.Sprintf("%d:%d", info.Size(), info.ModTime().UnixNano())
+}
@@ -0,0 +1,306 @@
+package endpoints
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/caarlos0/env/v7"
+	"github.com/lastbackend/toolkit/pkg/client/grpc/resolver"
+	"github.com/lastbackend/toolkit/pkg/client/grpc/resolver/route"
+	"github.com/lastbackend/toolkit/pkg/runtime"
+	"github.com/lastbackend/toolkit/pkg/runtime/logger"
+	"github.com/lastbackend/toolkit/pkg/runtime/logger/empty"
+)
+
+const testDocument = `
+services:
+  billing:
+    - address: 10.0.0.1:9000
+      zone: eu-west-1a
+      version: v1
+    - address: 10.0.0.2:9000
+      zone: eu-west-1b
+      version: v2
+      weight: 2
+      metadata:
+        canary: "true"
+`
+
+type testConfig struct {
+	runtime.Config
+	environment map[string]string
+}
+
+func (c testConfig) Parse(v interface{}, prefix string, opts ...env.Options) error {
+	opts = append(opts, env.Options{Prefix: strings.ToUpper(prefix) + "_", Environment: c.environment})
+	return env.Parse(v, opts...)
+}
+
+type testRuntime struct {
+	runtime.Runtime
+	environment map[string]string
+}
+
+func (testRuntime) Log() logger.Logger {
+	return empty.NewLogger()
+}
+
+func (r testRuntime) Config() runtime.Config {
+	return testConfig{environment: r.environment}
+}
+
+// writeDocument - write endpoints document with modification time in the future, so the change is detected
+func writeDocument(t *testing.T, file, data string) {
+	t.Helper()
+
+	if err := os.WriteFile(file, []byte(data), 0600); err != nil {
+		t.Fatal(err)
+	}
+	modified := time.Now().Add(time.Duration(len(data)) * time.Second)
+	if err := os.Chtimes(file, modified, modified); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func newTestResolver(t *testing.T, environment map[string]string) *Resolver {
+	t.Helper()
+	return NewResolver(testRuntime{environment: environment}).(*Resolver)
+}
+
+func addresses(routes route.List) string {
+	list := make([]string, 0, len(routes))
+	for _, r := range routes {
+		list = append(list, r.Address)
+	}
+	return strings.Join(list, ",")
+}
+
+func TestResolver_Lookup(t *testing.T) {
+	tests := []struct {
+		name    string
+		ext     string
+		data    string
+		zone    string
+		service string
+		opts    []resolver.LookupOption
+		routes  string
+		err     error
+	}{
+		{
+			"yaml document",
+			".yaml", testDocument, "",
+			"billing", nil,
+			"10.0.0.1:9000,10.0.0.2:9000", nil,
+		},
+		{
+			"json document",
+			".json", `{"services":{"billing":[{"address":"10.0.0.1:9000"}]}}`, "",
+			"billing", nil,
+			"10.0.0.1:9000", nil,
+		},
+		{
+			"zone endpoints preferred",
+			".yaml", testDocument, "eu-west-1b",
+			"billing", nil,
+			"10.0.0.2:9000", nil,
+		},
+		{
+			"other zone endpoints used when zone has no endpoints",
+			".yaml", testDocument, "us-east-1a",
+			"billing", nil,
+			"10.0.0.1:9000,10.0.0.2:9000", nil,
+		},
+		{
+			"version filter",
+			".yaml", testDocument, "",
+			"billing", []resolver.LookupOption{resolver.WithVersion("v1")},
+			"10.0.0.1:9000", nil,
+		},
+		{
+			"metadata filter",
+			".yaml", testDocument, "",
+			"billing", []resolver.LookupOption{resolver.WithMetadata("canary", "true")},
+			"10.0.0.2:9000", nil,
+		},
+		{
+			"no endpoints match filter",
+			".yaml", testDocument, "",
+			"billing", []resolver.LookupOption{resolver.WithVersion("v3")},
+			"", route.ErrRouteNotFound,
+		},
+		{
+			"unknown service",
+			".yaml", testDocument, "",
+			"unknown", nil,
+			"", route.ErrRouteNotFound,
+		},
+		{
+			"invalid document",
+			".yaml", "services: [", "",
+			"billing", nil,
+			"", route.ErrRouteNotFound,
+		},
+		{
+			"endpoint without address rejects document",
+			".yaml", "services:\n  billing:\n    - address: 10.0.0.1:9000\n    - zone: eu-west-1a\n", "",
+			"billing", nil,
+			"", route.ErrRouteNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			file := filepath.Join(t.TempDir(), "endpoints"+tt.ext)
+			writeDocument(t, file, tt.data)
+
+			r := newTestResolver(t, map[string]string{"RESOLVER_ENDPOINTS_FILE": file, "RESOLVER_ENDPOINTS_ZONE": tt.zone})
+
+			routes, err := r.Lookup(tt.service, tt.opts...)
+			if err != tt.err {
+				t.Fatal("error: expected", tt.err, "received", err)
+			}
+			if addrs := addresses(routes); addrs != tt.routes {
+				t.Error("routes: expected", tt.routes, "received", addrs)
+			}
+		})
+	}
+}
+
+func TestResolver_Endpoint(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "endpoints.yaml")
+	writeDocument(t, file, testDocument)
+
+	r := newTestResolver(t, map[string]string{"RESOLVER_ENDPOINTS_FILE": file})
+
+	tests := []struct {
+		name     string
+		address  string
+		weight   int
+		metadata map[string]string
+	}{
+		{
+			"zone and version in metadata",
+			"10.0.0.1:9000", 0,
+			map[string]string{route.MetadataZone: "eu-west-1a", route.MetadataVersion: "v1"},
+		},
+		{
+			"weight and custom metadata",
+			"10.0.0.2:9000", 2,
+			map[string]string{route.MetadataZone: "eu-west-1b", route.MetadataVersion: "v2", "canary": "true"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			routes, err := r.Lookup("billing", resolver.WithAddress(tt.address))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if routes[0].Weight != tt.weight {
+				t.Error("weight: expected", tt.weight, "received", routes[0].Weight)
+			}
+			if len(routes[0].Metadata) != len(tt.metadata) {
+				t.Error("metadata: expected", tt.metadata, "received", routes[0].Metadata)
+			}
+			for k, v := range tt.metadata {
+				if routes[0].Metadata[k] != v {
+					t.Error("metadata "+k+": expected", v, "received", routes[0].Metadata[k])
+				}
+			}
+		})
+	}
+}
+
+func TestResolver_Reload(t *testing.T) {
+	tests := []struct {
+		name   string
+		data   string
+		routes string
+		events []resolver.EventType
+	}{
+		{
+			"endpoint added",
+			testDocument + "    - address: 10.0.0.3:9000\n",
+			"10.0.0.1:9000,10.0.0.2:9000,10.0.0.3:9000",
+			[]resolver.EventType{resolver.EventAdd},
+		},
+		{
+			"endpoint removed",
+			"services:\n  billing:\n    - address: 10.0.0.1:9000\n      zone: eu-west-1a\n      version: v1\n",
+			"10.0.0.1:9000",
+			[]resolver.EventType{resolver.EventDelete},
+		},
+		{
+			"endpoint updated",
+			strings.Replace(testDocument, "weight: 2", "weight: 3", 1),
+			"10.0.0.1:9000,10.0.0.2:9000",
+			[]resolver.EventType{resolver.EventUpdate},
+		},
+		{
+			"invalid document keeps previous endpoints",
+			"services: [",
+			"10.0.0.1:9000,10.0.0.2:9000",
+			nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			file := filepath.Join(t.TempDir(), "endpoints.yaml")
+			writeDocument(t, file, testDocument)
+
+			r := newTestResolver(t, map[string]string{
+				"RESOLVER_ENDPOINTS_FILE":           file,
+				"RESOLVER_ENDPOINTS_WATCH_INTERVAL": "10ms",
+			})
+
+			w, err := r.Watch("billing")
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer w.Stop()
+
+			if err := r.OnStart(context.Background()); err != nil {
+				t.Fatal(err)
+			}
+
+			writeDocument(t, file, tt.data)
+			time.Sleep(100 * time.Millisecond)
+
+			if err := r.OnStop(context.Background()); err != nil {
+				t.Fatal(err)
+			}
+
+			routes, err := r.Lookup("billing")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if addrs := addresses(routes); addrs != tt.routes {
+				t.Error("routes: expected", tt.routes, "received", addrs)
+			}
+
+			w.Stop()
+			events := make([]resolver.EventType, 0)
+			for {
+				e, err := w.Next()
+				if err != nil {
+					break
+				}
+				events = append(events, e.Type)
+			}
+			if len(events) != len(tt.events) {
+				t.Fatal("events: expected", tt.events, "received", events)
+			}
+			for i := range events {
+				if events[i] != tt.events[i] {
+					t.Error("events: expected", tt.events, "received", events)
+					break
+				}
+			}
+		})
+	}
+}
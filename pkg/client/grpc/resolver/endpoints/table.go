@@ -0,0 +1,98 @@
+/*
+Copyright [2014] - [2023] The Last.Backend authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package endpoints
+
+import (
+	"reflect"
+	"sync"
+
+	"github.com/lastbackend/toolkit/pkg/client/grpc/resolver"
+	"github.com/lastbackend/toolkit/pkg/client/grpc/resolver/route"
+)
+
+// table - routes loaded from endpoints file, table is read only
+type table struct {
+	sync.RWMutex
+	routes map[string]route.List
+}
+
+// replace - swap all routes at once and notify watchers about changed routes
+func (t *table) replace(watchers *resolver.Watchers, routes map[string]route.List) {
+	t.Lock()
+	defer t.Unlock()
+
+	for service, list := range t.routes {
+		next := make(map[string]route.Route, len(routes[service]))
+		for _, r := range routes[service] {
+			next[r.Address] = r
+		}
+		for _, r := range list {
+			if _, ok := next[r.Address]; !ok {
+				watchers.Emit(resolver.EventDelete, r)
+			}
+		}
+	}
+
+	for service, list := range routes {
+		prev := make(map[string]route.Route, len(t.routes[service]))
+		for _, r := range t.routes[service] {
+			prev[r.Address] = r
+		}
+		for _, r := range list {
+			p, ok := prev[r.Address]
+			switch {
+			case !ok:
+				watchers.Emit(resolver.EventAdd, r)
+			case !reflect.DeepEqual(p, r):
+				watchers.Emit(resolver.EventUpdate, r)
+			}
+		}
+	}
+
+	t.routes = routes
+}
+
+func (t *table) Find(service string) ([]route.Route, error) {
+	t.RLock()
+	defer t.RUnlock()
+
+	if len(service) > 0 {
+		routes, ok := t.routes[service]
+		if !ok {
+			return nil, route.ErrRouteNotFound
+		}
+		return routes, nil
+	}
+
+	var routes []route.Route
+	for _, list := range t.routes {
+		routes = append(routes, list...)
+	}
+	return routes, nil
+}
+
+func (t *table) Create(route.Route) error {
+	return ErrReadOnly
+}
+
+func (t *table) Delete(route.Route) error {
+	return ErrReadOnly
+}
+
+func (t *table) Update(route.Route) error {
+	return ErrReadOnly
+}
@@ -25,6 +25,8 @@ import (
 	"github.com/lastbackend/toolkit/pkg/util/addr"
 	"os"
 	"path"
+	"sync"
+	"time"
 )
 
 const (
@@ -37,10 +39,16 @@ type Plugin interface {
 }
 
 type Resolver struct {
-	prefix  string
-	runtime runtime.Runtime
-	table   *table
-	opts    Config
+	prefix   string
+	runtime  runtime.Runtime
+	table    *table
+	watchers *resolver.Watchers
+	opts     Config
+
+	// routes registered for local servers
+	routes []route.Route
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
 }
 
 type Config struct {
@@ -57,8 +65,9 @@ func NewResolver(runtime runtime.Runtime) resolver.Resolver {
 	var err error
 
 	r := &Resolver{
-		prefix:  prefix,
-		runtime: runtime,
+		prefix:   prefix,
+		runtime:  runtime,
+		watchers: resolver.NewWatchers(),
 	}
 
 	if err := runtime.Config().Parse(&r.opts, prefix); err != nil {
@@ -74,7 +83,7 @@ func NewResolver(runtime runtime.Runtime) resolver.Resolver {
 		r.opts.File = path.Join(dirname, defaultFileName)
 	}
 
-	if r.table, err = newTable(r.opts.File, runtime.Log()); err != nil {
+	if r.table, err = newTable(r.opts.File, runtime.Log(), r.watchers); err != nil {
 		return nil
 	}
 
@@ -90,16 +99,69 @@ func (c *Resolver) OnStart(ctx context.Context) error {
 		return err
 	}
 
+	ctx, c.cancel = context.WithCancel(ctx)
+
 	srvs := c.runtime.Server().GRPCList()
 	for name, srv := range srvs {
-		c.table.Create(route.Route{
+		info := srv.Info()
+		r := route.Route{
 			Service: name,
-			Address: fmt.Sprintf("%s:%d", ip, srv.Info().Port),
-		})
+			Address: fmt.Sprintf("%s:%d", ip, info.Port),
+		}
+
+		if err := c.table.register(r, info.RegisterTTL); err != nil {
+			return err
+		}
+		c.routes = append(c.routes, r)
+
+		if info.RegisterInterval > 0 {
+			c.wg.Add(1)
+			go c.heartbeat(ctx, r, info.RegisterInterval, info.RegisterTTL)
+		}
 	}
 	return nil
 }
 
+// OnStop - stop registration refresh, remove routes of local servers and stop watching the table file
+func (c *Resolver) OnStop(context.Context) error {
+	c.runtime.Log().Info("resolver file on-stop call")
+
+	if c.cancel != nil {
+		c.cancel()
+	}
+	c.wg.Wait()
+
+	for _, r := range c.routes {
+		if err := c.table.Delete(r); err != nil && err != route.ErrRouteNotFound {
+			c.runtime.Log().Errorf("Can not deregister route %s with addr %s: %s", r.Service, r.Address, err.Error())
+		}
+	}
+	c.routes = nil
+
+	c.table.stop()
+	return nil
+}
+
+// heartbeat - refresh route registration until context is canceled
+func (c *Resolver) heartbeat(ctx context.Context, r route.Route, interval, ttl time.Duration) {
+	defer c.wg.Done()
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		if err := c.table.register(r, ttl); err != nil {
+			c.runtime.Log().Errorf("Can not refresh route %s with addr %s: %s", r.Service, r.Address, err.Error())
+		}
+	}
+}
+
 func (c *Resolver) Lookup(service string, opts ...resolver.LookupOption) (route.List, error) {
 	q := resolver.NewLookup(opts...)
 	routes, err := c.table.Find(service)
@@ -117,6 +179,10 @@ func (c *Resolver) Table() resolver.Table {
 	return c.table
 }
 
+func (c *Resolver) Watch(service string) (resolver.Watcher, error) {
+	return c.watchers.Watch(service), nil
+}
+
 func (c *Resolver) Print() {
 	return
 }
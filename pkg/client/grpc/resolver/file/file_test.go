@@ -0,0 +1,116 @@
+package file
+
+import (
+	"context"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/caarlos0/env/v7"
+	"github.com/lastbackend/toolkit/pkg/client/grpc/resolver/route"
+	"github.com/lastbackend/toolkit/pkg/runtime"
+	"github.com/lastbackend/toolkit/pkg/runtime/logger"
+	"github.com/lastbackend/toolkit/pkg/runtime/logger/empty"
+	"github.com/lastbackend/toolkit/pkg/server"
+)
+
+type testConfig struct {
+	runtime.Config
+	environment map[string]string
+}
+
+func (c testConfig) Parse(v interface{}, prefix string, opts ...env.Options) error {
+	opts = append(opts, env.Options{Prefix: strings.ToUpper(prefix) + "_", Environment: c.environment})
+	return env.Parse(v, opts...)
+}
+
+type testGRPCServer struct {
+	server.GRPCServer
+	info server.ServerInfo
+}
+
+func (s testGRPCServer) Info() server.ServerInfo {
+	return s.info
+}
+
+type testServer struct {
+	runtime.Server
+	servers map[string]server.GRPCServer
+}
+
+func (s testServer) GRPCList() map[string]server.GRPCServer {
+	return s.servers
+}
+
+type testRuntime struct {
+	runtime.Runtime
+	environment map[string]string
+	servers     map[string]server.GRPCServer
+}
+
+func (testRuntime) Log() logger.Logger {
+	return empty.NewLogger()
+}
+
+func (r testRuntime) Config() runtime.Config {
+	return testConfig{environment: r.environment}
+}
+
+func (r testRuntime) Server() runtime.Server {
+	return testServer{servers: r.servers}
+}
+
+func TestResolver_OnStartStop(t *testing.T) {
+	tests := []struct {
+		name     string
+		interval time.Duration
+		ttl      time.Duration
+		cancel   bool
+		routes   int
+	}{
+		{"route registered without expiry", 0, 0, false, 1},
+		{"route refreshed by heartbeat", 20 * time.Millisecond, 100 * time.Millisecond, false, 1},
+		{"route expired without heartbeat", 0, 50 * time.Millisecond, false, 0},
+		{"heartbeat stopped with start context", 20 * time.Millisecond, 100 * time.Millisecond, true, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := NewResolver(testRuntime{
+				environment: map[string]string{"RESOLVER_FILEPATH": filepath.Join(t.TempDir(), defaultFileName)},
+				servers: map[string]server.GRPCServer{
+					testService: testGRPCServer{info: server.ServerInfo{Port: 9000, RegisterInterval: tt.interval, RegisterTTL: tt.ttl}},
+				},
+			}).(*Resolver)
+
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			if err := r.OnStart(ctx); err != nil {
+				t.Fatal(err)
+			}
+			if routes, _ := r.Lookup(testService); len(routes) != 1 {
+				t.Fatal("registered routes: expected", 1, "received", len(routes))
+			}
+
+			if tt.cancel {
+				cancel()
+			}
+			time.Sleep(300 * time.Millisecond)
+
+			if routes, _ := r.Lookup(testService); len(routes) != tt.routes {
+				t.Error("routes: expected", tt.routes, "received", len(routes))
+			}
+
+			if err := r.OnStop(context.Background()); err != nil {
+				t.Fatal(err)
+			}
+
+			routes, err := r.table.Find(testService)
+			if err != route.ErrRouteNotFound {
+				t.Error("routes after stop: expected", 0, "received", len(routes))
+			}
+		})
+	}
+}
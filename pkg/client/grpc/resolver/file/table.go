@@ -18,19 +18,37 @@ package file
 
 import (
 	"encoding/json"
-	rt "github.com/lastbackend/toolkit/pkg/client/grpc/resolver/route"
-	"github.com/lastbackend/toolkit/pkg/runtime/logger"
+	"fmt"
 	"os"
+	"path/filepath"
+	"reflect"
 	"sync"
 	"time"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/lastbackend/toolkit/pkg/client/grpc/resolver"
+	rt "github.com/lastbackend/toolkit/pkg/client/grpc/resolver/route"
+	"github.com/lastbackend/toolkit/pkg/runtime/logger"
+)
+
+const (
+	// max interval between expired routes checks when no route has ttl
+	defaultExpireInterval = time.Hour
 )
 
 type table struct {
 	sync.RWMutex
-	file string
-	log  logger.Logger
-	x    int
-	Data tableData
+	file     string
+	log      logger.Logger
+	watchers *resolver.Watchers
+	version  string
+	Data     tableData
+
+	// changed is signaled when the table is saved to recalculate routes expiration
+	changed  chan struct{}
+	done     chan struct{}
+	stopped  chan struct{}
+	stopOnce sync.Once
 }
 
 type tableData struct {
@@ -39,35 +57,158 @@ type tableData struct {
 }
 
 type Route struct {
-	Route   rt.Route  `json:"route"`
-	Updated time.Time `json:"updated"`
+	Route   rt.Route      `json:"route"`
+	Updated time.Time     `json:"updated"`
+	TTL     time.Duration `json:"ttl,omitempty"`
 }
 
-func newTable(file string, log logger.Logger) (*table, error) {
+// expired - route is expired when it is not refreshed during TTL, routes without TTL never expire
+func (r *Route) expired(now time.Time) bool {
+	return r.TTL > 0 && now.Sub(r.Updated) > r.TTL
+}
+
+func newTable(file string, log logger.Logger, watchers *resolver.Watchers) (*table, error) {
 
 	t := &table{
-		file: file,
-		log:  log,
+		file:     filepath.Clean(file),
+		log:      log,
+		watchers: watchers,
 		Data: tableData{
 			Routes: make(map[string]map[string]*Route, 0),
 		},
+		changed: make(chan struct{}, 1),
+		done:    make(chan struct{}),
+		stopped: make(chan struct{}),
 	}
 
 	if err := t.load(); err != nil {
 		return t, err
 	}
 
-	go func() {
-		for {
-			t.load()
-			t.save()
-			time.Sleep(1 * time.Second)
+	// the directory is watched to follow files replaced by rename
+	w, err := fsnotify.NewWatcher()
+	if err == nil {
+		if err = w.Add(filepath.Dir(t.file)); err != nil {
+			_ = w.Close()
+			w = nil
 		}
-	}()
+	}
+	if err != nil {
+		t.log.Errorf("can not watch table file, changes of other processes are loaded on update: %s", err.Error())
+	}
+
+	go t.watch(w)
 
 	return t, nil
 }
 
+// watch - reload table when the file is modified by other processes and drop routes when they expire
+func (t *table) watch(w *fsnotify.Watcher) {
+	defer close(t.stopped)
+
+	var (
+		events <-chan fsnotify.Event
+		errs   <-chan error
+	)
+	if w != nil {
+		defer w.Close()
+		events, errs = w.Events, w.Errors
+	}
+
+	timer := time.NewTimer(t.refresh())
+	defer timer.Stop()
+
+	for {
+		select {
+		case <-t.done:
+			return
+		case e, ok := <-events:
+			if !ok {
+				events = nil
+				continue
+			}
+			if filepath.Clean(e.Name) != t.file {
+				continue
+			}
+		case err, ok := <-errs:
+			if !ok {
+				errs = nil
+				continue
+			}
+			t.log.Errorf("can not watch table file: %s", err.Error())
+			continue
+		case <-t.changed:
+		case <-timer.C:
+		}
+
+		if !timer.Stop() {
+			select {
+			case <-timer.C:
+			default:
+			}
+		}
+		timer.Reset(t.refresh())
+	}
+}
+
+// refresh - reload the table if the file was modified, drop expired routes
+// and get duration until the next route expiration
+func (t *table) refresh() time.Duration {
+	t.Lock()
+	defer t.Unlock()
+
+	if t.fingerprint() != t.version {
+		if err := t.sync(); err != nil {
+			t.log.Errorf("can not reload table: %s", err.Error())
+		}
+	}
+	t.expire()
+
+	var (
+		now  = time.Now()
+		wait = defaultExpireInterval
+	)
+	for _, routes := range t.Data.Routes {
+		for _, r := range routes {
+			if r.TTL <= 0 {
+				continue
+			}
+			if d := r.Updated.Add(r.TTL).Sub(now); d < wait {
+				wait = d
+			}
+		}
+	}
+	// expired routes are removed when ttl is exceeded
+	return max(wait, 0) + time.Millisecond
+}
+
+// stop - stop watching the table file
+func (t *table) stop() {
+	t.stopOnce.Do(func() {
+		close(t.done)
+	})
+	<-t.stopped
+}
+
+// expire - remove expired routes from memory and emit delete events,
+// expired routes are removed from the file on the next save, table lock must be held
+func (t *table) expire() {
+	now := time.Now()
+	for service, routes := range t.Data.Routes {
+		for sum, r := range routes {
+			if !r.expired(now) {
+				continue
+			}
+			t.log.V(5).Infof("route expired in table: %s with addr: %s", r.Route.Service, r.Route.Address)
+			delete(routes, sum)
+			t.watchers.Emit(resolver.EventDelete, r.Route)
+		}
+		if len(routes) == 0 {
+			delete(t.Data.Routes, service)
+		}
+	}
+}
+
 func (t *table) load() error {
 
 	if _, err := os.Stat(t.file); err != nil {
@@ -84,21 +225,75 @@ func (t *table) load() error {
 
 	}
 
+	t.Lock()
+	defer t.Unlock()
+
+	if err := t.sync(); err != nil {
+		t.log.Errorf("can not load table: %s", err.Error())
+	}
+
+	return nil
+}
+
+// sync - read the table file and emit events for routes changed since the last read,
+// table lock must be held
+func (t *table) sync() error {
+	version := t.fingerprint()
+
 	file, err := os.ReadFile(t.file)
 	if err != nil {
-		t.log.Errorf("can not load table: %s", err.Error())
 		return err
 	}
 
-	err = json.Unmarshal(file, &t.Data)
-	if err != nil {
-		t.log.Errorf("can not decode table from file: %s: %s > %s", t.file, string(file), err.Error())
-		return nil
+	data := tableData{Routes: make(map[string]map[string]*Route, 0)}
+	if len(file) > 0 {
+		if err := json.Unmarshal(file, &data); err != nil {
+			return fmt.Errorf("can not decode table from file: %s: %s", t.file, err.Error())
+		}
+		if data.Routes == nil {
+			data.Routes = make(map[string]map[string]*Route, 0)
+		}
+	}
+
+	// skip routes of the services which stopped refreshing registration
+	now := time.Now()
+	for service, routes := range data.Routes {
+		for sum, r := range routes {
+			if r.expired(now) {
+				delete(routes, sum)
+			}
+		}
+		if len(routes) == 0 {
+			delete(data.Routes, service)
+		}
+	}
+
+	for service, routes := range t.Data.Routes {
+		for sum, r := range routes {
+			if _, ok := data.Routes[service][sum]; !ok {
+				t.watchers.Emit(resolver.EventDelete, r.Route)
+			}
+		}
+	}
+
+	for service, routes := range data.Routes {
+		for sum, r := range routes {
+			prev, ok := t.Data.Routes[service][sum]
+			switch {
+			case !ok:
+				t.watchers.Emit(resolver.EventAdd, r.Route)
+			case !reflect.DeepEqual(prev.Route, r.Route):
+				t.watchers.Emit(resolver.EventUpdate, r.Route)
+			}
+		}
 	}
 
+	t.Data = data
+	t.version = version
 	return nil
 }
 
+// save - write the table file, table lock must be held
 func (t *table) save() error {
 	data, err := json.MarshalIndent(t.Data, "", "  ")
 	if err != nil {
@@ -111,28 +306,53 @@ func (t *table) save() error {
 		return err
 	}
 
+	t.version = t.fingerprint()
+
+	select {
+	case t.changed <- struct{}{}:
+	default:
+	}
+
 	return nil
 }
 
+// fingerprint - get table file modification state
+func (t *table) fingerprint() string {
+	info, err := os.Stat(t.file)
+	if err != nil {
+		return ""
+	}
+	return fmt.Sprintf("%d:%d", info.Size(), info.ModTime().UnixNano())
+}
+
 func (t *table) Find(service string) ([]rt.Route, error) {
 	var routes []rt.Route
 
 	t.RLock()
 	defer t.RUnlock()
 
+	now := time.Now()
+
 	if len(service) > 0 {
 		routeMap, ok := t.Data.Routes[service]
 		if !ok {
 			return nil, rt.ErrRouteNotFound
 		}
 		for _, rm := range routeMap {
-			routes = append(routes, rm.Route)
+			if !rm.expired(now) {
+				routes = append(routes, rm.Route)
+			}
+		}
+		if len(routes) == 0 {
+			return nil, rt.ErrRouteNotFound
 		}
 		return routes, nil
 	}
 	for _, serviceRoutes := range t.Data.Routes {
 		for _, sr := range serviceRoutes {
-			routes = append(routes, sr.Route)
+			if !sr.expired(now) {
+				routes = append(routes, sr.Route)
+			}
 		}
 	}
 	return routes, nil
@@ -142,21 +362,15 @@ func (t *table) Create(r rt.Route) error {
 
 	t.log.V(5).Infof("create Route record in table: %s with addr: %s", r.Service, r.Address)
 
-	service := r.Service
-	sum := r.Hash()
-	t.x = 100
-	t.Lock()
-	defer t.Unlock()
-	defer t.save()
+	return t.update(r, 0)
+}
 
-	if _, ok := t.Data.Routes[service]; !ok {
-		t.Data.Routes[service] = make(map[string]*Route)
-	}
+// register - create or refresh route which expires when it is not refreshed during ttl
+func (t *table) register(r rt.Route, ttl time.Duration) error {
 
-	t.Data.Routes[service][sum] = &Route{r, time.Now()}
-	t.Data.Updated = time.Now()
+	t.log.V(5).Infof("register Route record in table: %s with addr: %s", r.Service, r.Address)
 
-	return nil
+	return t.update(r, ttl)
 }
 
 func (t *table) Delete(r rt.Route) error {
@@ -167,6 +381,11 @@ func (t *table) Delete(r rt.Route) error {
 	t.Lock()
 	defer t.Unlock()
 
+	// merge changes made by other processes before modification
+	if err := t.sync(); err != nil {
+		t.log.Errorf("can not load table: %s", err.Error())
+	}
+
 	if _, ok := t.Data.Routes[service]; !ok {
 		return rt.ErrRouteNotFound
 	}
@@ -180,10 +399,18 @@ func (t *table) Delete(r rt.Route) error {
 	if len(t.Data.Routes[service]) == 0 {
 		delete(t.Data.Routes, service)
 	}
-	return nil
+
+	t.Data.Updated = time.Now()
+	t.watchers.Emit(resolver.EventDelete, r)
+
+	return t.save()
 }
 
 func (t *table) Update(r rt.Route) error {
+	return t.update(r, 0)
+}
+
+func (t *table) update(r rt.Route, ttl time.Duration) error {
 
 	service := r.Service
 	sum := r.Hash()
@@ -191,14 +418,25 @@ func (t *table) Update(r rt.Route) error {
 	t.Lock()
 	defer t.Unlock()
 
+	// merge changes made by other processes before modification
+	if err := t.sync(); err != nil {
+		t.log.Errorf("can not load table: %s", err.Error())
+	}
+
 	if _, ok := t.Data.Routes[service]; !ok {
 		t.Data.Routes[service] = make(map[string]*Route)
 	}
 
-	if _, ok := t.Data.Routes[service][sum]; !ok {
-		t.Data.Routes[service][sum] = &Route{r, time.Now()}
-		return nil
+	prev, ok := t.Data.Routes[service][sum]
+	t.Data.Routes[service][sum] = &Route{Route: r, Updated: time.Now(), TTL: ttl}
+	t.Data.Updated = time.Now()
+
+	switch {
+	case !ok:
+		t.watchers.Emit(resolver.EventAdd, r)
+	case !reflect.DeepEqual(prev.Route, r):
+		t.watchers.Emit(resolver.EventUpdate, r)
 	}
-	t.Data.Routes[service][sum] = &Route{r, time.Now()}
-	return nil
+
+	return t.save()
 }
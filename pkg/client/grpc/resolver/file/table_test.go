@@ -0,0 +1,236 @@
+package file
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/lastbackend/toolkit/pkg/client/grpc/resolver"
+	"github.com/lastbackend/toolkit/pkg/client/grpc/resolver/route"
+	"github.com/lastbackend/toolkit/pkg/runtime/logger/empty"
+)
+
+const testService = "test"
+
+func newTestTable(t *testing.T, file string) (*table, *resolver.Watchers) {
+	t.Helper()
+
+	watchers := resolver.NewWatchers()
+	tb, err := newTable(file, empty.NewLogger(), watchers)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(tb.stop)
+
+	return tb, watchers
+}
+
+// writeTable - write table file as other process does, file is replaced by rename when atomic is set
+func writeTable(t *testing.T, file string, atomic bool, routes ...Route) {
+	t.Helper()
+
+	data := tableData{Updated: time.Now(), Routes: make(map[string]map[string]*Route, 0)}
+	for i := range routes {
+		r := routes[i]
+		if data.Routes[r.Route.Service] == nil {
+			data.Routes[r.Route.Service] = make(map[string]*Route, 0)
+		}
+		data.Routes[r.Route.Service][r.Route.Hash()] = &r
+	}
+
+	b, err := json.Marshal(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	name := file
+	if atomic {
+		name = file + ".tmp"
+	}
+	if err := os.WriteFile(name, b, 0644); err != nil {
+		t.Fatal(err)
+	}
+	if atomic {
+		if err := os.Rename(name, file); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+// nextEvent - wait for the next watcher event, nil is returned on timeout
+func nextEvent(w resolver.Watcher, timeout time.Duration) *resolver.Event {
+	events := make(chan *resolver.Event, 1)
+	go func() {
+		e, _ := w.Next()
+		events <- e
+	}()
+
+	select {
+	case e := <-events:
+		return e
+	case <-time.After(timeout):
+		w.Stop()
+		return nil
+	}
+}
+
+func TestTable_Watch(t *testing.T) {
+	r := route.Route{Service: testService, Address: "127.0.0.1:9000"}
+
+	tests := []struct {
+		name   string
+		before []Route
+		change func(t *testing.T, tb *table, file string)
+		event  resolver.EventType
+	}{
+		{
+			"route added by other process",
+			nil,
+			func(t *testing.T, _ *table, file string) {
+				writeTable(t, file, false, Route{Route: r, Updated: time.Now()})
+			},
+			resolver.EventAdd,
+		},
+		{
+			"route removed by other process",
+			[]Route{{Route: r, Updated: time.Now()}},
+			func(t *testing.T, _ *table, file string) {
+				writeTable(t, file, false)
+			},
+			resolver.EventDelete,
+		},
+		{
+			"table file replaced by other process",
+			nil,
+			func(t *testing.T, _ *table, file string) {
+				writeTable(t, file, true, Route{Route: r, Updated: time.Now()})
+			},
+			resolver.EventAdd,
+		},
+		{
+			"registered route expired",
+			nil,
+			func(t *testing.T, tb *table, _ string) {
+				if err := tb.register(r, 50*time.Millisecond); err != nil {
+					t.Fatal(err)
+				}
+			},
+			resolver.EventDelete,
+		},
+		{
+			"route of other process expired",
+			nil,
+			func(t *testing.T, _ *table, file string) {
+				writeTable(t, file, false, Route{Route: r, Updated: time.Now(), TTL: 50 * time.Millisecond})
+			},
+			resolver.EventDelete,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			file := filepath.Join(t.TempDir(), defaultFileName)
+			writeTable(t, file, false, tt.before...)
+
+			tb, watchers := newTestTable(t, file)
+
+			w := watchers.Watch(testService)
+			defer w.Stop()
+
+			tt.change(t, tb, file)
+
+			var last *resolver.Event
+			for e := nextEvent(w, 5*time.Second); e != nil; e = nextEvent(w, 200*time.Millisecond) {
+				last = e
+				if e.Type == tt.event {
+					break
+				}
+			}
+
+			if last == nil {
+				t.Fatal("event: expected", tt.event, "received", "none")
+			}
+			if last.Type != tt.event {
+				t.Error("event: expected", tt.event, "received", last.Type)
+			}
+			if last.Route.Address != r.Address {
+				t.Error("address: expected", r.Address, "received", last.Route.Address)
+			}
+		})
+	}
+}
+
+func TestTable_Stop(t *testing.T) {
+	tests := []struct {
+		name  string
+		stops int
+	}{
+		{"stop", 1},
+		{"repeated stop", 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			file := filepath.Join(t.TempDir(), defaultFileName)
+			tb, watchers := newTestTable(t, file)
+
+			w := watchers.Watch(testService)
+			defer w.Stop()
+
+			for i := 0; i < tt.stops; i++ {
+				tb.stop()
+			}
+
+			select {
+			case <-tb.stopped:
+			default:
+				t.Fatal("watch goroutine: expected", "stopped", "received", "running")
+			}
+
+			writeTable(t, file, false, Route{Route: route.Route{Service: testService, Address: "127.0.0.1:9000"}, Updated: time.Now()})
+
+			if e := nextEvent(w, 100*time.Millisecond); e != nil {
+				t.Error("event: expected", "none", "received", e.Type)
+			}
+		})
+	}
+}
+
+func TestTable_Find(t *testing.T) {
+	var (
+		active  = Route{Route: route.Route{Service: testService, Address: "127.0.0.1:9000"}, Updated: time.Now(), TTL: time.Hour}
+		static  = Route{Route: route.Route{Service: testService, Address: "127.0.0.1:9001"}, Updated: time.Now().Add(-time.Hour)}
+		expired = Route{Route: route.Route{Service: "other", Address: "127.0.0.1:9002"}, Updated: time.Now().Add(-time.Hour), TTL: time.Minute}
+	)
+
+	tests := []struct {
+		name    string
+		service string
+		count   int
+		err     error
+	}{
+		{"service routes", testService, 2, nil},
+		{"all routes", "", 2, nil},
+		{"expired service routes", "other", 0, route.ErrRouteNotFound},
+		{"unknown service", "unknown", 0, route.ErrRouteNotFound},
+	}
+
+	file := filepath.Join(t.TempDir(), defaultFileName)
+	writeTable(t, file, false, active, static, expired)
+
+	tb, _ := newTestTable(t, file)
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			routes, err := tb.Find(tt.service)
+			if err != tt.err {
+				t.Fatal("error: expected", tt.err, "received", err)
+			}
+			if len(routes) != tt.count {
+				t.Error("routes: expected", tt.count, "received", len(routes))
+			}
+		})
+	}
+}
@@ -20,7 +20,7 @@ import (
 	"github.com/lastbackend/toolkit/pkg/client/grpc/resolver/route"
 )
 
-func isMatch(route route.Route, address string) bool {
+func isMatch(route route.Route, address string, metadata map[string]string) bool {
 	match := func(a, b string) bool {
 		if a == "*" || b == "*" || a == b {
 			return true
@@ -39,6 +39,16 @@ func isMatch(route route.Route, address string) bool {
 			return false
 		}
 	}
+	return hasMetadata(route, metadata)
+}
+
+// hasMetadata - check route has all metadata values
+func hasMetadata(route route.Route, metadata map[string]string) bool {
+	for k, v := range metadata {
+		if route.Metadata[k] != v {
+			return false
+		}
+	}
 	return true
 }
 
@@ -47,7 +57,7 @@ func Filter(routes []route.Route, opts LookupOptions) []route.Route {
 	routeMap := make(map[string][]route.Route, 0)
 
 	for _, r := range routes {
-		if isMatch(r, address) {
+		if isMatch(r, address, opts.Metadata) {
 			routeKey := r.Service
 			routeMap[routeKey] = append(routeMap[routeKey], r)
 		}
@@ -59,5 +69,19 @@ func Filter(routes []route.Route, opts LookupOptions) []route.Route {
 		results = append(results, r...)
 	}
 
-	return results
+	if len(opts.Prefer) == 0 {
+		return results
+	}
+
+	preferred := make([]route.Route, 0, len(results))
+	for _, r := range results {
+		if hasMetadata(r, opts.Prefer) {
+			preferred = append(preferred, r)
+		}
+	}
+	if len(preferred) == 0 {
+		return results
+	}
+
+	return preferred
 }
@@ -30,9 +30,10 @@ const (
 )
 
 type Resolver struct {
-	runtime runtime.Runtime
-	table   *table
-	options *Options
+	runtime  runtime.Runtime
+	table    *table
+	watchers *resolver.Watchers
+	options  *Options
 }
 
 type Options struct {
@@ -47,10 +48,12 @@ func NewResolver(runtime runtime.Runtime) resolver.Resolver {
 		runtime.Log().Errorf("Can not parse config %s: $s", prefix, err.Error())
 	}
 
-	table := newTable()
+	watchers := resolver.NewWatchers()
+	table := newTable(watchers)
 	r := &Resolver{
-		table:   table,
-		options: opts,
+		table:    table,
+		watchers: watchers,
+		options:  opts,
 	}
 
 	for _, s := range opts.Endpoints {
@@ -92,3 +95,7 @@ func (c *Resolver) Lookup(service string, opts ...resolver.LookupOption) (route.
 func (c *Resolver) Table() resolver.Table {
 	return c.table
 }
+
+func (c *Resolver) Watch(service string) (resolver.Watcher, error) {
+	return c.watchers.Watch(service), nil
+}
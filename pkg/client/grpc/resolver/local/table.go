@@ -17,6 +17,7 @@ limitations under the License.
 package local
 
 import (
+	"github.com/lastbackend/toolkit/pkg/client/grpc/resolver"
 	rt "github.com/lastbackend/toolkit/pkg/client/grpc/resolver/route"
 	"sync"
 	"time"
@@ -24,8 +25,9 @@ import (
 
 type table struct {
 	sync.RWMutex
-	x      int
-	routes map[string]map[string]*resolverRoute
+	x        int
+	routes   map[string]map[string]*resolverRoute
+	watchers *resolver.Watchers
 }
 
 type resolverRoute struct {
@@ -33,9 +35,10 @@ type resolverRoute struct {
 	updated time.Time
 }
 
-func newTable() *table {
+func newTable(watchers *resolver.Watchers) *table {
 	return &table{
-		routes: make(map[string]map[string]*resolverRoute, 0),
+		routes:   make(map[string]map[string]*resolverRoute, 0),
+		watchers: watchers,
 	}
 }
 
@@ -79,6 +82,7 @@ func (t *table) Create(r rt.Route) error {
 	}
 
 	t.routes[service][sum] = &resolverRoute{r, time.Now()}
+	t.watchers.Emit(resolver.EventAdd, r)
 
 	return nil
 }
@@ -104,6 +108,8 @@ func (t *table) Delete(r rt.Route) error {
 	if len(t.routes[service]) == 0 {
 		delete(t.routes, service)
 	}
+
+	t.watchers.Emit(resolver.EventDelete, r)
 	return nil
 }
 
@@ -121,8 +127,10 @@ func (t *table) Update(r rt.Route) error {
 
 	if _, ok := t.routes[service][sum]; !ok {
 		t.routes[service][sum] = &resolverRoute{r, time.Now()}
+		t.watchers.Emit(resolver.EventAdd, r)
 		return nil
 	}
 	t.routes[service][sum] = &resolverRoute{r, time.Now()}
+	t.watchers.Emit(resolver.EventUpdate, r)
 	return nil
 }
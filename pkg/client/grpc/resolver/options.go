@@ -16,8 +16,47 @@ limitations under the License.
 
 package resolver
 
+import "github.com/lastbackend/toolkit/pkg/client/grpc/resolver/route"
+
 func DefaultOptions() Options {
 	return Options{
 		Cache: false,
 	}
 }
+
+// WithAddress - lookup routes with the address
+func WithAddress(address string) LookupOption {
+	return func(o *LookupOptions) {
+		o.Address = address
+	}
+}
+
+// WithMetadata - lookup routes with the metadata value
+func WithMetadata(key, value string) LookupOption {
+	return func(o *LookupOptions) {
+		if o.Metadata == nil {
+			o.Metadata = make(map[string]string, 0)
+		}
+		o.Metadata[key] = value
+	}
+}
+
+// WithVersion - lookup routes of the service version
+func WithVersion(version string) LookupOption {
+	return WithMetadata(route.MetadataVersion, version)
+}
+
+// PreferMetadata - prefer routes with the metadata value if any of them exists
+func PreferMetadata(key, value string) LookupOption {
+	return func(o *LookupOptions) {
+		if o.Prefer == nil {
+			o.Prefer = make(map[string]string, 0)
+		}
+		o.Prefer[key] = value
+	}
+}
+
+// PreferZone - prefer routes from the zone if any of them exists
+func PreferZone(zone string) LookupOption {
+	return PreferMetadata(route.MetadataZone, zone)
+}
@@ -29,8 +29,11 @@ func (r ResolveType) String() string {
 }
 
 const (
-	LocalResolver  ResolveType = "local"
-	ConsulResolver ResolveType = "consul"
+	LocalResolver     ResolveType = "local"
+	FileResolver      ResolveType = "file"
+	DNSResolver       ResolveType = "dns"
+	EndpointsResolver ResolveType = "endpoints"
+	ConsulResolver    ResolveType = "consul"
 )
 
 var (
@@ -55,6 +58,8 @@ type Resolver interface {
 	OnStart(ctx context.Context) error
 	Table() Table
 	Lookup(service string, opts ...LookupOption) (route.List, error)
+	// Watch - watch route changes of the service, empty service watches all services
+	Watch(service string) (Watcher, error)
 }
 
 type Table interface {
@@ -66,6 +71,10 @@ type Table interface {
 
 type LookupOptions struct {
 	Address string
+	// Metadata - route metadata values which must match
+	Metadata map[string]string
+	// Prefer - route metadata values which are preferred, other routes are used when no route matches
+	Prefer map[string]string
 }
 
 func NewLookup(opts ...LookupOption) LookupOptions {
@@ -22,6 +22,13 @@ import (
 	"github.com/pkg/errors"
 )
 
+const (
+	// MetadataZone - route metadata key of the zone the address is located in
+	MetadataZone = "zone"
+	// MetadataVersion - route metadata key of the service version served on the address
+	MetadataVersion = "version"
+)
+
 var (
 	ErrRouteNotFound  = errors.New("route not found")
 	ErrDuplicateRoute = errors.New("duplicate route")
@@ -30,6 +37,10 @@ var (
 type Route struct {
 	Service string `json:"service"`
 	Address string `json:"address"`
+	// Weight is used by weighted selector, routes without weight have weight 1
+	Weight int `json:"weight,omitempty"`
+	// Metadata is an arbitrary route labels set, e.g. zone or version
+	Metadata map[string]string `json:"metadata,omitempty"`
 }
 
 func (r *Route) Hash() string {
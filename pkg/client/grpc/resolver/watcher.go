@@ -0,0 +1,151 @@
+/*
+Copyright [2014] - [2023] The Last.Backend authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package resolver
+
+import (
+	"sync"
+
+	"github.com/lastbackend/toolkit/pkg/client/grpc/resolver/route"
+	"github.com/pkg/errors"
+)
+
+type EventType int
+
+const (
+	EventAdd EventType = iota
+	EventUpdate
+	EventDelete
+)
+
+func (t EventType) String() string {
+	switch t {
+	case EventAdd:
+		return "add"
+	case EventUpdate:
+		return "update"
+	case EventDelete:
+		return "delete"
+	}
+	return "unknown"
+}
+
+var (
+	ErrWatcherStopped = errors.New("watcher stopped")
+)
+
+// Event - route change emitted by resolver
+type Event struct {
+	Type  EventType
+	Route route.Route
+}
+
+// Watcher - stream of service route changes
+type Watcher interface {
+	// Next blocks until the next event or watcher stop
+	Next() (*Event, error)
+	Stop()
+}
+
+// Watchers - registry of resolver watchers, resolvers use it to emit route changes
+type Watchers struct {
+	mtx   sync.RWMutex
+	items map[*watcher]struct{}
+}
+
+func NewWatchers() *Watchers {
+	return &Watchers{
+		items: make(map[*watcher]struct{}, 0),
+	}
+}
+
+// Watch - watch route changes of the service, empty service watches all services
+func (w *Watchers) Watch(service string) Watcher {
+	item := &watcher{
+		service:  service,
+		watchers: w,
+		notify:   make(chan struct{}, 1),
+		exit:     make(chan struct{}),
+	}
+
+	w.mtx.Lock()
+	w.items[item] = struct{}{}
+	w.mtx.Unlock()
+
+	return item
+}
+
+// Emit - send event to watchers of the route service
+func (w *Watchers) Emit(t EventType, r route.Route) {
+	w.mtx.RLock()
+	defer w.mtx.RUnlock()
+
+	for item := range w.items {
+		if item.service == "" || item.service == r.Service {
+			item.push(Event{Type: t, Route: r})
+		}
+	}
+}
+
+type watcher struct {
+	service  string
+	watchers *Watchers
+
+	mtx    sync.Mutex
+	queue  []Event
+	notify chan struct{}
+	exit   chan struct{}
+	once   sync.Once
+}
+
+func (w *watcher) Next() (*Event, error) {
+	for {
+		w.mtx.Lock()
+		if len(w.queue) > 0 {
+			e := w.queue[0]
+			w.queue = w.queue[1:]
+			w.mtx.Unlock()
+			return &e, nil
+		}
+		w.mtx.Unlock()
+
+		select {
+		case <-w.notify:
+		case <-w.exit:
+			return nil, ErrWatcherStopped
+		}
+	}
+}
+
+func (w *watcher) Stop() {
+	w.once.Do(func() {
+		w.watchers.mtx.Lock()
+		delete(w.watchers.items, w)
+		w.watchers.mtx.Unlock()
+		close(w.exit)
+	})
+}
+
+func (w *watcher) push(e Event) {
+	w.mtx.Lock()
+	w.queue = append(w.queue, e)
+	w.mtx.Unlock()
+
+	select {
+	case w.notify <- struct{}{}:
+	default:
+	}
+}
@@ -0,0 +1,150 @@
+/*
+Copyright [2014] - [2023] The Last.Backend authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package grpc
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/lastbackend/toolkit/pkg/client"
+	"github.com/lastbackend/toolkit/pkg/client/grpc/selector"
+	"github.com/lastbackend/toolkit/pkg/util/backoff"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// newBackoff - get jittered exponential backoff func
+func newBackoff(min, max time.Duration) client.GRPCBackoffFunc {
+	b := &backoff.Backoff{Min: min, Max: max, Jitter: true}
+	return func(_ context.Context, _ *client.GRPCRequest, attempts int) (time.Duration, error) {
+		return b.ForAttempt(float64(attempts - 1)), nil
+	}
+}
+
+// parseCodes - convert code names like UNAVAILABLE or Unavailable to gRPC codes
+func parseCodes(names []string) ([]codes.Code, error) {
+	result := make([]codes.Code, 0, len(names))
+	for _, name := range names {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+		var (
+			normalized = strings.ReplaceAll(strings.ToLower(name), "_", "")
+			found      bool
+		)
+		for c := codes.OK; c <= codes.Unauthenticated; c++ {
+			if strings.ToLower(c.String()) == normalized {
+				result = append(result, c)
+				found = true
+				break
+			}
+		}
+		if !found {
+			return nil, fmt.Errorf("unknown grpc code: %s", name)
+		}
+	}
+	return result, nil
+}
+
+// retry - call fn until it succeeds or retry policy stops retries,
+// idempotent calls are retried on a different address if there is one,
+// other calls are retried on the same address
+func (c *grpcClient) retry(ctx context.Context, req *client.GRPCRequest, next selector.Next, count int,
+	opts client.GRPCCallOptions, fn func(addr string) error) error {
+
+	addr := next()
+
+	for attempt := 0; ; attempt++ {
+		if ctx.Err() != nil {
+			return status.FromContextError(ctx.Err()).Err()
+		}
+
+		err := fn(addr)
+		if err == nil {
+			return nil
+		}
+
+		retry, rErr := c.shouldRetry(ctx, req, attempt, err, opts)
+		if rErr != nil {
+			return rErr
+		}
+		if !retry {
+			return err
+		}
+
+		var delay time.Duration
+		if opts.Backoff != nil {
+			if delay, rErr = opts.Backoff(ctx, req, attempt+1); rErr != nil {
+				return rErr
+			}
+		}
+
+		c.runtime.Log().V(7).Infof("grpc client: retry %s call in %s: attempt %d: %v", req.Method(), delay, attempt+1, err)
+
+		if err := wait(ctx, delay); err != nil {
+			return err
+		}
+
+		if opts.Idempotent {
+			prev := addr
+			addr = next()
+			for i := 1; addr == prev && i < count; i++ {
+				addr = next()
+			}
+		}
+	}
+}
+
+// shouldRetry - check retry policy for the failed attempt
+func (c *grpcClient) shouldRetry(ctx context.Context, req *client.GRPCRequest, attempt int, err error, opts client.GRPCCallOptions) (bool, error) {
+	if attempt >= opts.Retries || ctx.Err() != nil {
+		return false, nil
+	}
+
+	if opts.Retry != nil {
+		return opts.Retry(ctx, req, attempt+1, err)
+	}
+
+	code := status.Code(err)
+	for _, c := range opts.RetryCodes {
+		if c == code {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+// wait - wait for delay or context cancellation
+func wait(ctx context.Context, delay time.Duration) error {
+	if delay <= 0 {
+		return nil
+	}
+
+	t := time.NewTimer(delay)
+	defer t.Stop()
+
+	select {
+	case <-ctx.Done():
+		return status.FromContextError(ctx.Err()).Err()
+	case <-t.C:
+		return nil
+	}
+}
@@ -0,0 +1,162 @@
+package grpc
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/lastbackend/toolkit/pkg/client"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestParseCodes(t *testing.T) {
+	tests := []struct {
+		name  string
+		names []string
+		codes []codes.Code
+		err   bool
+	}{
+		{"env style names", []string{"UNAVAILABLE", "RESOURCE_EXHAUSTED"}, []codes.Code{codes.Unavailable, codes.ResourceExhausted}, false},
+		{"go style names", []string{"DeadlineExceeded", " Aborted "}, []codes.Code{codes.DeadlineExceeded, codes.Aborted}, false},
+		{"empty names skipped", []string{"", "unavailable"}, []codes.Code{codes.Unavailable}, false},
+		{"unknown name", []string{"UNAVAILABLE", "BROKEN"}, nil, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := parseCodes(tt.names)
+			if (err != nil) != tt.err {
+				t.Fatal("error: expected", tt.err, "received", err)
+			}
+			if len(result) != len(tt.codes) {
+				t.Fatal("codes: expected", tt.codes, "received", result)
+			}
+			for i := range result {
+				if result[i] != tt.codes[i] {
+					t.Error("codes: expected", tt.codes, "received", result)
+				}
+			}
+		})
+	}
+}
+
+func TestGrpcClient_Retry(t *testing.T) {
+	tests := []struct {
+		name     string
+		code     codes.Code
+		opts     []client.GRPCCallOption
+		attempts int
+		// max number of attempts sent to a single address
+		perAddr int
+		result  codes.Code
+	}{
+		{
+			"no retries by default",
+			codes.Unavailable,
+			nil,
+			1,
+			1,
+			codes.Unavailable,
+		},
+		{
+			"non-idempotent call retried on the same address",
+			codes.Unavailable,
+			[]client.GRPCCallOption{client.GRPCOptionRetries(3)},
+			4,
+			4,
+			codes.Unavailable,
+		},
+		{
+			"idempotent call retried on a different address",
+			codes.Unavailable,
+			[]client.GRPCCallOption{client.GRPCOptionRetries(3), client.GRPCOptionIdempotent()},
+			4,
+			2,
+			codes.Unavailable,
+		},
+		{
+			"code not in retry codes",
+			codes.InvalidArgument,
+			[]client.GRPCCallOption{client.GRPCOptionRetries(3)},
+			1,
+			1,
+			codes.InvalidArgument,
+		},
+		{
+			"custom retry codes",
+			codes.Aborted,
+			[]client.GRPCCallOption{client.GRPCOptionRetries(2), client.GRPCOptionRetryCodes(codes.Aborted)},
+			3,
+			3,
+			codes.Aborted,
+		},
+		{
+			"retry func overrides retry codes",
+			codes.Unavailable,
+			[]client.GRPCCallOption{
+				client.GRPCOptionRetries(3),
+				client.GRPCOptionRetryFunc(func(ctx context.Context, req *client.GRPCRequest, retryCount int, err error) (bool, error) {
+					return retryCount < 2, nil
+				}),
+			},
+			2,
+			2,
+			codes.Unavailable,
+		},
+		{
+			"backoff error stops retries",
+			codes.Unavailable,
+			[]client.GRPCCallOption{
+				client.GRPCOptionRetries(3),
+				client.GRPCOptionBackoff(func(ctx context.Context, req *client.GRPCRequest, attempts int) (time.Duration, error) {
+					return 0, status.Error(codes.Canceled, "stop")
+				}),
+			},
+			1,
+			1,
+			codes.Canceled,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fail := func(ctx context.Context) error {
+				return status.Error(tt.code, "failed")
+			}
+			a, b := newTestServer(t, fail), newTestServer(t, fail)
+			c := newTestClient(t, nil, a, b)
+
+			err := check(context.Background(), c, tt.opts...)
+			if status.Code(err) != tt.result {
+				t.Error("code: expected", tt.result, "received", status.Code(err))
+			}
+
+			if n := a.Calls() + b.Calls(); n != tt.attempts {
+				t.Error("attempts: expected", tt.attempts, "received", n)
+			}
+			if n := max(a.Calls(), b.Calls()); n != tt.perAddr {
+				t.Error("attempts per address: expected", tt.perAddr, "received", n)
+			}
+		})
+	}
+}
+
+func TestGrpcClient_RetryRecovers(t *testing.T) {
+	var failures = 2
+	srv := newTestServer(t, func(ctx context.Context) error {
+		if failures > 0 {
+			failures--
+			return status.Error(codes.Unavailable, "unavailable")
+		}
+		return nil
+	})
+	c := newTestClient(t, nil, srv)
+
+	if err := check(context.Background(), c, client.GRPCOptionRetries(2)); err != nil {
+		t.Error("error: expected nil, received", err)
+	}
+	if srv.Calls() != 3 {
+		t.Error("attempts: expected", 3, "received", srv.Calls())
+	}
+}
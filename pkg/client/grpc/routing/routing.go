@@ -0,0 +1,257 @@
+/*
+Copyright [2014] - [2023] The Last.Backend authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package routing
+
+import (
+	"encoding/json"
+	"fmt"
+	"hash/crc32"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/lastbackend/toolkit/pkg/client/grpc/resolver/route"
+	"gopkg.in/yaml.v3"
+)
+
+// Rule - send calls of the service matched by the rule to routes with labels,
+// rule matches calls with the header value or the percent of calls if header is not set,
+// percents of the service rules are consecutive shares of calls and can not exceed 100 in total
+type Rule struct {
+	Service string            `json:"service" yaml:"service"`
+	Labels  map[string]string `json:"labels" yaml:"labels"`
+	Header  string            `json:"header,omitempty" yaml:"header,omitempty"`
+	Value   string            `json:"value,omitempty" yaml:"value,omitempty"`
+	Percent float64           `json:"percent,omitempty" yaml:"percent,omitempty"`
+}
+
+// Document - rules file content
+//
+//	rules:
+//	  - service: billing
+//	    labels:
+//	      canary: "true"
+//	    header: x-canary
+//	    value: "true"
+//	  - service: billing
+//	    labels:
+//	      version: v2
+//	    percent: 10
+type Document struct {
+	Rules []Rule `json:"rules" yaml:"rules"`
+}
+
+// match - check the rule against call headers, percent rule matches when the call point
+// falls into the rule share starting from the total percent of previous rules
+func (r Rule) match(headers map[string]string, point, total float64) bool {
+	if r.Header != "" {
+		v, ok := headers[strings.ToLower(r.Header)]
+		return ok && v == r.Value
+	}
+	return r.Percent > 0 && point >= total && point < total+r.Percent
+}
+
+// point - get position of the call in range [0, 100), calls with the same key get the same position
+func point(key string) float64 {
+	if key != "" {
+		return float64(crc32.ChecksumIEEE([]byte(key))%10000) / 100
+	}
+	return rand.Float64() * 100
+}
+
+// Router - apply routing rules to service routes
+type Router struct {
+	rules map[string][]Rule
+}
+
+func New(rules []Rule) *Router {
+	r := &Router{rules: make(map[string][]Rule, 0)}
+	for _, rule := range rules {
+		r.rules[rule.Service] = append(r.rules[rule.Service], rule)
+	}
+	return r
+}
+
+// Route - get routes for the call, the first matched rule of the service selects routes with its labels,
+// calls matched by no rule are sent to routes not labelled by service rules,
+// all routes are returned if there are no routes with required labels
+func (r *Router) Route(service string, routes route.List, headers map[string]string, key string) route.List {
+	rules, ok := r.rules[service]
+	if !ok {
+		return routes
+	}
+
+	var (
+		p     = point(key)
+		total float64
+	)
+
+	for _, rule := range rules {
+		matched := rule.match(headers, p, total)
+		if rule.Header == "" {
+			total += rule.Percent
+		}
+		if !matched {
+			continue
+		}
+		if list := filter(routes, func(rt route.Route) bool { return labelled(rt, rule.Labels) }); len(list) > 0 {
+			return list
+		}
+		return routes
+	}
+
+	list := filter(routes, func(rt route.Route) bool {
+		for _, rule := range rules {
+			if labelled(rt, rule.Labels) {
+				return false
+			}
+		}
+		return true
+	})
+	if len(list) > 0 {
+		return list
+	}
+	return routes
+}
+
+func labelled(r route.Route, labels map[string]string) bool {
+	if len(labels) == 0 {
+		return false
+	}
+	for k, v := range labels {
+		if r.Metadata[k] != v {
+			return false
+		}
+	}
+	return true
+}
+
+func filter(routes route.List, fn func(route.Route) bool) route.List {
+	list := make(route.List, 0, len(routes))
+	for _, r := range routes {
+		if fn(r) {
+			list = append(list, r)
+		}
+	}
+	return list
+}
+
+// Parse - parse rules list separated by semicolon in format <service>:<labels>:<condition>,
+// where labels are <key>=<value> pairs separated by comma and condition is <percent>% or <header>=<value>,
+// e.g. billing:canary=true:x-canary=true;billing:version=v2:10%
+func Parse(s string) ([]Rule, error) {
+	rules := make([]Rule, 0)
+
+	for _, item := range strings.Split(s, ";") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+
+		parts := strings.Split(item, ":")
+		if len(parts) != 3 || parts[0] == "" {
+			return nil, fmt.Errorf("invalid routing rule %q: expected <service>:<labels>:<condition>", item)
+		}
+
+		rule := Rule{
+			Service: parts[0],
+			Labels:  make(map[string]string, 0),
+		}
+
+		for _, label := range strings.Split(parts[1], ",") {
+			k, v, ok := strings.Cut(label, "=")
+			if !ok || k == "" {
+				return nil, fmt.Errorf("invalid routing rule %q: invalid label %q", item, label)
+			}
+			rule.Labels[k] = v
+		}
+
+		cond := parts[2]
+		if p, ok := strings.CutSuffix(cond, "%"); ok {
+			percent, err := strconv.ParseFloat(p, 64)
+			if err != nil || percent < 0 || percent > 100 {
+				return nil, fmt.Errorf("invalid routing rule %q: invalid percent %q", item, cond)
+			}
+			rule.Percent = percent
+		} else {
+			h, v, ok := strings.Cut(cond, "=")
+			if !ok || h == "" {
+				return nil, fmt.Errorf("invalid routing rule %q: invalid condition %q", item, cond)
+			}
+			rule.Header, rule.Value = h, v
+		}
+
+		rules = append(rules, rule)
+	}
+
+	if err := Validate(rules); err != nil {
+		return nil, err
+	}
+
+	return rules, nil
+}
+
+// Load - read rules from JSON or YAML file depending on file extension
+func Load(file string) ([]Rule, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+
+	doc := new(Document)
+	switch strings.ToLower(filepath.Ext(file)) {
+	case ".json":
+		err = json.Unmarshal(data, doc)
+	default:
+		err = yaml.Unmarshal(data, doc)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("can not decode routing rules: %s", err.Error())
+	}
+
+	for i, rule := range doc.Rules {
+		if rule.Service == "" || len(rule.Labels) == 0 {
+			return nil, fmt.Errorf("invalid routing rule %d: service and labels are required", i)
+		}
+		if rule.Percent < 0 || rule.Percent > 100 {
+			return nil, fmt.Errorf("invalid routing rule %d: invalid percent %v", i, rule.Percent)
+		}
+	}
+
+	if err := Validate(doc.Rules); err != nil {
+		return nil, err
+	}
+
+	return doc.Rules, nil
+}
+
+// Validate - check that percent rules of each service do not split more than 100 percent of calls
+func Validate(rules []Rule) error {
+	total := make(map[string]float64, 0)
+	for _, rule := range rules {
+		if rule.Header != "" {
+			continue
+		}
+		total[rule.Service] += rule.Percent
+		if total[rule.Service] > 100 {
+			return fmt.Errorf("invalid routing rules of service %s: total percent %v exceeds 100", rule.Service, total[rule.Service])
+		}
+	}
+	return nil
+}
@@ -0,0 +1,268 @@
+package routing
+
+import (
+	"math"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/lastbackend/toolkit/pkg/client/grpc/resolver/route"
+)
+
+var testRoutes = route.List{
+	{Service: "billing", Address: "10.0.0.1:9000"},
+	{Service: "billing", Address: "10.0.0.2:9000", Metadata: map[string]string{"version": "v2"}},
+	{Service: "billing", Address: "10.0.0.3:9000", Metadata: map[string]string{"version": "v3"}},
+	{Service: "billing", Address: "10.0.0.4:9000", Metadata: map[string]string{"canary": "true"}},
+}
+
+func addresses(routes route.List) string {
+	list := make([]string, 0, len(routes))
+	for _, r := range routes {
+		list = append(list, r.Address)
+	}
+	return strings.Join(list, ",")
+}
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name  string
+		rules string
+		count int
+		err   bool
+	}{
+		{"header and percent rules", "billing:canary=true:x-canary=true;billing:version=v2:10%", 2, false},
+		{"empty rules", " ; ", 0, false},
+		{"percents total 100", "billing:version=v2:60%;billing:version=v3:40%", 2, false},
+		{"header rules not counted in total", "billing:canary=true:x-canary=true;billing:version=v2:100%", 2, false},
+		{"percents of different services", "billing:version=v2:60%;orders:version=v2:60%", 2, false},
+		{"percents total over 100", "billing:version=v2:60%;billing:version=v3:50%", 0, true},
+		{"invalid format", "billing:version=v2", 0, true},
+		{"invalid label", "billing:version:10%", 0, true},
+		{"invalid percent", "billing:version=v2:110%", 0, true},
+		{"invalid condition", "billing:version=v2:x-canary", 0, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rules, err := Parse(tt.rules)
+			if (err != nil) != tt.err {
+				t.Fatal("error: expected", tt.err, "received", err)
+			}
+			if len(rules) != tt.count {
+				t.Error("rules: expected", tt.count, "received", len(rules))
+			}
+		})
+	}
+}
+
+func TestLoad(t *testing.T) {
+	tests := []struct {
+		name  string
+		ext   string
+		data  string
+		count int
+		err   bool
+	}{
+		{
+			"yaml rules",
+			".yaml",
+			"rules:\n  - service: billing\n    labels:\n      version: v2\n    percent: 10\n",
+			1, false,
+		},
+		{
+			"json rules",
+			".json",
+			`{"rules":[{"service":"billing","labels":{"canary":"true"},"header":"x-canary","value":"true"}]}`,
+			1, false,
+		},
+		{
+			"rule without labels",
+			".yaml",
+			"rules:\n  - service: billing\n    percent: 10\n",
+			0, true,
+		},
+		{
+			"invalid percent",
+			".yaml",
+			"rules:\n  - service: billing\n    labels:\n      version: v2\n    percent: -10\n",
+			0, true,
+		},
+		{
+			"percents total over 100",
+			".json",
+			`{"rules":[{"service":"billing","labels":{"version":"v2"},"percent":70},{"service":"billing","labels":{"version":"v3"},"percent":40}]}`,
+			0, true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			file := filepath.Join(t.TempDir(), "rules"+tt.ext)
+			if err := os.WriteFile(file, []byte(tt.data), 0600); err != nil {
+				t.Fatal(err)
+			}
+
+			rules, err := Load(file)
+			if (err != nil) != tt.err {
+				t.Fatal("error: expected", tt.err, "received", err)
+			}
+			if len(rules) != tt.count {
+				t.Error("rules: expected", tt.count, "received", len(rules))
+			}
+		})
+	}
+}
+
+func TestRouter_Route(t *testing.T) {
+	tests := []struct {
+		name    string
+		rules   string
+		service string
+		headers map[string]string
+		routes  string
+	}{
+		{
+			"service without rules",
+			"billing:canary=true:x-canary=true",
+			"orders",
+			nil,
+			"10.0.0.1:9000,10.0.0.2:9000,10.0.0.3:9000,10.0.0.4:9000",
+		},
+		{
+			"header rule matched",
+			"billing:canary=true:x-canary=true",
+			"billing",
+			map[string]string{"x-canary": "true"},
+			"10.0.0.4:9000",
+		},
+		{
+			"unmatched call sent to unlabelled routes",
+			"billing:canary=true:x-canary=true;billing:version=v2:0%",
+			"billing",
+			map[string]string{"x-canary": "false"},
+			"10.0.0.1:9000,10.0.0.3:9000",
+		},
+		{
+			"all calls matched by percent rule",
+			"billing:version=v2:100%",
+			"billing",
+			nil,
+			"10.0.0.2:9000",
+		},
+		{
+			"header rule has priority over percent rule",
+			"billing:canary=true:x-canary=true;billing:version=v2:100%",
+			"billing",
+			map[string]string{"x-canary": "true"},
+			"10.0.0.4:9000",
+		},
+		{
+			"all routes used when labelled routes are missing",
+			"billing:version=v9:100%",
+			"billing",
+			nil,
+			"10.0.0.1:9000,10.0.0.2:9000,10.0.0.3:9000,10.0.0.4:9000",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rules, err := Parse(tt.rules)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			routes := New(rules).Route(tt.service, testRoutes, tt.headers, "")
+			if addrs := addresses(routes); addrs != tt.routes {
+				t.Error("routes: expected", tt.routes, "received", addrs)
+			}
+		})
+	}
+}
+
+func TestRouter_Split(t *testing.T) {
+	const calls = 10000
+
+	tests := []struct {
+		name   string
+		rules  string
+		keyed  bool
+		shares map[string]float64
+	}{
+		{
+			"random calls split by rule percents",
+			"billing:version=v2:10%;billing:version=v3:20%",
+			false,
+			map[string]float64{"10.0.0.2:9000": 10, "10.0.0.3:9000": 20, "10.0.0.1:9000,10.0.0.4:9000": 70},
+		},
+		{
+			"keyed calls split by rule percents",
+			"billing:version=v2:10%;billing:version=v3:20%",
+			true,
+			map[string]float64{"10.0.0.2:9000": 10, "10.0.0.3:9000": 20, "10.0.0.1:9000,10.0.0.4:9000": 70},
+		},
+		{
+			"percents total 100",
+			"billing:version=v2:50%;billing:version=v3:50%",
+			true,
+			map[string]float64{"10.0.0.2:9000": 50, "10.0.0.3:9000": 50},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rules, err := Parse(tt.rules)
+			if err != nil {
+				t.Fatal(err)
+			}
+			r := New(rules)
+
+			counts := make(map[string]int, 0)
+			for i := 0; i < calls; i++ {
+				key := ""
+				if tt.keyed {
+					key = "call-" + strconv.Itoa(i)
+				}
+				counts[addresses(r.Route("billing", testRoutes, nil, key))]++
+			}
+
+			for routes, share := range tt.shares {
+				if received := float64(counts[routes]) * 100 / calls; math.Abs(received-share) > 1.5 {
+					t.Error("share of "+routes+": expected", share, "received", received)
+				}
+			}
+		})
+	}
+}
+
+func TestRouter_StickyKey(t *testing.T) {
+	tests := []struct {
+		name  string
+		rules string
+		key   string
+	}{
+		{"first rule share", "billing:version=v2:50%;billing:version=v3:50%", "user-1"},
+		{"second rule share", "billing:version=v2:50%;billing:version=v3:50%", "user-2"},
+		{"unmatched share", "billing:version=v2:10%", "user-3"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rules, err := Parse(tt.rules)
+			if err != nil {
+				t.Fatal(err)
+			}
+			r := New(rules)
+
+			expected := addresses(r.Route("billing", testRoutes, nil, tt.key))
+			for i := 0; i < 100; i++ {
+				if received := addresses(r.Route("billing", testRoutes, nil, tt.key)); received != expected {
+					t.Fatal("routes: expected", expected, "received", received)
+				}
+			}
+		})
+	}
+}
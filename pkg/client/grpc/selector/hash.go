@@ -0,0 +1,129 @@
+/*
+Copyright [2014] - [2023] The Last.Backend authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package selector
+
+import (
+	"hash/crc32"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/lastbackend/toolkit/pkg/client/grpc/resolver/route"
+)
+
+const (
+	// number of virtual nodes per address on the hash ring
+	defaultHashReplicas = 100
+)
+
+func newHashSelector() Selector {
+	return &consistentHash{
+		rings:    make(map[string]*ring, 0),
+		fallback: newRRSelector(),
+	}
+}
+
+// consistentHash - select address by hash of the call key, calls with the same key
+// are routed to the same address while the address is available.
+// Calls without key are routed with round-robin.
+type consistentHash struct {
+	mtx      sync.Mutex
+	rings    map[string]*ring
+	fallback Selector
+}
+
+type ring struct {
+	addresses string
+	hashes    []uint32
+	nodes     map[uint32]string
+	count     int
+}
+
+func (s *consistentHash) Select(service string, routes route.List, opts ...SelectOption) (Next, error) {
+	if len(routes) == 0 {
+		return nil, ErrNotAvailable
+	}
+
+	o := selectOptions(opts)
+	if o.Key == "" {
+		return s.fallback.Select(service, routes)
+	}
+
+	routes = unique(routes)
+	r := s.ring(service, routes.Addresses())
+
+	var (
+		mtx      sync.Mutex
+		h        = crc32.ChecksumIEEE([]byte(o.Key))
+		i        = sort.Search(len(r.hashes), func(i int) bool { return r.hashes[i] >= h })
+		selected = make(map[string]bool, r.count)
+	)
+
+	return func() string {
+		mtx.Lock()
+		defer mtx.Unlock()
+
+		// walk the ring clockwise to get next distinct address on retries
+		if len(selected) >= r.count {
+			selected = make(map[string]bool, r.count)
+		}
+		for {
+			addr := r.nodes[r.hashes[i%len(r.hashes)]]
+			i++
+			if !selected[addr] {
+				selected[addr] = true
+				return addr
+			}
+		}
+	}, nil
+}
+
+func (s *consistentHash) ring(service string, addresses []string) *ring {
+	sorted := append([]string(nil), addresses...)
+	sort.Strings(sorted)
+	id := strings.Join(sorted, ",")
+
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	if r, ok := s.rings[service]; ok && r.addresses == id {
+		return r
+	}
+
+	r := &ring{
+		addresses: id,
+		hashes:    make([]uint32, 0, len(sorted)*defaultHashReplicas),
+		nodes:     make(map[uint32]string, len(sorted)*defaultHashReplicas),
+	}
+
+	for _, addr := range sorted {
+		r.count++
+		for n := 0; n < defaultHashReplicas; n++ {
+			h := crc32.ChecksumIEEE([]byte(strconv.Itoa(n) + addr))
+			if _, ok := r.nodes[h]; ok {
+				continue
+			}
+			r.nodes[h] = addr
+			r.hashes = append(r.hashes, h)
+		}
+	}
+
+	sort.Slice(r.hashes, func(i, j int) bool { return r.hashes[i] < r.hashes[j] })
+	s.rings[service] = r
+	return r
+}
@@ -0,0 +1,102 @@
+/*
+Copyright [2014] - [2023] The Last.Backend authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package selector
+
+import (
+	"math/rand"
+	"sync"
+
+	"github.com/lastbackend/toolkit/pkg/client/grpc/resolver/route"
+)
+
+func newLeastOutstandingSelector() Selector {
+	return &leastOutstanding{
+		outstanding: make(map[string]int, 0),
+	}
+}
+
+// leastOutstanding - select address with the least number of outstanding requests,
+// requests are counted with Tracker Start and Done calls
+type leastOutstanding struct {
+	mtx         sync.Mutex
+	outstanding map[string]int
+}
+
+func (s *leastOutstanding) Select(service string, routes route.List, _ ...SelectOption) (Next, error) {
+	if len(routes) == 0 {
+		return nil, ErrNotAvailable
+	}
+
+	routes = unique(routes)
+	addresses := routes.Addresses()
+	selected := make(map[string]bool, len(addresses))
+
+	return func() string {
+		s.mtx.Lock()
+		defer s.mtx.Unlock()
+
+		// every next call returns another address until all addresses are selected
+		if len(selected) >= len(addresses) {
+			selected = make(map[string]bool, len(addresses))
+		}
+
+		var (
+			result string
+			min    = -1
+			ties   = 0
+		)
+
+		for _, addr := range addresses {
+			if selected[addr] {
+				continue
+			}
+			n := s.outstanding[key(service, addr)]
+			switch {
+			case min < 0 || n < min:
+				min, result, ties = n, addr, 1
+			case n == min:
+				// pick one of addresses with equal load at random
+				ties++
+				if rand.Intn(ties) == 0 {
+					result = addr
+				}
+			}
+		}
+
+		selected[result] = true
+		return result
+	}, nil
+}
+
+func (s *leastOutstanding) Start(service, address string) {
+	s.mtx.Lock()
+	s.outstanding[key(service, address)]++
+	s.mtx.Unlock()
+}
+
+func (s *leastOutstanding) Done(service, address string) {
+	s.mtx.Lock()
+	k := key(service, address)
+	if s.outstanding[k]--; s.outstanding[k] <= 0 {
+		delete(s.outstanding, k)
+	}
+	s.mtx.Unlock()
+}
+
+func key(service, address string) string {
+	return service + "|" + address
+}
@@ -18,6 +18,8 @@ package selector
 
 import (
 	"math/rand"
+
+	"github.com/lastbackend/toolkit/pkg/client/grpc/resolver/route"
 )
 
 func newRandomSelector() Selector {
@@ -26,14 +28,16 @@ func newRandomSelector() Selector {
 
 type random struct{}
 
-func (s *random) Select(routes []string) (Next, error) {
+func (s *random) Select(_ string, routes route.List, _ ...SelectOption) (Next, error) {
 	if len(routes) == 0 {
 		return nil, ErrNotAvailable
 	}
+	routes = unique(routes)
+	addresses := routes.Addresses()
 	return func() string {
-		if len(routes) == 1 {
-			return routes[0]
+		if len(addresses) == 1 {
+			return addresses[0]
 		}
-		return routes[rand.Intn(len(routes))]
+		return addresses[rand.Intn(len(addresses))]
 	}, nil
 }
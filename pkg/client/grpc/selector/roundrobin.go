@@ -17,23 +17,44 @@ limitations under the License.
 package selector
 
 import (
-	"math/rand"
+	"sync"
+
+	"github.com/lastbackend/toolkit/pkg/client/grpc/resolver/route"
 )
 
 func newRRSelector() Selector {
-	return new(roundRobin)
+	return &roundRobin{
+		counters: make(map[string]*uint64, 0),
+	}
 }
 
-type roundRobin struct{}
+// roundRobin - round-robin over service addresses, counter is shared between calls of the service
+type roundRobin struct {
+	mtx      sync.Mutex
+	counters map[string]*uint64
+}
 
-func (s *roundRobin) Select(routes []string) (Next, error) {
+func (s *roundRobin) Select(service string, routes route.List, _ ...SelectOption) (Next, error) {
 	if len(routes) == 0 {
 		return nil, ErrNotAvailable
 	}
-	i := rand.Intn(len(routes))
+
+	routes = unique(routes)
+	addresses := routes.Addresses()
+
+	s.mtx.Lock()
+	counter, ok := s.counters[service]
+	if !ok {
+		counter = new(uint64)
+		s.counters[service] = counter
+	}
+	s.mtx.Unlock()
+
 	return func() string {
-		route := routes[i%len(routes)]
-		i++
-		return route
+		s.mtx.Lock()
+		i := *counter
+		*counter++
+		s.mtx.Unlock()
+		return addresses[i%uint64(len(addresses))]
 	}, nil
 }
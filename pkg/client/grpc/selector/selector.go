@@ -17,6 +17,9 @@ limitations under the License.
 package selector
 
 import (
+	"strings"
+
+	"github.com/lastbackend/toolkit/pkg/client/grpc/resolver/route"
 	"github.com/pkg/errors"
 )
 
@@ -25,6 +28,9 @@ type Type int
 const (
 	Random Type = iota
 	RoundRobin
+	LeastOutstanding
+	Weighted
+	ConsistentHash
 )
 
 var (
@@ -32,20 +38,92 @@ var (
 	ErrNotAvailable        = errors.New("not available")
 )
 
+// Selector - select service address for the call, selectors keep state per service,
+// routes with duplicated addresses are selected as one route
 type Selector interface {
-	Select([]string) (Next, error)
+	Select(service string, routes route.List, opts ...SelectOption) (Next, error)
+}
+
+// Tracker - selector which tracks outstanding requests per service address
+type Tracker interface {
+	Start(service, address string)
+	Done(service, address string)
+}
+
+// Pruner - selector which keeps state per service address, Prune is called with all resolved
+// routes of the service to remove state of addresses which are not resolved anymore,
+// routes passed to Select can be a subset filtered for the call
+type Pruner interface {
+	Prune(service string, routes route.List)
 }
 
 type Next func() string
 
+type SelectOptions struct {
+	// Key is used by consistent hash selector to route calls with the same key to the same address
+	Key string
+}
+
+type SelectOption func(*SelectOptions)
+
+func WithKey(key string) SelectOption {
+	return func(o *SelectOptions) {
+		o.Key = key
+	}
+}
+
 func New(t Type) (selector Selector, err error) {
 	switch t {
 	case Random:
 		selector = newRandomSelector()
 	case RoundRobin:
 		selector = newRRSelector()
+	case LeastOutstanding:
+		selector = newLeastOutstandingSelector()
+	case Weighted:
+		selector = newWeightedSelector()
+	case ConsistentHash:
+		selector = newHashSelector()
 	default:
 		err = ErrSelectorNotDetected
 	}
 	return selector, err
 }
+
+// Parse - get selector type by name [random, round_robin, least_outstanding, weighted, consistent_hash]
+func Parse(name string) (Type, error) {
+	switch strings.ReplaceAll(strings.ToLower(name), "-", "_") {
+	case "random":
+		return Random, nil
+	case "round_robin", "roundrobin":
+		return RoundRobin, nil
+	case "least_outstanding", "least_request":
+		return LeastOutstanding, nil
+	case "weighted":
+		return Weighted, nil
+	case "consistent_hash", "hash":
+		return ConsistentHash, nil
+	}
+	return 0, ErrSelectorNotDetected
+}
+
+func selectOptions(opts []SelectOption) SelectOptions {
+	o := SelectOptions{}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return o
+}
+
+// unique - remove routes with duplicated addresses keeping the first route and the order
+func unique(routes route.List) route.List {
+	seen := make(map[string]bool, len(routes))
+	result := make(route.List, 0, len(routes))
+	for _, r := range routes {
+		if !seen[r.Address] {
+			seen[r.Address] = true
+			result = append(result, r)
+		}
+	}
+	return result
+}
@@ -0,0 +1,324 @@
+package selector
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/lastbackend/toolkit/pkg/client/grpc/resolver/route"
+)
+
+const testService = "test"
+
+func testRoutes(addresses ...string) route.List {
+	routes := make(route.List, 0, len(addresses))
+	for _, addr := range addresses {
+		routes = append(routes, route.Route{Service: testService, Address: addr})
+	}
+	return routes
+}
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name     string
+		selector string
+		t        Type
+		err      error
+	}{
+		{"random", "random", Random, nil},
+		{"round robin", "round_robin", RoundRobin, nil},
+		{"least outstanding with dash", "least-outstanding", LeastOutstanding, nil},
+		{"weighted upper case", "WEIGHTED", Weighted, nil},
+		{"consistent hash alias", "hash", ConsistentHash, nil},
+		{"unknown selector", "fastest", 0, ErrSelectorNotDetected},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			st, err := Parse(tt.selector)
+			if err != tt.err {
+				t.Fatal("error: expected", tt.err, "received", err)
+			}
+			if st != tt.t {
+				t.Error("type: expected", tt.t, "received", st)
+			}
+		})
+	}
+}
+
+func TestSelector_Select(t *testing.T) {
+	tests := []struct {
+		name     string
+		selector Type
+		routes   route.List
+		opts     []SelectOption
+		calls    int
+		expected []string
+	}{
+		{
+			"round robin",
+			RoundRobin,
+			testRoutes("a", "b", "c"),
+			nil,
+			4,
+			[]string{"a", "b", "c", "a"},
+		},
+		{
+			"round robin duplicated addresses",
+			RoundRobin,
+			testRoutes("a", "a", "b"),
+			nil,
+			4,
+			[]string{"a", "b", "a", "b"},
+		},
+		{
+			"least outstanding returns every address before repeating",
+			LeastOutstanding,
+			testRoutes("a", "a", "a"),
+			nil,
+			3,
+			[]string{"a", "a", "a"},
+		},
+		{
+			"weighted",
+			Weighted,
+			route.List{{Service: testService, Address: "a", Weight: 2}, {Service: testService, Address: "b"}},
+			nil,
+			3,
+			[]string{"a", "b", "a"},
+		},
+		{
+			"weighted duplicated addresses",
+			Weighted,
+			testRoutes("a", "a", "b"),
+			nil,
+			4,
+			[]string{"a", "b", "a", "b"},
+		},
+		{
+			"consistent hash walks distinct addresses",
+			ConsistentHash,
+			testRoutes("a", "a"),
+			[]SelectOption{WithKey("session")},
+			2,
+			[]string{"a", "a"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, err := New(tt.selector)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			next, err := s.Select(testService, tt.routes, tt.opts...)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			for i := 0; i < tt.calls; i++ {
+				if addr := next(); addr != tt.expected[i] {
+					t.Error("address", i, ": expected", tt.expected[i], "received", addr)
+				}
+			}
+		})
+	}
+}
+
+func TestSelector_SelectEmpty(t *testing.T) {
+	tests := []struct {
+		name     string
+		selector Type
+	}{
+		{"random", Random},
+		{"round robin", RoundRobin},
+		{"least outstanding", LeastOutstanding},
+		{"weighted", Weighted},
+		{"consistent hash", ConsistentHash},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, err := New(tt.selector)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if _, err := s.Select(testService, nil); err != ErrNotAvailable {
+				t.Error("error: expected", ErrNotAvailable, "received", err)
+			}
+		})
+	}
+}
+
+func TestSelector_ConcurrentNext(t *testing.T) {
+	tests := []struct {
+		name     string
+		selector Type
+		opts     []SelectOption
+	}{
+		{"random", Random, nil},
+		{"round robin", RoundRobin, nil},
+		{"least outstanding", LeastOutstanding, nil},
+		{"weighted", Weighted, nil},
+		{"consistent hash", ConsistentHash, []SelectOption{WithKey("session")}},
+	}
+
+	routes := testRoutes("a", "b", "b", "c")
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, err := New(tt.selector)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			next, err := s.Select(testService, routes, tt.opts...)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var wg sync.WaitGroup
+			for i := 0; i < 8; i++ {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					for j := 0; j < 100; j++ {
+						if addr := next(); addr == "" {
+							t.Error("address: expected", "not empty", "received", addr)
+							return
+						}
+					}
+				}()
+			}
+			wg.Wait()
+		})
+	}
+}
+
+func TestLeastOutstanding_Select(t *testing.T) {
+	tests := []struct {
+		name        string
+		outstanding map[string]int
+		expected    []string
+	}{
+		{"least loaded first", map[string]int{"a": 2, "b": 0, "c": 1}, []string{"b", "c", "a"}},
+		{"done requests are released", map[string]int{"a": 1, "b": 2}, []string{"a", "b"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newLeastOutstandingSelector()
+			tracker := s.(Tracker)
+
+			for addr, n := range tt.outstanding {
+				for i := 0; i < n+1; i++ {
+					tracker.Start(testService, addr)
+				}
+				tracker.Done(testService, addr)
+			}
+
+			next, err := s.Select(testService, testRoutes(tt.expected...))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			for i, expected := range tt.expected {
+				if addr := next(); addr != expected {
+					t.Error("address", i, ": expected", expected, "received", addr)
+				}
+			}
+		})
+	}
+}
+
+func TestConsistentHash_Select(t *testing.T) {
+	tests := []struct {
+		name   string
+		before route.List
+		after  route.List
+		same   bool
+	}{
+		{"same addresses", testRoutes("a", "b", "c"), testRoutes("c", "b", "a"), true},
+		{"address added", testRoutes("a", "b"), testRoutes("a", "b", "c", "d", "e", "f", "g", "h"), false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newHashSelector()
+
+			sticky := 0
+			for i := 0; i < 100; i++ {
+				key := WithKey(string(rune('a'+i%26)) + string(rune('a'+i/26)))
+
+				before, err := s.Select(testService, tt.before, key)
+				if err != nil {
+					t.Fatal(err)
+				}
+				after, err := s.Select(testService, tt.after, key)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if before() == after() {
+					sticky++
+				}
+			}
+
+			if same := sticky == 100; same != tt.same {
+				t.Error("same addresses: expected", tt.same, "received", same, sticky)
+			}
+		})
+	}
+}
+
+func TestWeighted_Prune(t *testing.T) {
+	tests := []struct {
+		name   string
+		before route.List
+		after  route.List
+		// after routes are resolved routes passed to Prune instead of filtered routes passed to Select
+		prune   bool
+		current int
+	}{
+		{"addresses kept", testRoutes("a", "b"), testRoutes("a", "b"), true, 2},
+		{"address removed", testRoutes("a", "b", "c"), testRoutes("a"), true, 1},
+		{"all addresses replaced", testRoutes("a", "b"), testRoutes("c"), true, 0},
+		{"filtered routes keep weights", testRoutes("a", "b", "c"), testRoutes("a"), false, 3},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newWeightedSelector().(*weighted)
+
+			next, err := s.Select(testService, tt.before)
+			if err != nil {
+				t.Fatal(err)
+			}
+			next()
+
+			// other service weights are not pruned
+			next, err = s.Select("other", testRoutes("x"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			next()
+
+			if tt.prune {
+				s.Prune(testService, tt.after)
+			} else {
+				next, err = s.Select(testService, tt.after)
+				if err != nil {
+					t.Fatal(err)
+				}
+				next()
+			}
+
+			s.mtx.Lock()
+			current := len(s.current) - 1
+			s.mtx.Unlock()
+
+			if current != tt.current {
+				t.Error("current weights: expected", tt.current, "received", current)
+			}
+		})
+	}
+}
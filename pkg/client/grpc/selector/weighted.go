@@ -0,0 +1,92 @@
+/*
+Copyright [2014] - [2023] The Last.Backend authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package selector
+
+import (
+	"strings"
+	"sync"
+
+	"github.com/lastbackend/toolkit/pkg/client/grpc/resolver/route"
+)
+
+func newWeightedSelector() Selector {
+	return &weighted{
+		current: make(map[string]int, 0),
+	}
+}
+
+// weighted - smooth weighted round-robin over service addresses, routes without weight have weight 1.
+// Current weights of addresses not passed to Select are kept, so subsets of routes filtered
+// for the call share the state, weights of addresses removed from resolver are pruned by Prune
+type weighted struct {
+	mtx     sync.Mutex
+	current map[string]int
+}
+
+func (s *weighted) Select(service string, routes route.List, _ ...SelectOption) (Next, error) {
+	if len(routes) == 0 {
+		return nil, ErrNotAvailable
+	}
+
+	routes = unique(routes)
+
+	return func() string {
+		s.mtx.Lock()
+		defer s.mtx.Unlock()
+
+		var (
+			total int
+			best  = -1
+		)
+
+		for i, r := range routes {
+			w := r.Weight
+			if w <= 0 {
+				w = 1
+			}
+			total += w
+
+			k := key(service, r.Address)
+			s.current[k] += w
+			if best < 0 || s.current[k] > s.current[key(service, routes[best].Address)] {
+				best = i
+			}
+		}
+
+		s.current[key(service, routes[best].Address)] -= total
+		return routes[best].Address
+	}, nil
+}
+
+// Prune - remove current weights of the service addresses which are not in routes anymore
+func (s *weighted) Prune(service string, routes route.List) {
+	addresses := make(map[string]bool, len(routes))
+	for _, r := range routes {
+		addresses[key(service, r.Address)] = true
+	}
+
+	prefix := key(service, "")
+
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	for k := range s.current {
+		if strings.HasPrefix(k, prefix) && !addresses[k] {
+			delete(s.current, k)
+		}
+	}
+}
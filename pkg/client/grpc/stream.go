@@ -19,8 +19,12 @@ package grpc
 import (
 	"github.com/lastbackend/toolkit/pkg/client"
 	"google.golang.org/grpc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
 
 	"context"
+	"io"
+	"strings"
 	"sync"
 )
 
@@ -32,7 +36,10 @@ type stream struct {
 
 	request *client.GRPCRequest
 	conn    *poolConn
-	close   func(err error)
+	finish  func(err error)
+	// serverStreams is false for methods with a single response, such stream is finished by the response
+	serverStreams bool
+	once          sync.Once
 }
 
 func (s *stream) Context() context.Context {
@@ -48,9 +55,39 @@ func (s *stream) SendMsg(msg interface{}) error {
 }
 
 func (s *stream) RecvMsg(msg interface{}) (err error) {
-	return s.ClientStream.RecvMsg(msg)
+	err = s.ClientStream.RecvMsg(msg)
+	switch {
+	case err == io.EOF:
+		s.done(nil)
+	case err != nil:
+		s.done(err)
+	case !s.serverStreams:
+		s.done(nil)
+	}
+	return err
+}
+
+// done - finish the stream once on the first terminal result
+func (s *stream) done(err error) {
+	if s.finish != nil {
+		s.once.Do(func() {
+			s.finish(err)
+		})
+	}
 }
 
 func (s *stream) CloseSend() error {
 	return s.ClientStream.CloseSend()
 }
+
+// serverStreams - check if method of the registered proto service streams responses,
+// methods of unknown services are handled as bidirectional streams
+func serverStreams(method string) bool {
+	name := strings.ReplaceAll(strings.TrimPrefix(method, "/"), "/", ".")
+	d, err := protoregistry.GlobalFiles.FindDescriptorByName(protoreflect.FullName(name))
+	if err != nil {
+		return true
+	}
+	m, ok := d.(protoreflect.MethodDescriptor)
+	return !ok || m.IsStreamingServer()
+}
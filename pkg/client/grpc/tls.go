@@ -0,0 +1,134 @@
+/*
+Copyright [2014] - [2023] The Last.Backend authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package grpc
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"os"
+
+	"github.com/lastbackend/toolkit/pkg/client"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+	grpc_md "google.golang.org/grpc/metadata"
+)
+
+type TLSOptions struct {
+	Enabled            bool     `env:"TLS_ENABLED" envDefault:"false" comment:"Enable TLS for GRPC client connections"`
+	CAFile             string   `env:"TLS_CA_FILE" comment:"Set path to CA bundle used to verify servers certificates (default system roots)"`
+	CertFile           string   `env:"TLS_CERT_FILE" comment:"Set path to client certificate file used for mTLS"`
+	KeyFile            string   `env:"TLS_KEY_FILE" comment:"Set path to client private key file used for mTLS"`
+	ServerName         string   `env:"TLS_SERVER_NAME" comment:"Override server name used to verify servers certificates"`
+	InsecureSkipVerify bool     `env:"TLS_INSECURE_SKIP_VERIFY" envDefault:"false" comment:"Skip servers certificates verification, use for development only"`
+	Plaintext          []string `env:"TLS_PLAINTEXT_SERVICES" envSeparator:"," comment:"Set services connected without TLS when TLS is enabled (svc1,svc2)"`
+	Services           []string `env:"TLS_SERVICES" envSeparator:"," comment:"Set services connected with TLS when TLS is disabled (svc1,svc2)"`
+}
+
+type AuthOptions struct {
+	TokenMetadata string `env:"AUTH_TOKEN_METADATA" comment:"Set metadata key holding bearer token sent with call credentials"`
+	Token         string `env:"AUTH_TOKEN" comment:"Set static bearer token sent with call credentials when metadata has no token"`
+	AllowInsecure bool   `env:"AUTH_ALLOW_INSECURE" envDefault:"false" comment:"Allow sending bearer token over plaintext connections, use for development only"`
+}
+
+// newTLSConfig - create client TLS config from files defined in options
+func newTLSConfig(opts TLSOptions) (*tls.Config, error) {
+	cfg := &tls.Config{
+		ServerName:         opts.ServerName,
+		InsecureSkipVerify: opts.InsecureSkipVerify,
+		MinVersion:         tls.VersionTLS12,
+	}
+
+	if opts.CAFile != "" {
+		data, err := os.ReadFile(opts.CAFile)
+		if err != nil {
+			return nil, fmt.Errorf("can not read ca file: %v", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(data) {
+			return nil, fmt.Errorf("can not parse ca file %s", opts.CAFile)
+		}
+		cfg.RootCAs = pool
+	}
+
+	if opts.CertFile != "" || opts.KeyFile != "" {
+		cert, err := tls.LoadX509KeyPair(opts.CertFile, opts.KeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("can not load client certificate: %v", err)
+		}
+		cfg.Certificates = []tls.Certificate{cert}
+	}
+
+	return cfg, nil
+}
+
+// secure - check if connections to the service use TLS
+func (c *grpcClient) secure(service string) bool {
+	if c.tls == nil {
+		return false
+	}
+	if c.opts.TLS.Enabled {
+		return !contains(c.opts.TLS.Plaintext, service)
+	}
+	return contains(c.opts.TLS.Services, service)
+}
+
+// transportCredentials - get transport credentials for connections to the service
+func (c *grpcClient) transportCredentials(service string) credentials.TransportCredentials {
+	if c.secure(service) {
+		return credentials.NewTLS(c.tls)
+	}
+	return insecure.NewCredentials()
+}
+
+// authorize - attach bearer token from call metadata as call credentials,
+// the token is always removed from headers to not be sent twice or leaked over plaintext connections.
+// Token is not attached to calls over plaintext connections unless it is allowed.
+func (c *grpcClient) authorize(ctx context.Context, service string, headers map[string]string, opts *client.GRPCCallOptions) {
+	token := c.opts.Auth.Token
+	if key := c.opts.Auth.TokenMetadata; key != "" {
+		v, ok := headers[key]
+		if ok {
+			delete(headers, key)
+		} else if md, _ := grpc_md.FromOutgoingContext(ctx); len(md.Get(key)) > 0 {
+			// token of forwarded metadata, e.g. proxied call
+			v, ok = md.Get(key)[0], true
+		}
+		if ok && v != "" {
+			token = v
+		}
+	}
+
+	if token == "" || opts.Credentials != nil || (!c.opts.Auth.AllowInsecure && !c.secure(service)) {
+		return
+	}
+
+	opts.Credentials = client.BearerToken{
+		Token:         token,
+		AllowInsecure: c.opts.Auth.AllowInsecure,
+	}
+}
+
+func contains(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
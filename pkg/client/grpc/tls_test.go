@@ -0,0 +1,386 @@
+package grpc
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/pem"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/lastbackend/toolkit/pkg/client"
+	util_tls "github.com/lastbackend/toolkit/pkg/util/tls"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	grpc_md "google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// writeCertificate - write certificate and private key PEM files to the directory
+func writeCertificate(t *testing.T, dir, name string, cert tls.Certificate) (string, string) {
+	t.Helper()
+
+	key, err := x509.MarshalPKCS8PrivateKey(cert.PrivateKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	certFile := filepath.Join(dir, name+".crt")
+	keyFile := filepath.Join(dir, name+".key")
+
+	if err := os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: cert.Certificate[0]}), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: key}), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	return certFile, keyFile
+}
+
+func newTestCertificate(t *testing.T, host ...string) tls.Certificate {
+	t.Helper()
+
+	cert, err := util_tls.Certificate(host...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return cert
+}
+
+func TestGrpcClient_TLS(t *testing.T) {
+	var (
+		dir        = t.TempDir()
+		serverCert = newTestCertificate(t, "127.0.0.1")
+		clientCert = newTestCertificate(t, "client")
+		otherCert  = newTestCertificate(t, "other")
+	)
+
+	serverFile, _ := writeCertificate(t, dir, "server", serverCert)
+	clientFile, clientKey := writeCertificate(t, dir, "client", clientCert)
+	otherFile, otherKey := writeCertificate(t, dir, "other", otherCert)
+
+	leaf, err := x509.ParseCertificate(clientCert.Certificate[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	clientCAs := x509.NewCertPool()
+	clientCAs.AddCert(leaf)
+
+	s := newTestServer(t, nil, grpc.Creds(credentials.NewTLS(&tls.Config{
+		Certificates: []tls.Certificate{serverCert},
+		ClientCAs:    clientCAs,
+		ClientAuth:   tls.RequireAndVerifyClientCert,
+		MinVersion:   tls.VersionTLS12,
+	})))
+
+	tests := []struct {
+		name  string
+		env   map[string]string
+		code  codes.Code
+		calls int
+	}{
+		{
+			"mutual tls handshake",
+			map[string]string{
+				"GRPC_CLIENT_TLS_ENABLED":   "true",
+				"GRPC_CLIENT_TLS_CA_FILE":   serverFile,
+				"GRPC_CLIENT_TLS_CERT_FILE": clientFile,
+				"GRPC_CLIENT_TLS_KEY_FILE":  clientKey,
+			},
+			codes.OK,
+			1,
+		},
+		{
+			"tls enabled for the service only",
+			map[string]string{
+				"GRPC_CLIENT_TLS_SERVICES":  testService,
+				"GRPC_CLIENT_TLS_CA_FILE":   serverFile,
+				"GRPC_CLIENT_TLS_CERT_FILE": clientFile,
+				"GRPC_CLIENT_TLS_KEY_FILE":  clientKey,
+			},
+			codes.OK,
+			1,
+		},
+		{
+			"client without certificate rejected",
+			map[string]string{
+				"GRPC_CLIENT_TLS_ENABLED": "true",
+				"GRPC_CLIENT_TLS_CA_FILE": serverFile,
+			},
+			codes.Unavailable,
+			0,
+		},
+		{
+			"client with untrusted certificate rejected",
+			map[string]string{
+				"GRPC_CLIENT_TLS_ENABLED":   "true",
+				"GRPC_CLIENT_TLS_CA_FILE":   serverFile,
+				"GRPC_CLIENT_TLS_CERT_FILE": otherFile,
+				"GRPC_CLIENT_TLS_KEY_FILE":  otherKey,
+			},
+			codes.Unavailable,
+			0,
+		},
+		{
+			"untrusted server certificate",
+			map[string]string{
+				"GRPC_CLIENT_TLS_ENABLED":   "true",
+				"GRPC_CLIENT_TLS_CA_FILE":   otherFile,
+				"GRPC_CLIENT_TLS_CERT_FILE": clientFile,
+				"GRPC_CLIENT_TLS_KEY_FILE":  clientKey,
+			},
+			codes.Unavailable,
+			0,
+		},
+		{
+			"plaintext connection to tls server",
+			map[string]string{
+				"GRPC_CLIENT_TLS_ENABLED":            "true",
+				"GRPC_CLIENT_TLS_CA_FILE":            serverFile,
+				"GRPC_CLIENT_TLS_PLAINTEXT_SERVICES": testService,
+			},
+			codes.Unavailable,
+			0,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := newTestClient(t, tt.env, s)
+
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+
+			before := s.Calls()
+
+			err := check(ctx, c)
+			if status.Code(err) != tt.code {
+				t.Error("code: expected", tt.code, "received", status.Code(err), err)
+			}
+			if calls := s.Calls() - before; calls != tt.calls {
+				t.Error("server calls: expected", tt.calls, "received", calls)
+			}
+		})
+	}
+}
+
+func TestNewClient_TLS(t *testing.T) {
+	dir := t.TempDir()
+	certFile, keyFile := writeCertificate(t, dir, "client", newTestCertificate(t, "client"))
+
+	invalidFile := filepath.Join(dir, "invalid.crt")
+	if err := os.WriteFile(invalidFile, []byte("invalid"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name string
+		env  map[string]string
+		err  bool
+	}{
+		{
+			"tls disabled with invalid files",
+			map[string]string{"GRPC_CLIENT_TLS_CA_FILE": invalidFile},
+			false,
+		},
+		{
+			"valid certificate",
+			map[string]string{
+				"GRPC_CLIENT_TLS_ENABLED":   "true",
+				"GRPC_CLIENT_TLS_CA_FILE":   certFile,
+				"GRPC_CLIENT_TLS_CERT_FILE": certFile,
+				"GRPC_CLIENT_TLS_KEY_FILE":  keyFile,
+			},
+			false,
+		},
+		{
+			"missing ca file",
+			map[string]string{
+				"GRPC_CLIENT_TLS_ENABLED": "true",
+				"GRPC_CLIENT_TLS_CA_FILE": filepath.Join(dir, "missing.crt"),
+			},
+			true,
+		},
+		{
+			"invalid ca file",
+			map[string]string{
+				"GRPC_CLIENT_TLS_ENABLED": "true",
+				"GRPC_CLIENT_TLS_CA_FILE": invalidFile,
+			},
+			true,
+		},
+		{
+			"certificate without key",
+			map[string]string{
+				"GRPC_CLIENT_TLS_SERVICES":  testService,
+				"GRPC_CLIENT_TLS_CERT_FILE": certFile,
+			},
+			true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, err := NewClient(context.Background(), testRuntime{environment: tt.env})
+			if (err != nil) != tt.err {
+				t.Fatal("error: expected", tt.err, "received", err)
+			}
+			if (c == nil) != tt.err {
+				t.Error("client: expected", !tt.err, "received", c != nil)
+			}
+		})
+	}
+}
+
+func TestGrpcClient_Authorize(t *testing.T) {
+	const header = "x-auth-token"
+
+	tests := []struct {
+		name    string
+		env     map[string]string
+		headers map[string]string
+		opts    client.GRPCCallOptions
+		token   string
+	}{
+		{
+			"token from metadata over tls",
+			map[string]string{"GRPC_CLIENT_TLS_ENABLED": "true"},
+			map[string]string{header: "metadata"},
+			client.GRPCCallOptions{},
+			"metadata",
+		},
+		{
+			"static token over tls",
+			map[string]string{"GRPC_CLIENT_TLS_ENABLED": "true", "GRPC_CLIENT_AUTH_TOKEN": "static"},
+			map[string]string{},
+			client.GRPCCallOptions{},
+			"static",
+		},
+		{
+			"empty metadata token falls back to static token",
+			map[string]string{"GRPC_CLIENT_TLS_ENABLED": "true", "GRPC_CLIENT_AUTH_TOKEN": "static"},
+			map[string]string{header: ""},
+			client.GRPCCallOptions{},
+			"static",
+		},
+		{
+			"token is not sent over plaintext",
+			map[string]string{},
+			map[string]string{header: "metadata"},
+			client.GRPCCallOptions{},
+			"",
+		},
+		{
+			"token over plaintext allowed",
+			map[string]string{"GRPC_CLIENT_AUTH_ALLOW_INSECURE": "true"},
+			map[string]string{header: "metadata"},
+			client.GRPCCallOptions{},
+			"metadata",
+		},
+		{
+			"call credentials kept",
+			map[string]string{"GRPC_CLIENT_TLS_ENABLED": "true"},
+			map[string]string{header: "metadata"},
+			client.GRPCCallOptions{Credentials: client.BearerToken{Token: "call"}},
+			"call",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			env := map[string]string{"GRPC_CLIENT_AUTH_TOKEN_METADATA": header}
+			for k, v := range tt.env {
+				env[k] = v
+			}
+			c := newTestClient(t, env)
+
+			opts := tt.opts
+			c.authorize(context.Background(), testService, tt.headers, &opts)
+
+			if _, ok := tt.headers[header]; ok {
+				t.Error("token header: expected", "removed", "received", tt.headers[header])
+			}
+
+			token := ""
+			if creds, ok := opts.Credentials.(client.BearerToken); ok {
+				token = creds.Token
+			}
+			if token != tt.token {
+				t.Error("token: expected", tt.token, "received", token)
+			}
+		})
+	}
+}
+
+func TestGrpcClient_OutgoingContext(t *testing.T) {
+	const header = "x-auth-token"
+
+	tests := []struct {
+		name     string
+		md       grpc_md.MD
+		headers  map[string]string
+		token    string
+		expected grpc_md.MD
+	}{
+		{
+			"multi-value metadata kept",
+			grpc_md.Pairs("x-value", "a", "x-value", "b"),
+			map[string]string{"x-service-name": testService},
+			"",
+			grpc_md.MD{"x-value": {"a", "b"}, "x-service-name": {testService}},
+		},
+		{
+			"header replaces metadata",
+			grpc_md.Pairs("x-value", "a", "x-value", "b"),
+			map[string]string{"x-value": "c"},
+			"",
+			grpc_md.MD{"x-value": {"c"}},
+		},
+		{
+			"token metadata sent as credentials",
+			grpc_md.Pairs(header, "metadata", "x-value", "a"),
+			map[string]string{},
+			"metadata",
+			grpc_md.MD{"x-value": {"a"}},
+		},
+		{
+			"token header has priority over metadata",
+			grpc_md.Pairs(header, "metadata"),
+			map[string]string{header: "header"},
+			"header",
+			grpc_md.MD{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := newTestClient(t, map[string]string{
+				"GRPC_CLIENT_AUTH_TOKEN_METADATA": header,
+				"GRPC_CLIENT_TLS_ENABLED":         "true",
+			})
+
+			ctx := grpc_md.NewOutgoingContext(context.Background(), tt.md)
+
+			opts := client.GRPCCallOptions{}
+			c.authorize(ctx, testService, tt.headers, &opts)
+
+			token := ""
+			if creds, ok := opts.Credentials.(client.BearerToken); ok {
+				token = creds.Token
+			}
+			if token != tt.token {
+				t.Error("token: expected", tt.token, "received", token)
+			}
+
+			md, _ := grpc_md.FromOutgoingContext(c.outgoingContext(ctx, tt.headers))
+			if !reflect.DeepEqual(md, tt.expected) {
+				t.Error("metadata: expected", tt.expected, "received", md)
+			}
+		})
+	}
+}
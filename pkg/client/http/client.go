@@ -1 +1,363 @@
+/*
+Copyright [2014] - [2023] The Last.Backend authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
 package http
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/lastbackend/toolkit/pkg/client"
+	"github.com/lastbackend/toolkit/pkg/client/grpc/resolver"
+	"github.com/lastbackend/toolkit/pkg/client/grpc/resolver/route"
+	"github.com/lastbackend/toolkit/pkg/client/grpc/selector"
+	"github.com/lastbackend/toolkit/pkg/context/deadline"
+	"github.com/lastbackend/toolkit/pkg/context/metadata"
+	"github.com/lastbackend/toolkit/pkg/runtime"
+	tk_http "github.com/lastbackend/toolkit/pkg/server/http"
+	"github.com/lastbackend/toolkit/pkg/server/http/marshaler"
+	"github.com/lastbackend/toolkit/pkg/util/backoff"
+)
+
+const (
+	// default prefix
+	defaultPrefix = "HTTP_CLIENT"
+	// The default scheme of services resolved by name
+	defaultScheme = "http"
+	// The default request content-type
+	defaultContentType = "application/json"
+	// The default request timeout
+	defaultRequestTimeout = 15 * time.Second
+	// The default margin subtracted from remaining deadline of the incoming request
+	defaultDeadlineMargin = 5 * time.Millisecond
+)
+
+type Options struct {
+	Scheme         string        `env:"SCHEME" envDefault:"http" comment:"Set HTTP client scheme used for services resolved by name [http, https]"`
+	ContentType    string        `env:"CONTENT_TYPE" envDefault:"application/json" comment:"Set HTTP client request content-type"`
+	RequestTimeout time.Duration `env:"REQUEST_TIMEOUT" envDefault:"15s" comment:"Set HTTP client request timeout, shorter remaining deadline of the incoming request is used instead"`
+	DeadlineMargin time.Duration `env:"DEADLINE_MARGIN" envDefault:"5ms" comment:"Set safety margin subtracted from remaining deadline of the incoming request for outgoing requests"`
+	Retries        int           `env:"RETRIES" envDefault:"0" comment:"Set number of HTTP client request retries on network errors and 502, 503, 504 responses, only idempotent requests are retried"`
+	BackoffMin     time.Duration `env:"BACKOFF_MIN" envDefault:"100ms" comment:"Set minimal delay between HTTP client request retries"`
+	BackoffMax     time.Duration `env:"BACKOFF_MAX" envDefault:"5s" comment:"Set maximal delay between HTTP client request retries"`
+
+	MaxIdleConns    int           `env:"MAX_IDLE_CONNS" envDefault:"100" comment:"Set maximum number of idle (keep-alive) connections across all hosts"`
+	IdleConnTimeout time.Duration `env:"IDLE_CONN_TIMEOUT" envDefault:"90s" comment:"Set maximum amount of time an idle (keep-alive) connection will remain idle"`
+
+	Selector selector.Selector
+}
+
+// Error - non-2xx response in errors.Http shape
+type Error struct {
+	Code    int    `json:"code"`
+	Status  string `json:"status"`
+	Message string `json:"message"`
+}
+
+func (e *Error) Error() string {
+	if e.Message == "" {
+		return fmt.Sprintf("http status %d: %s", e.Code, e.Status)
+	}
+	return fmt.Sprintf("http status %d: %s", e.Code, e.Message)
+}
+
+type httpClient struct {
+	ctx     context.Context
+	runtime runtime.Runtime
+
+	opts       Options
+	client     *http.Client
+	marshalers map[string]marshaler.Marshaler
+}
+
+func NewClient(ctx context.Context, runtime runtime.Runtime) client.HTTPClient {
+
+	slc, _ := selector.New(selector.RoundRobin)
+
+	c := &httpClient{
+		ctx:     ctx,
+		runtime: runtime,
+		opts: Options{
+			Scheme:         defaultScheme,
+			ContentType:    defaultContentType,
+			RequestTimeout: defaultRequestTimeout,
+			DeadlineMargin: defaultDeadlineMargin,
+			Selector:       slc,
+		},
+		marshalers: tk_http.GetMarshalerMap(),
+	}
+
+	if err := runtime.Config().Parse(&c.opts, defaultPrefix); err != nil {
+		runtime.Log().Errorf("Can not parse config %s: %s", defaultPrefix, err.Error())
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.MaxIdleConns = c.opts.MaxIdleConns
+	transport.IdleConnTimeout = c.opts.IdleConnTimeout
+
+	c.client = &http.Client{Transport: transport}
+
+	return c
+}
+
+func (c *httpClient) Get(ctx context.Context, service, path string, rsp interface{}, opts ...client.HTTPCallOption) error {
+	return c.Do(ctx, http.MethodGet, service, path, nil, rsp, opts...)
+}
+
+func (c *httpClient) Post(ctx context.Context, service, path string, body, rsp interface{}, opts ...client.HTTPCallOption) error {
+	return c.Do(ctx, http.MethodPost, service, path, body, rsp, opts...)
+}
+
+func (c *httpClient) Put(ctx context.Context, service, path string, body, rsp interface{}, opts ...client.HTTPCallOption) error {
+	return c.Do(ctx, http.MethodPut, service, path, body, rsp, opts...)
+}
+
+func (c *httpClient) Patch(ctx context.Context, service, path string, body, rsp interface{}, opts ...client.HTTPCallOption) error {
+	return c.Do(ctx, http.MethodPatch, service, path, body, rsp, opts...)
+}
+
+func (c *httpClient) Delete(ctx context.Context, service, path string, rsp interface{}, opts ...client.HTTPCallOption) error {
+	return c.Do(ctx, http.MethodDelete, service, path, nil, rsp, opts...)
+}
+
+func (c *httpClient) Do(ctx context.Context, method, service, path string, body, rsp interface{}, opts ...client.HTTPCallOption) error {
+
+	callOpts := client.HTTPCallOptions{
+		ContentType:    c.opts.ContentType,
+		RequestTimeout: c.opts.RequestTimeout,
+		Retries:        c.opts.Retries,
+	}
+	for _, opt := range opts {
+		opt(&callOpts)
+	}
+
+	timeout, err := deadline.Budget(ctx, callOpts.RequestTimeout, c.opts.DeadlineMargin)
+	if err != nil {
+		return fmt.Errorf("%w: %w", err, context.DeadlineExceeded)
+	}
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
+	m := c.getMarshaler(callOpts.ContentType)
+
+	var payload []byte
+	if body != nil {
+		buf, err := m.Marshal(body)
+		if err != nil {
+			return fmt.Errorf("can not marshal request body: %v", err)
+		}
+		payload = buf
+	}
+
+	next, err := c.lookup(service)
+	if err != nil {
+		return err
+	}
+
+	headers := c.makeHeaders(ctx, callOpts)
+
+	b := &backoff.Backoff{
+		Min:    c.opts.BackoffMin,
+		Max:    c.opts.BackoffMax,
+		Jitter: true,
+	}
+
+	retries := callOpts.Retries
+	if !callOpts.Idempotent && !isIdempotent(method) {
+		retries = 0
+	}
+
+	for attempt := 0; ; attempt++ {
+		retry, err := c.do(ctx, method, next(), path, headers, payload, callOpts, m, rsp)
+		if err == nil || !retry || attempt >= retries {
+			return err
+		}
+
+		t := time.NewTimer(b.Duration())
+		select {
+		case <-ctx.Done():
+			t.Stop()
+			return ctx.Err()
+		case <-t.C:
+		}
+	}
+}
+
+// isIdempotent - check if request with the method can be safely sent more than once
+func isIdempotent(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodPut, http.MethodDelete, http.MethodOptions:
+		return true
+	}
+	return false
+}
+
+// do - perform single request attempt, returns true if request can be retried
+func (c *httpClient) do(ctx context.Context, method, address, path string, headers map[string]string, payload []byte,
+	opts client.HTTPCallOptions, m marshaler.Marshaler, rsp interface{}) (bool, error) {
+
+	u := strings.TrimSuffix(address, "/") + "/" + strings.TrimPrefix(path, "/")
+	if len(opts.Query) > 0 {
+		u = u + "?" + opts.Query.Encode()
+	}
+
+	var body io.Reader
+	if payload != nil {
+		body = bytes.NewReader(payload)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, u, body)
+	if err != nil {
+		return false, err
+	}
+
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+
+	// propagate remaining deadline to the service
+	if dl, ok := ctx.Deadline(); ok {
+		req.Header.Set(deadline.Header, deadline.Encode(time.Until(dl)))
+	}
+
+	res, err := c.client.Do(req)
+	if err != nil {
+		return ctx.Err() == nil, err
+	}
+	defer res.Body.Close()
+
+	data, err := io.ReadAll(res.Body)
+	if err != nil {
+		return true, err
+	}
+
+	if res.StatusCode < http.StatusOK || res.StatusCode >= http.StatusMultipleChoices {
+		e := &Error{Code: res.StatusCode, Status: http.StatusText(res.StatusCode)}
+		if err := json.Unmarshal(data, e); err != nil || e.Code == 0 {
+			e.Code = res.StatusCode
+			e.Message = strings.TrimSpace(string(data))
+		}
+		switch res.StatusCode {
+		case http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+			return true, e
+		}
+		return false, e
+	}
+
+	if rsp == nil || len(data) == 0 {
+		return false, nil
+	}
+
+	if contentType, _, err := mime.ParseMediaType(res.Header.Get("Content-Type")); err == nil {
+		if rm, ok := c.marshalers[contentType]; ok {
+			m = rm
+		}
+	}
+
+	if err := m.Unmarshal(data, rsp); err != nil {
+		return false, fmt.Errorf("can not unmarshal response body: %v", err)
+	}
+
+	return false, nil
+}
+
+// lookup - resolve service name through resolver, service can be also an url or host:port address
+func (c *httpClient) lookup(service string) (selector.Next, error) {
+
+	if strings.Contains(service, "://") {
+		return func() string { return service }, nil
+	}
+
+	var routes route.List
+
+	if r := c.getResolver(); r != nil {
+		var err error
+		routes, err = r.Lookup(service)
+		if err != nil && err != route.ErrRouteNotFound {
+			return nil, err
+		}
+	}
+
+	if len(routes) == 0 {
+		routes = route.List{{Service: service, Address: service}}
+	}
+
+	urls := make(route.List, len(routes))
+	for i, r := range routes {
+		urls[i] = r
+		if !strings.Contains(r.Address, "://") {
+			urls[i].Address = fmt.Sprintf("%s://%s", c.opts.Scheme, r.Address)
+		}
+	}
+
+	return c.opts.Selector.Select(service, urls)
+}
+
+func (c *httpClient) makeHeaders(ctx context.Context, opts client.HTTPCallOptions) map[string]string {
+	var headers = make(map[string]string, 0)
+
+	if md, ok := metadata.LoadFromContext(ctx); ok {
+		for k, v := range md {
+			headers[strings.ToLower(k)] = v
+		}
+	}
+	if opts.Headers != nil {
+		for k, v := range opts.Headers {
+			headers[strings.ToLower(k)] = v
+		}
+	}
+
+	if _, ok := headers["content-type"]; !ok {
+		headers["content-type"] = opts.ContentType
+	}
+	if _, ok := headers["accept"]; !ok {
+		headers["accept"] = opts.ContentType
+	}
+
+	if c.runtime.Tools() != nil {
+		if t := c.runtime.Tools().Traces(); t != nil && t.Enabled() {
+			t.Inject(ctx, headers)
+		}
+	}
+
+	return headers
+}
+
+func (c *httpClient) getMarshaler(contentType string) marshaler.Marshaler {
+	if ct, _, err := mime.ParseMediaType(contentType); err == nil {
+		if m, ok := c.marshalers[ct]; ok {
+			return m
+		}
+	}
+	return tk_http.DefaultMarshaler
+}
+
+func (c *httpClient) getResolver() resolver.Resolver {
+	if c.runtime.Client() == nil || c.runtime.Client().GRPC() == nil {
+		return nil
+	}
+	return c.runtime.Client().GRPC().GetResolver()
+}
@@ -0,0 +1,212 @@
+package http
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/caarlos0/env/v7"
+	"github.com/lastbackend/toolkit/pkg/client"
+	"github.com/lastbackend/toolkit/pkg/context/deadline"
+	"github.com/lastbackend/toolkit/pkg/runtime"
+	"github.com/lastbackend/toolkit/pkg/runtime/logger"
+	"github.com/lastbackend/toolkit/pkg/runtime/logger/empty"
+)
+
+type testConfig struct {
+	runtime.Config
+	environment map[string]string
+	err         error
+}
+
+func (c testConfig) Parse(v interface{}, prefix string, opts ...env.Options) error {
+	if c.err != nil {
+		return c.err
+	}
+	opts = append(opts, env.Options{Prefix: strings.ToUpper(prefix) + "_", Environment: c.environment})
+	return env.Parse(v, opts...)
+}
+
+type testRuntime struct {
+	runtime.Runtime
+	environment map[string]string
+	configErr   error
+}
+
+func (testRuntime) Log() logger.Logger {
+	return empty.NewLogger()
+}
+
+func (r testRuntime) Config() runtime.Config {
+	return testConfig{environment: r.environment, err: r.configErr}
+}
+
+func (testRuntime) Client() runtime.Client {
+	return nil
+}
+
+func (testRuntime) Tools() runtime.Tools {
+	return nil
+}
+
+func newTestClient() client.HTTPClient {
+	return NewClient(context.Background(), testRuntime{environment: map[string]string{
+		"HTTP_CLIENT_RETRIES":     "2",
+		"HTTP_CLIENT_BACKOFF_MIN": "1ms",
+		"HTTP_CLIENT_BACKOFF_MAX": "2ms",
+	}})
+}
+
+func TestNewClient(t *testing.T) {
+	tests := []struct {
+		name        string
+		environment map[string]string
+		err         error
+		scheme      string
+		contentType string
+	}{
+		{"defaults", nil, nil, "http", "application/json"},
+		{"configured", map[string]string{"HTTP_CLIENT_SCHEME": "https", "HTTP_CLIENT_CONTENT_TYPE": "application/xml"}, nil, "https", "application/xml"},
+		{"defaults kept when config parse failed", nil, errors.New("parse failed"), "http", "application/json"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := NewClient(context.Background(), testRuntime{environment: tt.environment, configErr: tt.err}).(*httpClient)
+			if c.opts.Scheme != tt.scheme {
+				t.Error("scheme: expected", tt.scheme, "received", c.opts.Scheme)
+			}
+			if c.opts.ContentType != tt.contentType {
+				t.Error("content-type: expected", tt.contentType, "received", c.opts.ContentType)
+			}
+		})
+	}
+}
+
+func TestHttpClient_Retries(t *testing.T) {
+	tests := []struct {
+		name     string
+		method   string
+		opts     []client.HTTPCallOption
+		status   int
+		attempts int32
+	}{
+		{"get is retried", http.MethodGet, nil, http.StatusServiceUnavailable, 3},
+		{"head is retried", http.MethodHead, nil, http.StatusBadGateway, 3},
+		{"put is retried", http.MethodPut, nil, http.StatusGatewayTimeout, 3},
+		{"delete is retried", http.MethodDelete, nil, http.StatusServiceUnavailable, 3},
+		{"options is retried", http.MethodOptions, nil, http.StatusServiceUnavailable, 3},
+		{"post is not retried", http.MethodPost, nil, http.StatusServiceUnavailable, 1},
+		{"patch is not retried", http.MethodPatch, nil, http.StatusServiceUnavailable, 1},
+		{"idempotent post is retried", http.MethodPost, []client.HTTPCallOption{client.HTTPOptionIdempotent()}, http.StatusServiceUnavailable, 3},
+		{"retries overridden per call", http.MethodGet, []client.HTTPCallOption{client.HTTPOptionRetries(0)}, http.StatusServiceUnavailable, 1},
+		{"client error is not retried", http.MethodGet, nil, http.StatusNotFound, 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var attempts int32
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				atomic.AddInt32(&attempts, 1)
+				w.WriteHeader(tt.status)
+			}))
+			defer srv.Close()
+
+			err := newTestClient().Do(context.Background(), tt.method, srv.URL, "/", nil, nil, tt.opts...)
+
+			var e *Error
+			if !errors.As(err, &e) || e.Code != tt.status {
+				t.Error("error: expected status", tt.status, "received", err)
+			}
+			if n := atomic.LoadInt32(&attempts); n != tt.attempts {
+				t.Error("attempts: expected", tt.attempts, "received", n)
+			}
+		})
+	}
+}
+
+func TestHttpClient_Do(t *testing.T) {
+	type payload struct {
+		Name string `json:"name"`
+	}
+
+	tests := []struct {
+		name    string
+		handler http.HandlerFunc
+		body    interface{}
+		rsp     *payload
+		err     string
+	}{
+		{
+			"echo body",
+			func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", "application/json")
+				_, _ = w.Write([]byte(`{"name":"echo"}`))
+			},
+			&payload{Name: "request"},
+			&payload{Name: "echo"},
+			"",
+		},
+		{
+			"error in errors.Http shape",
+			func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusBadRequest)
+				_, _ = w.Write([]byte(`{"code":400,"status":"Bad Request","message":"invalid name"}`))
+			},
+			nil,
+			nil,
+			"http status 400: invalid name",
+		},
+		{
+			"error with plain text body",
+			func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusConflict)
+				_, _ = w.Write([]byte("already exists"))
+			},
+			nil,
+			nil,
+			"http status 409: already exists",
+		},
+		{
+			"deadline propagated",
+			func(w http.ResponseWriter, r *http.Request) {
+				if _, err := deadline.Decode(r.Header.Get(deadline.Header)); err != nil {
+					w.WriteHeader(http.StatusBadRequest)
+				}
+			},
+			nil,
+			nil,
+			"",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := httptest.NewServer(tt.handler)
+			defer srv.Close()
+
+			rsp := new(payload)
+			ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+			defer cancel()
+
+			err := newTestClient().Post(ctx, srv.URL, "/", tt.body, rsp)
+			if tt.err != "" {
+				if err == nil || err.Error() != tt.err {
+					t.Error("error: expected", tt.err, "received", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal("error: unexpected", err)
+			}
+			if tt.rsp != nil && *rsp != *tt.rsp {
+				t.Error("response: expected", tt.rsp, "received", rsp)
+			}
+		})
+	}
+}
@@ -0,0 +1,105 @@
+package deadline
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"time"
+)
+
+// Header - HTTP header carrying request timeout in grpc-timeout format, e.g. 150m or 2S
+const Header = "Grpc-Timeout"
+
+// max value of grpc-timeout is 8 digits
+const maxTimeoutValue int64 = 100000000 - 1
+
+var (
+	ErrExhausted = errors.New("deadline budget exhausted")
+)
+
+var units = []struct {
+	unit byte
+	d    time.Duration
+}{
+	{'n', time.Nanosecond},
+	{'u', time.Microsecond},
+	{'m', time.Millisecond},
+	{'S', time.Second},
+	{'M', time.Minute},
+	{'H', time.Hour},
+}
+
+// Encode - encode timeout in grpc-timeout format, the smallest unit keeping value in 8 digits is used
+func Encode(t time.Duration) string {
+	if t <= 0 {
+		return "0n"
+	}
+	for _, u := range units {
+		// round up to do not shorten the timeout
+		v := int64(t / u.d)
+		if t%u.d > 0 {
+			v++
+		}
+		if v <= maxTimeoutValue {
+			return strconv.FormatInt(v, 10) + string(u.unit)
+		}
+	}
+	// unreachable, max duration fits in 8 digits of hours
+	return strconv.FormatInt(int64(t/time.Hour), 10) + "H"
+}
+
+// Decode - decode timeout in grpc-timeout format
+func Decode(s string) (time.Duration, error) {
+	size := len(s)
+	if size < 2 || size > 9 {
+		return 0, fmt.Errorf("invalid timeout %q", s)
+	}
+
+	unit := s[size-1]
+
+	var d time.Duration
+	for _, u := range units {
+		if u.unit == unit {
+			d = u.d
+			break
+		}
+	}
+	if d == 0 {
+		return 0, fmt.Errorf("invalid timeout unit %q", s)
+	}
+
+	v, err := strconv.ParseInt(s[:size-1], 10, 64)
+	if err != nil || v < 0 {
+		return 0, fmt.Errorf("invalid timeout value %q", s)
+	}
+
+	if v > int64(math.MaxInt64/d) {
+		return time.Duration(math.MaxInt64), nil
+	}
+	return d * time.Duration(v), nil
+}
+
+// Budget - get timeout of outgoing call as min(remaining ctx time minus margin, timeout),
+// zero timeout means no limit, ErrExhausted is returned if ctx has no time left
+func Budget(ctx context.Context, timeout, margin time.Duration) (time.Duration, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, ErrExhausted
+	}
+
+	dl, ok := ctx.Deadline()
+	if !ok {
+		return timeout, nil
+	}
+
+	remaining := time.Until(dl) - margin
+	if remaining <= 0 {
+		return 0, ErrExhausted
+	}
+
+	if timeout <= 0 || remaining < timeout {
+		return remaining, nil
+	}
+	return timeout, nil
+}
@@ -0,0 +1,136 @@
+package deadline
+
+import (
+	"context"
+	"math"
+	"testing"
+	"time"
+)
+
+func TestEncode(t *testing.T) {
+	tests := []struct {
+		name    string
+		timeout time.Duration
+		value   string
+	}{
+		{"zero timeout", 0, "0n"},
+		{"negative timeout", -time.Second, "0n"},
+		{"nanoseconds", 500 * time.Nanosecond, "500n"},
+		{"milliseconds", 250 * time.Millisecond, "250000u"},
+		{"seconds", 30 * time.Second, "30000000u"},
+		{"rounded up", 100*time.Second + time.Nanosecond, "100001m"},
+		{"hours", 1000 * time.Hour, "3600000S"},
+		{"max duration", time.Duration(math.MaxInt64), "2562048H"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if value := Encode(tt.timeout); value != tt.value {
+				t.Error("value: expected", tt.value, "received", value)
+			}
+		})
+	}
+}
+
+func TestDecode(t *testing.T) {
+	tests := []struct {
+		name    string
+		value   string
+		timeout time.Duration
+		err     bool
+	}{
+		{"nanoseconds", "500n", 500 * time.Nanosecond, false},
+		{"microseconds", "250u", 250 * time.Microsecond, false},
+		{"milliseconds", "100m", 100 * time.Millisecond, false},
+		{"seconds", "30S", 30 * time.Second, false},
+		{"minutes", "5M", 5 * time.Minute, false},
+		{"hours", "2H", 2 * time.Hour, false},
+		{"overflow capped", "99999999H", time.Duration(math.MaxInt64), false},
+		{"empty value", "", 0, true},
+		{"missing value", "S", 0, true},
+		{"too many digits", "123456789S", 0, true},
+		{"invalid unit", "10s", 0, true},
+		{"invalid value", "1xS", 0, true},
+		{"negative value", "-1S", 0, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			timeout, err := Decode(tt.value)
+			if (err != nil) != tt.err {
+				t.Fatal("error: expected", tt.err, "received", err)
+			}
+			if timeout != tt.timeout {
+				t.Error("timeout: expected", tt.timeout, "received", timeout)
+			}
+		})
+	}
+}
+
+func TestEncodeDecode(t *testing.T) {
+	tests := []struct {
+		name    string
+		timeout time.Duration
+	}{
+		{"nanoseconds", 999 * time.Nanosecond},
+		{"seconds", 42 * time.Second},
+		{"odd duration", 3*time.Second + 7*time.Microsecond},
+		{"days", 72 * time.Hour},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			timeout, err := Decode(Encode(tt.timeout))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if timeout < tt.timeout {
+				t.Error("timeout: expected at least", tt.timeout, "received", timeout)
+			}
+		})
+	}
+}
+
+func TestBudget(t *testing.T) {
+	tests := []struct {
+		name     string
+		deadline time.Duration
+		canceled bool
+		timeout  time.Duration
+		margin   time.Duration
+		min      time.Duration
+		max      time.Duration
+		err      error
+	}{
+		{"no deadline", 0, false, time.Second, 0, time.Second, time.Second, nil},
+		{"no deadline and no timeout", 0, false, 0, 0, 0, 0, nil},
+		{"timeout shorter than deadline", time.Minute, false, time.Second, 0, time.Second, time.Second, nil},
+		{"deadline shorter than timeout", time.Second, false, time.Minute, 0, 900 * time.Millisecond, time.Second, nil},
+		{"deadline without timeout", time.Second, false, 0, 0, 900 * time.Millisecond, time.Second, nil},
+		{"margin subtracted", time.Second, false, time.Minute, 500 * time.Millisecond, 400 * time.Millisecond, 500 * time.Millisecond, nil},
+		{"margin exceeds deadline", time.Second, false, time.Minute, 2 * time.Second, 0, 0, ErrExhausted},
+		{"canceled context", 0, true, time.Second, 0, 0, 0, ErrExhausted},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx, cancel := context.WithCancel(context.Background())
+			if tt.deadline > 0 {
+				ctx, cancel = context.WithTimeout(context.Background(), tt.deadline)
+			}
+			defer cancel()
+
+			if tt.canceled {
+				cancel()
+			}
+
+			budget, err := Budget(ctx, tt.timeout, tt.margin)
+			if err != tt.err {
+				t.Fatal("error: expected", tt.err, "received", err)
+			}
+			if budget < tt.min || budget > tt.max {
+				t.Error("budget: expected between", tt.min, "and", tt.max, "received", budget)
+			}
+		})
+	}
+}
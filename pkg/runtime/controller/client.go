@@ -4,6 +4,7 @@ import (
 	"context"
 	"github.com/lastbackend/toolkit/pkg/client"
 	"github.com/lastbackend/toolkit/pkg/client/grpc"
+	"github.com/lastbackend/toolkit/pkg/client/http"
 	"github.com/lastbackend/toolkit/pkg/runtime"
 	"github.com/lastbackend/toolkit/pkg/runtime/logger"
 )
@@ -22,11 +23,16 @@ func (c *clientManager) HTTP() client.HTTPClient {
 	return c.http
 }
 
-func newClientController(ctx context.Context, runtime runtime.Runtime) runtime.Client {
+func newClientController(ctx context.Context, runtime runtime.Runtime) (runtime.Client, error) {
 	cl := new(clientManager)
 
 	cl.log = runtime.Log()
-	cl.grpc = grpc.NewClient(ctx, runtime)
 
-	return cl
+	var err error
+	if cl.grpc, err = grpc.NewClient(ctx, runtime); err != nil {
+		return nil, err
+	}
+	cl.http = http.NewClient(ctx, runtime)
+
+	return cl, nil
 }
@@ -295,9 +295,18 @@ func (c *controller) onStop(ctx context.Context) error {
 	if err := c.Package().OnStop(ctx); err != nil {
 		return err
 	}
+	// resolvers holding resources or registered routes release them on stop
+	if r, ok := c.client.GRPC().GetResolver().(interface{ OnStop(context.Context) error }); ok {
+		if err := r.OnStop(ctx); err != nil {
+			return err
+		}
+	}
 	if err := c.Server().Stop(ctx); err != nil {
 		return err
 	}
+	if err := c.Tools().OnStop(ctx); err != nil {
+		return err
+	}
 
 	for _, fn := range c.onStopHook {
 		fn := fn
@@ -413,7 +422,9 @@ func NewRuntime(ctx context.Context, name string, opts ...runtime.Option) (runti
 		"microservice": name,
 	})
 
-	rt.client = newClientController(ctx, rt)
+	if rt.client, err = newClientController(ctx, rt); err != nil {
+		return nil, err
+	}
 	rt.plugin = newPluginController(ctx, rt)
 	rt.pkg = newPackageController(ctx, rt)
 	rt.server = newServerController(ctx, rt)
@@ -76,9 +76,9 @@ func (c *serverManager) Provides() []interface{} {
 	provides := make([]interface{}, 0)
 
 	for _, s := range c.grpc {
-		service := s.GetService()
-		if service != nil {
-			provides = append(provides, service)
+		services := s.GetServices()
+		if len(services) > 0 {
+			provides = append(provides, services...)
 
 			interceptors := s.GetInterceptors()
 			for _, interceptor := range interceptors {
@@ -90,6 +90,16 @@ func (c *serverManager) Provides() []interface{} {
 				)
 			}
 
+			streamInterceptors := s.GetStreamInterceptors()
+			for _, interceptor := range streamInterceptors {
+				provides = append(provides, fx.Annotate(
+					interceptor,
+					fx.As(new(server.GRPCStreamInterceptor)),
+					fx.ResultTags(`group:"stream_interceptors"`),
+				),
+				)
+			}
+
 		}
 	}
 
@@ -114,10 +124,10 @@ func (c *serverManager) Constructors() []interface{} {
 	provides := make([]interface{}, 0)
 
 	for _, s := range c.grpc {
-		provides = append(provides, s.GetConstructor())
+		provides = append(provides, s.GetConstructors()...)
 		provides = append(provides, fx.Annotate(
 			s.GetInterceptorsConstructor(),
-			fx.ParamTags(`group:"interceptors"`)))
+			fx.ParamTags(`group:"interceptors"`, `group:"stream_interceptors"`)))
 	}
 
 	for _, s := range c.http {
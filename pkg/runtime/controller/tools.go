@@ -5,9 +5,11 @@ import (
 
 	"github.com/lastbackend/toolkit/pkg/runtime"
 	"github.com/lastbackend/toolkit/pkg/tools/metrics"
+	metrics_server "github.com/lastbackend/toolkit/pkg/tools/metrics/server"
 	"github.com/lastbackend/toolkit/pkg/tools/probes"
 	"github.com/lastbackend/toolkit/pkg/tools/probes/server"
 	"github.com/lastbackend/toolkit/pkg/tools/traces"
+	"github.com/lastbackend/toolkit/pkg/tools/traces/tracer"
 )
 
 type Tools struct {
@@ -32,7 +34,17 @@ func (t *Tools) Metrics() metrics.Metrics {
 }
 
 func (t *Tools) OnStart(ctx context.Context) error {
-	return t.probes.Start(ctx)
+	if err := t.probes.Start(ctx); err != nil {
+		return err
+	}
+	if err := t.metrics.Start(ctx); err != nil {
+		return err
+	}
+	return t.traces.Start(ctx)
+}
+
+func (t *Tools) OnStop(ctx context.Context) error {
+	return t.traces.Shutdown(ctx)
 }
 
 func newToolsRegistration(runtime runtime.Runtime) (runtime.Tools, error) {
@@ -46,5 +58,13 @@ func newToolsRegistration(runtime runtime.Runtime) (runtime.Tools, error) {
 		return nil, err
 	}
 
+	if tools.metrics, err = metrics_server.NewMetricsServer(runtime); err != nil {
+		return nil, err
+	}
+
+	if tools.traces, err = tracer.NewTracer(runtime); err != nil {
+		return nil, err
+	}
+
 	return tools, nil
 }
@@ -99,6 +99,7 @@ type Package interface {
 
 type Tools interface {
 	OnStart(ctx context.Context) error
+	OnStop(ctx context.Context) error
 
 	Metrics() metrics.Metrics
 	Probes() probes.Probes
@@ -18,10 +18,10 @@ package grpc
 
 import (
 	"context"
-	"crypto/tls"
 	"fmt"
 	"github.com/lastbackend/toolkit/pkg/runtime"
 	"github.com/lastbackend/toolkit/pkg/server"
+	tk_tls "github.com/lastbackend/toolkit/pkg/util/tls"
 	"net"
 	"net/http"
 	"regexp"
@@ -30,7 +30,11 @@ import (
 	"github.com/improbable-eng/grpc-web/go/grpcweb"
 	"golang.org/x/net/netutil"
 	grpc "google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/credentials"
+	health "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/reflection"
+	"google.golang.org/grpc/status"
 )
 
 const (
@@ -47,14 +51,22 @@ type grpcServer struct {
 
 	opts Config
 
-	descriptor grpc.ServiceDesc
+	// descriptor/implementation pairs registered on the server
+	services []*serviceItem
+	// implementations registered before matching descriptor
+	pending []interface{}
 
-	// fn for init user-defined service
-	// fn for server registration
-	provide interface{}
-	service interface{}
+	// fn for init user-defined services
+	// fn for services registration
+	provides     []interface{}
+	constructors []interface{}
 
 	interceptors *Interceptors
+	metrics      *serverMetrics
+	traces       *serverTraces
+	health       *healthServer
+	proxy        *serverProxy
+	recovery     *serverRecovery
 
 	grpc    *grpc.Server
 	options *server.GRPCServerOptions
@@ -95,34 +107,53 @@ func (g *grpcServer) Info() server.ServerInfo {
 		Host:      g.opts.Host,
 		Port:      g.opts.Port,
 		TLSConfig: g.opts.TLSConfig,
+
+		RegisterInterval: g.opts.RegisterInterval,
+		RegisterTTL:      g.opts.RegisterTTL,
 	}
 }
 
-// SetDescriptor - set generated grpc server descriptor
+// SetDescriptor - add generated grpc service descriptor
 func (g *grpcServer) SetDescriptor(descriptor grpc.ServiceDesc) {
-	g.descriptor = descriptor
+	g.Lock()
+	defer g.Unlock()
+
+	for _, item := range g.services {
+		if item.descriptor.ServiceName == descriptor.ServiceName {
+			item.descriptor = &descriptor
+			return
+		}
+	}
+
+	g.services = append(g.services, &serviceItem{descriptor: &descriptor})
 }
 
-// SetService - set user-defined handlers
+// SetService - add user-defined handlers constructor
 func (g *grpcServer) SetService(service interface{}) {
-	g.service = service
+	g.provides = append(g.provides, service)
 	return
 }
 
-// GetService - set user-defined handlers
-func (g *grpcServer) GetService() interface{} {
-	return g.service
+// GetServices - get user-defined handlers constructors
+func (g *grpcServer) GetServices() []interface{} {
+	return g.provides
 }
 
-// RegisterService - set user-defined handlers
+// RegisterService - bind user-defined handlers to the descriptor they implement
 func (g *grpcServer) RegisterService(service interface{}) {
-	g.service = service
+	g.Lock()
+	defer g.Unlock()
+
+	// binding is retried on start when descriptors are set later
+	if err := g.bind(service); err != nil {
+		g.pending = append(g.pending, service)
+	}
 	return
 }
 
-// SetConstructor - set fx handlers definition
+// SetConstructor - add fx handlers registration definition
 func (g *grpcServer) SetConstructor(fn interface{}) {
-	g.provide = fn
+	g.constructors = append(g.constructors, fn)
 	return
 }
 
@@ -130,24 +161,41 @@ func (s *grpcServer) GetInterceptors() []interface{} {
 	return s.interceptors.constructors
 }
 
+func (s *grpcServer) GetStreamInterceptors() []interface{} {
+	return s.interceptors.streamConstructors
+}
+
 // SetInterceptor - set fx handlers definition
 func (g *grpcServer) SetInterceptor(interceptor any) {
 	g.interceptors.AddConstructor(interceptor)
 }
 
-// GetConstructor - set fx handlers definition
-func (g *grpcServer) GetConstructor() interface{} {
-	return g.provide
+// UseInterceptor - set interceptors applied to every method of the server
+func (g *grpcServer) UseInterceptor(interceptors ...server.KindInterceptor) {
+	g.interceptors.SetGlobal(interceptors...)
+}
+
+// SetMethodOptions - set interceptor options for full method name (/package.Service/Method)
+func (g *grpcServer) SetMethodOptions(method string, opts ...server.GRPCMethodOption) {
+	g.interceptors.SetMethodOptions(method, opts...)
+}
+
+// GetConstructors - get fx handlers registration definitions
+func (g *grpcServer) GetConstructors() []interface{} {
+	return g.constructors
 }
 
 func (g *grpcServer) GetInterceptorsConstructor() interface{} {
 	return g.constructor
 }
 
-func (g *grpcServer) constructor(interceptors ...server.GRPCInterceptor) {
+func (g *grpcServer) constructor(interceptors []server.GRPCInterceptor, streams []server.GRPCStreamInterceptor) {
 	for _, interceptor := range interceptors {
 		g.interceptors.Add(interceptor)
 	}
+	for _, interceptor := range streams {
+		g.interceptors.AddStream(interceptor)
+	}
 }
 
 // parseOptions - get options from config and convert them to grpc server options
@@ -173,6 +221,10 @@ func (g *grpcServer) parseOptions(options *server.GRPCServerOptions) []grpc.Serv
 		grpc.UnknownServiceHandler(g.defaultHandler),
 	}
 
+	if g.opts.EnableProxy {
+		g.proxy = newServerProxy(g.runtime)
+	}
+
 	if g.opts.TLSConfig != nil {
 		gopts = append(gopts, grpc.Creds(credentials.NewTLS(g.opts.TLSConfig)))
 	}
@@ -181,12 +233,39 @@ func (g *grpcServer) parseOptions(options *server.GRPCServerOptions) []grpc.Serv
 		gopts = append(gopts, g.opts.GrpcOptions...)
 	}
 
-	var interceptors = make([]grpc.UnaryServerInterceptor, 0)
-	for _, i := range g.interceptors.items {
-		interceptors = append(interceptors, i.Interceptor)
+	var (
+		interceptors       = make([]grpc.UnaryServerInterceptor, 0)
+		streamInterceptors = make([]grpc.StreamServerInterceptor, 0)
+	)
+
+	if g.traces != nil {
+		interceptors = append(interceptors, g.traces.unaryInterceptor)
+		streamInterceptors = append(streamInterceptors, g.traces.streamInterceptor)
+	}
+
+	if g.metrics != nil {
+		interceptors = append(interceptors, g.metrics.unaryInterceptor)
+		streamInterceptors = append(streamInterceptors, g.metrics.streamInterceptor)
+	}
+
+	if g.recovery != nil {
+		interceptors = append(interceptors, g.recovery.unaryInterceptor)
+		streamInterceptors = append(streamInterceptors, g.recovery.streamInterceptor)
+	}
+
+	interceptors = append(interceptors, deadlineUnaryInterceptor)
+	streamInterceptors = append(streamInterceptors, deadlineStreamInterceptor)
+
+	if len(g.interceptors.items) > 0 {
+		interceptors = append(interceptors, g.interceptors.unaryInterceptor)
+	}
+
+	if len(g.interceptors.streams) > 0 {
+		streamInterceptors = append(streamInterceptors, g.interceptors.streamInterceptor)
 	}
 
 	gopts = append(gopts, grpc.ChainUnaryInterceptor(interceptors...))
+	gopts = append(gopts, grpc.ChainStreamInterceptor(streamInterceptors...))
 
 	return gopts
 }
@@ -205,12 +284,66 @@ func (g *grpcServer) Start(_ context.Context) error {
 		err      error
 	)
 
-	address := fmt.Sprintf("%s:%d", g.opts.Host, g.opts.Port)
-	if transportConfig := g.opts.TLSConfig; transportConfig != nil {
-		listener, err = tls.Listen("tcp", address, transportConfig)
-	} else {
-		listener, err = net.Listen("tcp", address)
+	if g.opts.EnableMetrics && g.runtime.Tools().Metrics() != nil {
+		if g.metrics, err = newServerMetrics(g.prefix, g.runtime.Tools().Metrics()); err != nil {
+			return err
+		}
+	}
+
+	if t := g.runtime.Tools().Traces(); t != nil && t.Enabled() {
+		g.traces = newServerTraces(t)
+	}
+
+	if g.opts.EnableRecovery {
+		if g.recovery, err = newServerRecovery(g.prefix, g.runtime.Log(), g.runtime.Tools().Metrics()); err != nil {
+			return err
+		}
+	}
+
+	if err := g.interceptors.prepare(); err != nil {
+		return err
+	}
+
+	if g.opts.TLSConfig == nil && g.opts.TLSCertFile != "" {
+		r, err := tk_tls.NewReloader(tk_tls.ReloaderOptions{
+			CertFile:   g.opts.TLSCertFile,
+			KeyFile:    g.opts.TLSKeyFile,
+			CAFile:     g.opts.TLSCAFile,
+			ClientAuth: g.opts.TLSClientAuth,
+			Interval:   g.opts.TLSReloadInterval,
+			OnError: func(err error) {
+				g.runtime.Log().Errorf("server [grpc] tls reload error: %v", err)
+			},
+		})
+		if err != nil {
+			return err
+		}
+		g.opts.TLSConfig = r.Config()
+	}
+
+	g.grpc = grpc.NewServer(g.parseOptions(g.options)...)
+	if err := g.registerServices(); err != nil {
+		return err
+	}
+
+	if g.opts.EnableHealth {
+		g.health = newHealthServer(g.runtime, g.opts.HealthWatchInterval)
+		for name := range g.grpc.GetServiceInfo() {
+			g.health.add(name)
+		}
+		health.RegisterHealthServer(g.grpc, g.health)
+	}
+
+	if g.opts.EnableReflection {
+		reflection.Register(g.grpc)
+	}
+
+	if g.metrics != nil {
+		g.metrics.register(g.grpc.GetServiceInfo())
 	}
+
+	// TLS handshake is done by grpc server transport credentials
+	listener, err = net.Listen("tcp", fmt.Sprintf("%s:%d", g.opts.Host, g.opts.Port))
 	if err != nil {
 		return err
 	}
@@ -225,9 +358,6 @@ func (g *grpcServer) Start(_ context.Context) error {
 	g.address = listener.Addr().String()
 	g.Unlock()
 
-	g.grpc = grpc.NewServer(g.parseOptions(g.options)...)
-	g.grpc.RegisterService(&g.descriptor, g.service)
-
 	if g.opts.GRPCWebPort > 0 {
 
 		grpcWebOptions := make([]grpcweb.Option, 0)
@@ -245,7 +375,12 @@ func (g *grpcServer) Start(_ context.Context) error {
 		go func() {
 			g.wait.Add(1)
 			g.runtime.Log().V(5).Infof("server [gRPC-Web] [%s:%d] started", g.opts.GRPCWebHost, g.opts.GRPCWebPort)
-			if err = webGRPCServer.ListenAndServe(); err != nil {
+			if webGRPCServer.TLSConfig != nil {
+				err = webGRPCServer.ListenAndServeTLS("", "")
+			} else {
+				err = webGRPCServer.ListenAndServe()
+			}
+			if err != nil {
 				g.runtime.Log().Errorf("server [grpc] [%s:%d]  start error: %v", g.opts.GRPCWebHost, g.opts.GRPCWebPort, err)
 			}
 			g.runtime.Log().V(5).Infof("server [gRPC-Web] [%s:%d] stopped", g.opts.GRPCWebHost, g.opts.GRPCWebPort)
@@ -274,6 +409,9 @@ func (g *grpcServer) Start(_ context.Context) error {
 func (g *grpcServer) Stop() error {
 
 	g.runtime.Log().V(5).Infof("server [grpc] [%s:%d] stop call start", g.opts.Host, g.opts.Port)
+	if g.health != nil {
+		g.health.stop()
+	}
 	g.grpc.GracefulStop()
 	g.wait.Wait()
 	g.runtime.Log().V(5).Infof("server [grpc] [%s:%d] stop call end", g.opts.Host, g.opts.Port)
@@ -281,7 +419,11 @@ func (g *grpcServer) Stop() error {
 	return nil
 }
 
-// TODO: need implement defaultHandler method
-func (g *grpcServer) defaultHandler(_ interface{}, _ grpc.ServerStream) error {
-	return nil
+// defaultHandler - handle calls of unknown services, proxy them if proxy is enabled
+func (g *grpcServer) defaultHandler(srv interface{}, stream grpc.ServerStream) error {
+	if g.proxy == nil {
+		method, _ := grpc.MethodFromServerStream(stream)
+		return status.Errorf(codes.Unimplemented, "unknown method %s", method)
+	}
+	return g.proxy.handler(srv, stream)
 }
@@ -0,0 +1,101 @@
+package grpc
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/caarlos0/env/v7"
+	"github.com/lastbackend/toolkit/pkg/client"
+	"github.com/lastbackend/toolkit/pkg/runtime"
+	"github.com/lastbackend/toolkit/pkg/runtime/logger"
+	"github.com/lastbackend/toolkit/pkg/runtime/logger/empty"
+	"github.com/lastbackend/toolkit/pkg/runtime/meta"
+	"github.com/lastbackend/toolkit/pkg/tools/metrics"
+	"github.com/lastbackend/toolkit/pkg/tools/probes"
+	"github.com/lastbackend/toolkit/pkg/tools/traces"
+)
+
+type testConfig struct {
+	runtime.Config
+	environment map[string]string
+}
+
+func (c testConfig) Parse(v interface{}, prefix string, opts ...env.Options) error {
+	opts = append(opts, env.Options{Prefix: strings.ToUpper(prefix) + "_", Environment: c.environment})
+	return env.Parse(v, opts...)
+}
+
+type testRuntime struct {
+	runtime.Runtime
+	environment map[string]string
+	tools       *testTools
+	client      *testClient
+}
+
+func (testRuntime) Meta() *meta.Meta {
+	return new(meta.Meta).SetName("test")
+}
+
+func (testRuntime) Log() logger.Logger {
+	return empty.NewLogger()
+}
+
+func (r testRuntime) Config() runtime.Config {
+	return testConfig{environment: r.environment}
+}
+
+func (r testRuntime) Tools() runtime.Tools {
+	if r.tools == nil {
+		return nil
+	}
+	return r.tools
+}
+
+func (r testRuntime) Client() runtime.Client {
+	if r.client == nil {
+		return nil
+	}
+	return r.client
+}
+
+type testClient struct {
+	runtime.Client
+	grpc client.GRPCClient
+}
+
+func (c *testClient) GRPC() client.GRPCClient {
+	return c.grpc
+}
+
+type testTools struct {
+	runtime.Tools
+	probes *testProbes
+}
+
+func (t *testTools) Probes() probes.Probes {
+	if t.probes == nil {
+		return nil
+	}
+	return t.probes
+}
+
+func (t *testTools) Metrics() metrics.Metrics {
+	return nil
+}
+
+func (t *testTools) Traces() traces.Traces {
+	return nil
+}
+
+// testProbes - readiness check result controlled by the test
+type testProbes struct {
+	probes.Probes
+	ready bool
+}
+
+func (p *testProbes) Check(_ probes.ProbeKind) error {
+	if !p.ready {
+		return errors.New("not ready")
+	}
+	return nil
+}
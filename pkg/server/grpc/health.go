@@ -0,0 +1,140 @@
+/*
+Copyright [2014] - [2023] The Last.Backend authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package grpc
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/lastbackend/toolkit/pkg/runtime"
+	"github.com/lastbackend/toolkit/pkg/tools/probes"
+	"google.golang.org/grpc/codes"
+	health "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/status"
+)
+
+// healthServer - grpc.health.v1.Health implementation driven by readiness probes
+type healthServer struct {
+	health.UnimplementedHealthServer
+
+	mtx      sync.RWMutex
+	runtime  runtime.Runtime
+	interval time.Duration
+	services map[string]bool
+	shutdown bool
+	// closed on stop to finish active watch streams, otherwise they block graceful stop
+	done chan struct{}
+}
+
+func newHealthServer(runtime runtime.Runtime, interval time.Duration) *healthServer {
+	return &healthServer{
+		runtime:  runtime,
+		interval: interval,
+		services: map[string]bool{"": true},
+		done:     make(chan struct{}),
+	}
+}
+
+// add - register service name available for health checks
+func (h *healthServer) add(service string) {
+	h.mtx.Lock()
+	defer h.mtx.Unlock()
+	h.services[service] = true
+}
+
+// stop - mark all services as not serving and finish active watch streams
+func (h *healthServer) stop() {
+	h.mtx.Lock()
+	defer h.mtx.Unlock()
+	if h.shutdown {
+		return
+	}
+	h.shutdown = true
+	close(h.done)
+}
+
+func (h *healthServer) Check(_ context.Context, req *health.HealthCheckRequest) (*health.HealthCheckResponse, error) {
+	st, ok := h.status(req.GetService())
+	if !ok {
+		return nil, status.Errorf(codes.NotFound, "unknown service %s", req.GetService())
+	}
+	return &health.HealthCheckResponse{Status: st}, nil
+}
+
+func (h *healthServer) Watch(req *health.HealthCheckRequest, stream health.Health_WatchServer) error {
+
+	var (
+		last   = health.HealthCheckResponse_UNKNOWN
+		ticker = time.NewTicker(h.interval)
+		first  = true
+	)
+
+	defer ticker.Stop()
+
+	for {
+		st, ok := h.status(req.GetService())
+		if !ok {
+			st = health.HealthCheckResponse_SERVICE_UNKNOWN
+		}
+
+		if first || st != last {
+			if err := stream.Send(&health.HealthCheckResponse{Status: st}); err != nil {
+				return status.Error(codes.Canceled, "stream has ended")
+			}
+			first = false
+			last = st
+		}
+
+		select {
+		case <-stream.Context().Done():
+			return status.Error(codes.Canceled, "stream has ended")
+		case <-h.done:
+			if last != health.HealthCheckResponse_NOT_SERVING {
+				_ = stream.Send(&health.HealthCheckResponse{Status: health.HealthCheckResponse_NOT_SERVING})
+			}
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// status - get serving status of the service based on readiness checks
+func (h *healthServer) status(service string) (health.HealthCheckResponse_ServingStatus, bool) {
+	h.mtx.RLock()
+	_, ok := h.services[service]
+	shutdown := h.shutdown
+	h.mtx.RUnlock()
+
+	if !ok {
+		return health.HealthCheckResponse_SERVICE_UNKNOWN, false
+	}
+
+	if shutdown {
+		return health.HealthCheckResponse_NOT_SERVING, true
+	}
+
+	if h.runtime.Tools() == nil || h.runtime.Tools().Probes() == nil {
+		return health.HealthCheckResponse_SERVING, true
+	}
+
+	if err := h.runtime.Tools().Probes().Check(probes.ReadinessProbe); err != nil {
+		return health.HealthCheckResponse_NOT_SERVING, true
+	}
+
+	return health.HealthCheckResponse_SERVING, true
+}
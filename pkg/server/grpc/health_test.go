@@ -0,0 +1,120 @@
+package grpc
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	health "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+func TestHealthServer_Check(t *testing.T) {
+	tests := []struct {
+		name     string
+		service  string
+		tools    *testTools
+		shutdown bool
+		status   health.HealthCheckResponse_ServingStatus
+		code     codes.Code
+	}{
+		{"server health without probes", "", nil, false, health.HealthCheckResponse_SERVING, codes.OK},
+		{"registered service", "test.Foo", nil, false, health.HealthCheckResponse_SERVING, codes.OK},
+		{"unknown service", "test.Bar", nil, false, health.HealthCheckResponse_UNKNOWN, codes.NotFound},
+		{"ready probes", "test.Foo", &testTools{probes: &testProbes{ready: true}}, false, health.HealthCheckResponse_SERVING, codes.OK},
+		{"failed readiness probe", "test.Foo", &testTools{probes: &testProbes{ready: false}}, false, health.HealthCheckResponse_NOT_SERVING, codes.OK},
+		{"stopped server", "test.Foo", nil, true, health.HealthCheckResponse_NOT_SERVING, codes.OK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := newHealthServer(testRuntime{tools: tt.tools}, time.Second)
+			h.add("test.Foo")
+			if tt.shutdown {
+				h.stop()
+				h.stop()
+			}
+
+			rsp, err := h.Check(context.Background(), &health.HealthCheckRequest{Service: tt.service})
+			if status.Code(err) != tt.code {
+				t.Fatal("error code: expected", tt.code, "received", status.Code(err))
+			}
+			if rsp.GetStatus() != tt.status {
+				t.Error("status: expected", tt.status, "received", rsp.GetStatus())
+			}
+		})
+	}
+}
+
+func TestHealthServer_Watch(t *testing.T) {
+	tests := []struct {
+		name    string
+		service string
+		first   health.HealthCheckResponse_ServingStatus
+	}{
+		{"serving service", "test.Foo", health.HealthCheckResponse_SERVING},
+		{"unknown service", "test.Bar", health.HealthCheckResponse_SERVICE_UNKNOWN},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := newHealthServer(testRuntime{}, time.Hour)
+			h.add("test.Foo")
+
+			listener := bufconn.Listen(1024 * 1024)
+			srv := grpc.NewServer()
+			health.RegisterHealthServer(srv, h)
+			go func() {
+				_ = srv.Serve(listener)
+			}()
+
+			conn, err := grpc.Dial("bufnet",
+				grpc.WithTransportCredentials(insecure.NewCredentials()),
+				grpc.WithContextDialer(func(context.Context, string) (net.Conn, error) { return listener.Dial() }))
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer conn.Close()
+
+			stream, err := health.NewHealthClient(conn).Watch(context.Background(), &health.HealthCheckRequest{Service: tt.service})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			rsp, err := stream.Recv()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if rsp.GetStatus() != tt.first {
+				t.Error("first status: expected", tt.first, "received", rsp.GetStatus())
+			}
+
+			// graceful stop waits for active watch streams
+			stopped := make(chan struct{})
+			go func() {
+				h.stop()
+				srv.GracefulStop()
+				close(stopped)
+			}()
+
+			select {
+			case <-stopped:
+			case <-time.After(5 * time.Second):
+				t.Fatal("graceful stop: blocked by watch stream")
+			}
+
+			rsp, err = stream.Recv()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if rsp.GetStatus() != health.HealthCheckResponse_NOT_SERVING {
+				t.Error("last status: expected", health.HealthCheckResponse_NOT_SERVING, "received", rsp.GetStatus())
+			}
+		})
+	}
+}
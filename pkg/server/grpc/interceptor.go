@@ -17,29 +17,252 @@ limitations under the License.
 package grpc
 
 import (
+	"context"
+	"fmt"
+	"reflect"
+	"regexp"
+	"sort"
+	"sync"
+
 	"github.com/lastbackend/toolkit/pkg/runtime/logger"
 	"github.com/lastbackend/toolkit/pkg/server"
+	"google.golang.org/grpc"
+)
+
+const InterceptorNotFoundError string = "Can not apply interceptor to method: %s Can not find server interceptor: %s. To " +
+	"register interceptor, please add Server().GRPC().SetInterceptor(constructor) to runtime."
+
+var (
+	unaryInterceptorType  = reflect.TypeOf((*server.GRPCInterceptor)(nil)).Elem()
+	streamInterceptorType = reflect.TypeOf((*server.GRPCStreamInterceptor)(nil)).Elem()
 )
 
 type Interceptors struct {
-	log          logger.Logger
-	constructors []interface{}
-	items        map[server.KindInterceptor]server.GRPCInterceptor
+	log                logger.Logger
+	global             []server.KindInterceptor
+	constructors       []interface{}
+	streamConstructors []interface{}
+	items              []server.GRPCInterceptor
+	streams            []server.GRPCStreamInterceptor
+
+	// per-method options and rules compiled from them
+	options map[string][]server.GRPCMethodOption
+	rules   map[string]*methodRules
+
+	unaryChains  sync.Map
+	streamChains sync.Map
+}
+
+type methodRules struct {
+	include []server.KindInterceptor
+	exclude []*regexp.Regexp
+}
+
+// SetGlobal - set interceptors applied to every method,
+// if global interceptors are not set, all registered interceptors are applied to every method
+func (i *Interceptors) SetGlobal(interceptors ...server.KindInterceptor) {
+	for _, item := range interceptors {
+		if item != "" && !containsKind(i.global, item) {
+			i.global = append(i.global, item)
+		}
+	}
 }
 
+// SetMethodOptions - set interceptor options for full method name (/package.Service/Method)
+func (i *Interceptors) SetMethodOptions(method string, opts ...server.GRPCMethodOption) {
+	i.options[method] = append(i.options[method], opts...)
+}
+
+// AddConstructor - add interceptor constructor, constructor is treated as unary
+// or stream (or both) interceptor constructor by the type it returns
 func (i *Interceptors) AddConstructor(h interface{}) {
-	i.constructors = append(i.constructors, h)
+
+	var unary, stream bool
+
+	if t := reflect.TypeOf(h); t != nil && t.Kind() == reflect.Func && t.NumOut() > 0 {
+		unary = t.Out(0).Implements(unaryInterceptorType)
+		stream = t.Out(0).Implements(streamInterceptorType)
+	}
+
+	if stream {
+		i.streamConstructors = append(i.streamConstructors, h)
+	}
+
+	if unary || !stream {
+		i.constructors = append(i.constructors, h)
+	}
 }
 
 func (i *Interceptors) Add(h server.GRPCInterceptor) {
-	i.items[h.Kind()] = h
+	i.items = append(i.items, h)
+}
+
+func (i *Interceptors) AddStream(h server.GRPCStreamInterceptor) {
+	i.streams = append(i.streams, h)
+}
+
+// prepare - sort interceptors by order and validate global and per-method options
+func (i *Interceptors) prepare() error {
+
+	sort.SliceStable(i.items, func(a, b int) bool {
+		return i.items[a].Order() < i.items[b].Order()
+	})
+
+	sort.SliceStable(i.streams, func(a, b int) bool {
+		return i.streams[a].Order() < i.streams[b].Order()
+	})
+
+	for _, kind := range i.global {
+		if !i.exists(kind) {
+			i.log.Errorf(InterceptorNotFoundError, "*", kind)
+			return fmt.Errorf("can not find server interceptor: %s", kind)
+		}
+	}
+
+	i.rules = make(map[string]*methodRules, len(i.options))
+
+	for method, opts := range i.options {
+		rules := new(methodRules)
+
+		for _, opt := range opts {
+			switch o := opt.(type) {
+			case *optionInterceptor:
+				if !i.exists(o.interceptor) {
+					i.log.Errorf(InterceptorNotFoundError, method, o.interceptor)
+					return fmt.Errorf("can not find server interceptor: %s", o.interceptor)
+				}
+				rules.include = append(rules.include, o.interceptor)
+			case *optionExcludeGlobalInterceptor:
+				re, err := regexp.Compile(o.regexp)
+				if err != nil {
+					return fmt.Errorf("invalid exclude interceptor regexp for method %s: %v", method, err)
+				}
+				rules.exclude = append(rules.exclude, re)
+			}
+		}
+
+		i.rules[method] = rules
+	}
+
+	return nil
+}
+
+// exists - check if unary or stream interceptor with kind is registered
+func (i *Interceptors) exists(kind server.KindInterceptor) bool {
+	for _, item := range i.items {
+		if item.Kind() == kind {
+			return true
+		}
+	}
+	for _, item := range i.streams {
+		if item.Kind() == kind {
+			return true
+		}
+	}
+	return false
+}
+
+// applies - check if interceptor kind should be applied to the method
+func (i *Interceptors) applies(method string, kind server.KindInterceptor) bool {
+
+	rules := i.rules[method]
+
+	if rules != nil && containsKind(rules.include, kind) {
+		return true
+	}
+
+	if len(i.global) > 0 && !containsKind(i.global, kind) {
+		return false
+	}
+
+	if rules != nil {
+		for _, re := range rules.exclude {
+			if re.MatchString(string(kind)) {
+				return false
+			}
+		}
+	}
+
+	return true
+}
+
+func (i *Interceptors) unaryChain(method string) []server.GRPCInterceptor {
+	if chain, ok := i.unaryChains.Load(method); ok {
+		return chain.([]server.GRPCInterceptor)
+	}
+
+	chain := make([]server.GRPCInterceptor, 0)
+	for _, item := range i.items {
+		if i.applies(method, item.Kind()) {
+			i.log.V(5).Infof("apply interceptor %s to %s", item.Kind(), method)
+			chain = append(chain, item)
+		}
+	}
+
+	i.unaryChains.Store(method, chain)
+	return chain
+}
+
+func (i *Interceptors) streamChain(method string) []server.GRPCStreamInterceptor {
+	if chain, ok := i.streamChains.Load(method); ok {
+		return chain.([]server.GRPCStreamInterceptor)
+	}
+
+	chain := make([]server.GRPCStreamInterceptor, 0)
+	for _, item := range i.streams {
+		if i.applies(method, item.Kind()) {
+			i.log.V(5).Infof("apply stream interceptor %s to %s", item.Kind(), method)
+			chain = append(chain, item)
+		}
+	}
+
+	i.streamChains.Store(method, chain)
+	return chain
+}
+
+// unaryInterceptor - call interceptors applied to the method in order
+func (i *Interceptors) unaryInterceptor(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	chain := i.unaryChain(info.FullMethod)
+	for n := len(chain) - 1; n >= 0; n-- {
+		interceptor, next := chain[n], handler
+		handler = func(ctx context.Context, req interface{}) (interface{}, error) {
+			return interceptor.Interceptor(ctx, req, info, next)
+		}
+	}
+	return handler(ctx, req)
+}
+
+// streamInterceptor - call stream interceptors applied to the method in order
+func (i *Interceptors) streamInterceptor(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	chain := i.streamChain(info.FullMethod)
+	for n := len(chain) - 1; n >= 0; n-- {
+		interceptor, next := chain[n], handler
+		handler = func(srv interface{}, ss grpc.ServerStream) error {
+			return interceptor.StreamInterceptor(srv, ss, info, next)
+		}
+	}
+	return handler(srv, ss)
+}
+
+func containsKind(kinds []server.KindInterceptor, kind server.KindInterceptor) bool {
+	for _, k := range kinds {
+		if k == kind {
+			return true
+		}
+	}
+	return false
 }
 
 func newInterceptors(log logger.Logger) *Interceptors {
 	interceptors := Interceptors{
-		log:          log,
-		constructors: make([]interface{}, 0),
-		items:        make(map[server.KindInterceptor]server.GRPCInterceptor),
+		log:                log,
+		global:             make([]server.KindInterceptor, 0),
+		constructors:       make([]interface{}, 0),
+		streamConstructors: make([]interface{}, 0),
+		items:              make([]server.GRPCInterceptor, 0),
+		streams:            make([]server.GRPCStreamInterceptor, 0),
+		options:            make(map[string][]server.GRPCMethodOption, 0),
+		rules:              make(map[string]*methodRules, 0),
 	}
 
 	return &interceptors
@@ -0,0 +1,255 @@
+package grpc
+
+import (
+	"context"
+	"testing"
+
+	"github.com/lastbackend/toolkit/pkg/runtime/logger/empty"
+	"github.com/lastbackend/toolkit/pkg/server"
+	"google.golang.org/grpc"
+)
+
+// testInterceptor - record interceptor kind on every call
+type testInterceptor struct {
+	kind  server.KindInterceptor
+	order int
+	calls *[]string
+}
+
+func (i testInterceptor) Kind() server.KindInterceptor {
+	return i.kind
+}
+
+func (i testInterceptor) Order() int {
+	return i.order
+}
+
+func (i testInterceptor) Interceptor(ctx context.Context, req interface{}, _ *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	*i.calls = append(*i.calls, string(i.kind))
+	return handler(ctx, req)
+}
+
+func (i testInterceptor) StreamInterceptor(srv interface{}, ss grpc.ServerStream, _ *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	*i.calls = append(*i.calls, string(i.kind))
+	return handler(srv, ss)
+}
+
+type testUnaryInterceptor struct {
+	server.GRPCInterceptor
+}
+
+type testStreamInterceptor struct {
+	server.GRPCStreamInterceptor
+}
+
+func equalCalls(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func TestInterceptors_AddConstructor(t *testing.T) {
+	tests := []struct {
+		name        string
+		constructor interface{}
+		unary       int
+		stream      int
+	}{
+		{"unary interceptor", func() testUnaryInterceptor { return testUnaryInterceptor{} }, 1, 0},
+		{"stream interceptor", func() testStreamInterceptor { return testStreamInterceptor{} }, 0, 1},
+		{"unary and stream interceptor", func() testInterceptor { return testInterceptor{} }, 1, 1},
+		{"unknown constructor", func() string { return "" }, 1, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			i := newInterceptors(empty.NewLogger())
+			i.AddConstructor(tt.constructor)
+
+			if len(i.constructors) != tt.unary {
+				t.Error("unary constructors: expected", tt.unary, "received", len(i.constructors))
+			}
+			if len(i.streamConstructors) != tt.stream {
+				t.Error("stream constructors: expected", tt.stream, "received", len(i.streamConstructors))
+			}
+		})
+	}
+}
+
+func TestInterceptors_StreamInterceptor(t *testing.T) {
+	tests := []struct {
+		name   string
+		items  []testInterceptor
+		global []server.KindInterceptor
+		calls  []string
+	}{
+		{
+			"no interceptors",
+			nil,
+			nil,
+			[]string{"handler"},
+		},
+		{
+			"interceptors called in order",
+			[]testInterceptor{{kind: "b", order: 2}, {kind: "a", order: 1}},
+			nil,
+			[]string{"a", "b", "handler"},
+		},
+		{
+			"only global interceptors",
+			[]testInterceptor{{kind: "a", order: 1}, {kind: "b", order: 2}},
+			[]server.KindInterceptor{"b"},
+			[]string{"b", "handler"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			calls := make([]string, 0)
+
+			i := newInterceptors(empty.NewLogger())
+			for _, item := range tt.items {
+				item.calls = &calls
+				i.AddStream(item)
+			}
+			i.SetGlobal(tt.global...)
+			if err := i.prepare(); err != nil {
+				t.Fatal(err)
+			}
+
+			handler := func(srv interface{}, ss grpc.ServerStream) error {
+				calls = append(calls, "handler")
+				return nil
+			}
+
+			info := &grpc.StreamServerInfo{FullMethod: "/test.Foo/Stream"}
+			if err := i.streamInterceptor(nil, nil, info, handler); err != nil {
+				t.Fatal(err)
+			}
+
+			if !equalCalls(calls, tt.calls) {
+				t.Error("calls: expected", tt.calls, "received", calls)
+			}
+		})
+	}
+}
+
+func TestInterceptors_UnaryInterceptor(t *testing.T) {
+	tests := []struct {
+		name    string
+		items   []testInterceptor
+		global  []server.KindInterceptor
+		options []server.GRPCMethodOption
+		calls   []string
+		err     bool
+	}{
+		{
+			"interceptors sorted by order",
+			[]testInterceptor{{kind: "c", order: 3}, {kind: "a", order: 1}, {kind: "b", order: 2}},
+			nil,
+			nil,
+			[]string{"a", "b", "c", "handler"},
+			false,
+		},
+		{
+			"same order keeps registration order",
+			[]testInterceptor{{kind: "b"}, {kind: "a"}},
+			nil,
+			nil,
+			[]string{"b", "a", "handler"},
+			false,
+		},
+		{
+			"global interceptors",
+			[]testInterceptor{{kind: "a", order: 1}, {kind: "b", order: 2}},
+			[]server.KindInterceptor{"a"},
+			nil,
+			[]string{"a", "handler"},
+			false,
+		},
+		{
+			"method interceptor added to global",
+			[]testInterceptor{{kind: "a", order: 1}, {kind: "b", order: 2}},
+			[]server.KindInterceptor{"a"},
+			[]server.GRPCMethodOption{WithInterceptor("b")},
+			[]string{"a", "b", "handler"},
+			false,
+		},
+		{
+			"global interceptor excluded for method",
+			[]testInterceptor{{kind: "auth", order: 1}, {kind: "audit", order: 2}, {kind: "log", order: 3}},
+			nil,
+			[]server.GRPCMethodOption{WithExcludeGlobalInterceptor("^au")},
+			[]string{"log", "handler"},
+			false,
+		},
+		{
+			"unknown global interceptor",
+			[]testInterceptor{{kind: "a"}},
+			[]server.KindInterceptor{"b"},
+			nil,
+			nil,
+			true,
+		},
+		{
+			"unknown method interceptor",
+			[]testInterceptor{{kind: "a"}},
+			nil,
+			[]server.GRPCMethodOption{WithInterceptor("b")},
+			nil,
+			true,
+		},
+		{
+			"invalid exclude regexp",
+			[]testInterceptor{{kind: "a"}},
+			nil,
+			[]server.GRPCMethodOption{WithExcludeGlobalInterceptor("(")},
+			nil,
+			true,
+		},
+	}
+
+	const method = "/test.Foo/Call"
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			calls := make([]string, 0)
+
+			i := newInterceptors(empty.NewLogger())
+			for _, item := range tt.items {
+				item.calls = &calls
+				i.Add(item)
+			}
+			i.SetGlobal(tt.global...)
+			i.SetMethodOptions(method, tt.options...)
+
+			err := i.prepare()
+			if (err != nil) != tt.err {
+				t.Fatal("error: expected", tt.err, "received", err)
+			}
+			if tt.err {
+				return
+			}
+
+			handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+				calls = append(calls, "handler")
+				return req, nil
+			}
+
+			info := &grpc.UnaryServerInfo{FullMethod: method}
+			if _, err := i.unaryInterceptor(context.Background(), nil, info, handler); err != nil {
+				t.Fatal(err)
+			}
+
+			if !equalCalls(calls, tt.calls) {
+				t.Error("calls: expected", tt.calls, "received", calls)
+			}
+		})
+	}
+}
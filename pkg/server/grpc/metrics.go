@@ -0,0 +1,125 @@
+/*
+Copyright [2014] - [2023] The Last.Backend authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package grpc
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/lastbackend/toolkit/pkg/tools/metrics"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const unknownLabel = "unknown"
+
+const (
+	metricRequestsTotal   = "grpc_server_requests_total"
+	metricErrorsTotal     = "grpc_server_errors_total"
+	metricHandlingSeconds = "grpc_server_handling_seconds"
+)
+
+type serverMetrics struct {
+	name     string
+	requests metrics.Counter
+	errors   metrics.Counter
+	duration metrics.Histogram
+	// full method names of the registered services, other methods are labeled as unknown
+	methods map[string]struct{}
+}
+
+func newServerMetrics(name string, m metrics.Metrics) (*serverMetrics, error) {
+	var (
+		sm  = &serverMetrics{name: name, methods: make(map[string]struct{}, 0)}
+		err error
+	)
+
+	if sm.requests, err = m.RegisterCounter(metricRequestsTotal,
+		"Total number of RPCs completed on the server, regardless of success or failure.",
+		"server", "service", "method", "code"); err != nil {
+		return nil, err
+	}
+
+	if sm.errors, err = m.RegisterCounter(metricErrorsTotal,
+		"Total number of RPCs completed on the server with non-OK status code.",
+		"server", "service", "method", "code"); err != nil {
+		return nil, err
+	}
+
+	if sm.duration, err = m.RegisterHistogram(metricHandlingSeconds,
+		"Histogram of response latency (seconds) of RPCs handled by the server.",
+		metrics.DefBuckets, "server", "service", "method"); err != nil {
+		return nil, err
+	}
+
+	return sm, nil
+}
+
+// register - remember methods of the registered services, must be called before the server starts serving
+func (m *serverMetrics) register(services map[string]grpc.ServiceInfo) {
+	for name, info := range services {
+		for _, method := range info.Methods {
+			m.methods[fmt.Sprintf("/%s/%s", name, method.Name)] = struct{}{}
+		}
+	}
+}
+
+// labels - return service and method labels, methods of unknown services (e.g. proxied calls)
+// are reported with a fixed label to keep labels cardinality bounded
+func (m *serverMetrics) labels(fullMethod string) (string, string) {
+	if _, ok := m.methods[fullMethod]; !ok {
+		return unknownLabel, unknownLabel
+	}
+	return splitMethodName(fullMethod)
+}
+
+func (m *serverMetrics) observe(fullMethod string, started time.Time, err error) {
+	service, method := m.labels(fullMethod)
+	code := status.Code(err).String()
+
+	m.requests.Inc(m.name, service, method, code)
+	if status.Code(err) != codes.OK {
+		m.errors.Inc(m.name, service, method, code)
+	}
+	m.duration.Observe(time.Since(started).Seconds(), m.name, service, method)
+}
+
+func (m *serverMetrics) unaryInterceptor(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	started := time.Now()
+	resp, err := handler(ctx, req)
+	m.observe(info.FullMethod, started, err)
+	return resp, err
+}
+
+func (m *serverMetrics) streamInterceptor(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	started := time.Now()
+	err := handler(srv, ss)
+	m.observe(info.FullMethod, started, err)
+	return err
+}
+
+// splitMethodName - split "/package.service/method" to service and method names
+func splitMethodName(fullMethod string) (string, string) {
+	fullMethod = strings.TrimPrefix(fullMethod, "/")
+	if i := strings.Index(fullMethod, "/"); i >= 0 {
+		return fullMethod[:i], fullMethod[i+1:]
+	}
+	return unknownLabel, unknownLabel
+}
@@ -0,0 +1,150 @@
+package grpc
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/lastbackend/toolkit/pkg/tools/metrics"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// fakeMetrics - records counter increments by joined label values
+type fakeMetrics struct {
+	mtx    sync.Mutex
+	values map[string]float64
+}
+
+func newFakeMetrics() *fakeMetrics {
+	return &fakeMetrics{values: make(map[string]float64)}
+}
+
+func (f *fakeMetrics) add(name string, v float64, labels ...string) {
+	f.mtx.Lock()
+	defer f.mtx.Unlock()
+	f.values[name+"{"+strings.Join(labels, ",")+"}"] += v
+}
+
+func (f *fakeMetrics) get(name string, labels ...string) float64 {
+	f.mtx.Lock()
+	defer f.mtx.Unlock()
+	return f.values[name+"{"+strings.Join(labels, ",")+"}"]
+}
+
+func (f *fakeMetrics) Start(_ context.Context) error { return nil }
+func (f *fakeMetrics) Handler() http.Handler         { return http.NotFoundHandler() }
+func (f *fakeMetrics) OnCollect(_ func())            {}
+
+func (f *fakeMetrics) RegisterCounter(name, _ string, _ ...string) (metrics.Counter, error) {
+	return &fakeCollector{name: name, m: f}, nil
+}
+
+func (f *fakeMetrics) RegisterGauge(name, _ string, _ ...string) (metrics.Gauge, error) {
+	return &fakeCollector{name: name, m: f}, nil
+}
+
+func (f *fakeMetrics) RegisterHistogram(name, _ string, _ []float64, _ ...string) (metrics.Histogram, error) {
+	return &fakeCollector{name: name, m: f}, nil
+}
+
+type fakeCollector struct {
+	name string
+	m    *fakeMetrics
+}
+
+func (c *fakeCollector) Inc(labels ...string)            { c.m.add(c.name, 1, labels...) }
+func (c *fakeCollector) Dec(labels ...string)            { c.m.add(c.name, -1, labels...) }
+func (c *fakeCollector) Add(v float64, labels ...string) { c.m.add(c.name, v, labels...) }
+func (c *fakeCollector) Set(v float64, labels ...string) { c.m.add(c.name, v, labels...) }
+func (c *fakeCollector) Observe(_ float64, labels ...string) {
+	c.m.add(c.name, 1, labels...)
+}
+
+func TestServerMetrics_Observe(t *testing.T) {
+	tests := []struct {
+		name    string
+		method  string
+		err     error
+		service string
+		label   string
+		code    string
+		errors  float64
+	}{
+		{
+			"registered method",
+			"/helloworld.Greeter/SayHello",
+			nil,
+			"helloworld.Greeter",
+			"SayHello",
+			codes.OK.String(),
+			0,
+		},
+		{
+			"registered method with error",
+			"/helloworld.Greeter/SayHello",
+			status.Error(codes.NotFound, "not found"),
+			"helloworld.Greeter",
+			"SayHello",
+			codes.NotFound.String(),
+			1,
+		},
+		{
+			"unknown method of registered service",
+			"/helloworld.Greeter/SayBye",
+			nil,
+			unknownLabel,
+			unknownLabel,
+			codes.OK.String(),
+			0,
+		},
+		{
+			"unknown service",
+			"/proxied.Service/Call",
+			errors.New("failed"),
+			unknownLabel,
+			unknownLabel,
+			codes.Unknown.String(),
+			1,
+		},
+		{
+			"malformed method",
+			"garbage",
+			nil,
+			unknownLabel,
+			unknownLabel,
+			codes.OK.String(),
+			0,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fm := newFakeMetrics()
+			sm, err := newServerMetrics("test", fm)
+			if err != nil {
+				t.Fatal(err)
+			}
+			sm.register(map[string]grpc.ServiceInfo{
+				"helloworld.Greeter": {Methods: []grpc.MethodInfo{{Name: "SayHello"}}},
+			})
+
+			sm.observe(tt.method, time.Now(), tt.err)
+
+			if v := fm.get(metricRequestsTotal, "test", tt.service, tt.label, tt.code); v != 1 {
+				t.Error("requests: expected", 1, "received", v)
+			}
+			if v := fm.get(metricErrorsTotal, "test", tt.service, tt.label, tt.code); v != tt.errors {
+				t.Error("errors: expected", tt.errors, "received", v)
+			}
+			if v := fm.get(metricHandlingSeconds, "test", tt.service, tt.label); v != 1 {
+				t.Error("duration: expected", 1, "received", v)
+			}
+		})
+	}
+}
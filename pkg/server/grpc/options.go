@@ -19,6 +19,7 @@ package grpc
 import (
 	"github.com/google/uuid"
 	"github.com/improbable-eng/grpc-web/go/grpcweb"
+	"github.com/lastbackend/toolkit/pkg/server"
 	"google.golang.org/grpc"
 
 	"crypto/tls"
@@ -30,8 +31,40 @@ const (
 	defaultName             = "go.toolkit.server"
 	defaultRegisterInterval = time.Second * 30
 	defaultRegisterTTL      = time.Second * 90
+	defaultHealthInterval   = time.Second * 5
+
+	optionKindInterceptor              server.GrpcOptionKind = "interceptor"
+	optionKindExcludeGlobalInterceptor server.GrpcOptionKind = "excludeGlobalInterceptor"
 )
 
+type optionInterceptor struct {
+	kind        server.GrpcOptionKind
+	interceptor server.KindInterceptor
+}
+
+func (optionInterceptor) Kind() server.GrpcOptionKind {
+	return optionKindInterceptor
+}
+
+// WithInterceptor - apply interceptor to the method in addition to global interceptors
+func WithInterceptor(interceptor server.KindInterceptor) server.GRPCMethodOption {
+	return &optionInterceptor{kind: optionKindInterceptor, interceptor: interceptor}
+}
+
+type optionExcludeGlobalInterceptor struct {
+	kind   server.GrpcOptionKind
+	regexp string
+}
+
+func (optionExcludeGlobalInterceptor) Kind() server.GrpcOptionKind {
+	return optionKindExcludeGlobalInterceptor
+}
+
+// WithExcludeGlobalInterceptor - skip global interceptors matched by regexp for the method
+func WithExcludeGlobalInterceptor(regexp string) server.GRPCMethodOption {
+	return &optionExcludeGlobalInterceptor{kind: optionKindExcludeGlobalInterceptor, regexp: regexp}
+}
+
 type Config struct {
 	ID   string
 	Name string `env:"GRPC_SERVER_NAME" envDefault:"" comment:"Set GRPC server name"`
@@ -45,16 +78,32 @@ type Config struct {
 
 	IsDisable bool `env:"GRPC_SERVER_DISABLED" envDefault:"false" comment:"GRPC server disable (default: false)"`
 
+	EnableMetrics  bool `env:"GRPC_SERVER_METRICS_ENABLED" envDefault:"false" comment:"Enable requests, errors and latency metrics for every GRPC method (default: false)"`
+	EnableRecovery bool `env:"GRPC_SERVER_RECOVERY_ENABLED" envDefault:"true" comment:"Recover panics in GRPC methods and return Internal error code (default: true)"`
+
+	EnableReflection    bool          `env:"GRPC_SERVER_REFLECTION_ENABLED" envDefault:"false" comment:"Register GRPC server reflection service (default: false)"`
+	EnableHealth        bool          `env:"GRPC_SERVER_HEALTH_ENABLED" envDefault:"false" comment:"Register grpc.health.v1.Health service driven by readiness probes (default: false)"`
+	HealthWatchInterval time.Duration `env:"GRPC_SERVER_HEALTH_WATCH_INTERVAL" envDefault:"5s" comment:"Set interval of readiness checks for grpc.health.v1.Health watch streams"`
+
+	EnableProxy bool `env:"GRPC_SERVER_PROXY_ENABLED" envDefault:"false" comment:"Proxy calls of unknown services to the services found by client resolver (default: false)"`
+
 	GrpcOptions []grpc.ServerOption `env:"GRPC_SERVER_OPTIONS" envSeparator:"," comment:"Set GRPC server additional options (key=value,key2=value2)"`
-	TLSConfig   *tls.Config
+
+	TLSCertFile       string        `env:"GRPC_SERVER_TLS_CERT_FILE" comment:"Set path to GRPC server TLS certificate file, enables TLS"`
+	TLSKeyFile        string        `env:"GRPC_SERVER_TLS_KEY_FILE" comment:"Set path to GRPC server TLS private key file"`
+	TLSCAFile         string        `env:"GRPC_SERVER_TLS_CA_FILE" comment:"Set path to CA bundle used to verify client certificates, requires GRPC_SERVER_TLS_CLIENT_AUTH"`
+	TLSClientAuth     bool          `env:"GRPC_SERVER_TLS_CLIENT_AUTH" envDefault:"false" comment:"Require and verify client certificates (mTLS)"`
+	TLSReloadInterval time.Duration `env:"GRPC_SERVER_TLS_RELOAD_INTERVAL" envDefault:"1m" comment:"Set interval of TLS files modification checks, modified files are reloaded"`
+
+	TLSConfig *tls.Config
 
 	GRPCWebHost string `env:"GRPC_WEB_SERVER_LISTEN" envDefault:"0.0.0.0" comment:"Set GRPC WEB server listen host"`
 	GRPCWebPort int    `env:"GRPC_WEB_SERVER_PORT" comment:"Set GRPC WEB server listen host"`
 
 	GrpcWebOptions []grpcweb.Option
 
-	RegisterInterval time.Duration
-	RegisterTTL      time.Duration
+	RegisterInterval time.Duration `env:"GRPC_SERVER_REGISTER_INTERVAL" envDefault:"30s" comment:"Set interval of GRPC server registration refresh in resolver"`
+	RegisterTTL      time.Duration `env:"GRPC_SERVER_REGISTER_TTL" envDefault:"90s" comment:"Set time after which not refreshed GRPC server registration is expired"`
 }
 
 func defaultOptions() Config {
@@ -64,5 +113,8 @@ func defaultOptions() Config {
 		Port:             defaultPort,
 		RegisterInterval: defaultRegisterInterval,
 		RegisterTTL:      defaultRegisterTTL,
+
+		HealthWatchInterval: defaultHealthInterval,
+		EnableRecovery:      true,
 	}
 }
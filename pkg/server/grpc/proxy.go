@@ -0,0 +1,208 @@
+/*
+Copyright [2014] - [2023] The Last.Backend authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package grpc
+
+import (
+	"context"
+	"io"
+	"strings"
+
+	"github.com/lastbackend/toolkit/pkg/client"
+	"github.com/lastbackend/toolkit/pkg/runtime"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/encoding"
+	encoding_proto "google.golang.org/grpc/encoding/proto"
+	grpc_md "google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// frame - raw message forwarded by proxy without decoding
+type frame struct {
+	payload []byte
+}
+
+// proxyCodec - pass raw frames through, other messages are handled by the wrapped proto codec
+type proxyCodec struct {
+	encoding.Codec
+}
+
+var defaultProxyCodec = proxyCodec{Codec: encoding.GetCodec(encoding_proto.Name)}
+
+func init() {
+	// the proto codec is wrapped instead of forcing the codec on the server,
+	// so registered services keep content-subtype codec selection and only unknown service calls receive raw frames
+	encoding.RegisterCodec(defaultProxyCodec)
+}
+
+func (c proxyCodec) Marshal(v interface{}) ([]byte, error) {
+	if f, ok := v.(*frame); ok {
+		return f.payload, nil
+	}
+	return c.Codec.Marshal(v)
+}
+
+func (c proxyCodec) Unmarshal(data []byte, v interface{}) error {
+	if f, ok := v.(*frame); ok {
+		f.payload = append(f.payload[:0], data...)
+		return nil
+	}
+	return c.Codec.Unmarshal(data, v)
+}
+
+// serverProxy - forward calls of unknown services to the service resolved by client resolver
+type serverProxy struct {
+	runtime runtime.Runtime
+}
+
+func newServerProxy(runtime runtime.Runtime) *serverProxy {
+	return &serverProxy{runtime: runtime}
+}
+
+func (p *serverProxy) handler(_ interface{}, ss grpc.ServerStream) error {
+
+	fullMethod, ok := grpc.MethodFromServerStream(ss)
+	if !ok {
+		return status.Error(codes.Internal, "can not get method from server stream")
+	}
+
+	service, _ := splitMethodName(fullMethod)
+
+	cli := p.runtime.Client().GRPC()
+	if cli == nil || cli.GetResolver() == nil {
+		return status.Errorf(codes.Unimplemented, "unknown service %s", service)
+	}
+
+	// the first request is passed to the client stream to open it through the client call path
+	var body interface{}
+	first := new(frame)
+	switch err := ss.RecvMsg(first); err {
+	case nil:
+		body = first
+	case io.EOF:
+	default:
+		return err
+	}
+
+	ctx, cancel := context.WithCancel(ss.Context())
+	defer cancel()
+
+	// all values of incoming metadata are forwarded, pseudo headers are set by the transport
+	md, _ := grpc_md.FromIncomingContext(ctx)
+	out := make(grpc_md.MD, len(md))
+	for k, v := range md {
+		if !strings.HasPrefix(k, ":") {
+			out[k] = v
+		}
+	}
+	ctx = grpc_md.NewOutgoingContext(ctx, out)
+
+	cs, err := cli.Stream(ctx, service, fullMethod, body,
+		client.GRPCOptionCodec(defaultProxyCodec),
+		client.GRPCOptionResolvedOnly())
+	if err != nil {
+		return err
+	}
+
+	p.runtime.Log().V(7).Infof("server [grpc] proxy %s call", fullMethod)
+
+	var c2s chan error
+	if body == nil {
+		c2s = make(chan error, 1)
+		c2s <- cs.CloseSend()
+	} else {
+		c2s = forwardClientToServer(ss, cs)
+	}
+	s2c := forwardServerToClient(cs, ss)
+
+	for i := 0; i < 2; i++ {
+		select {
+		case err := <-c2s:
+			if err == nil {
+				// client finished sending or upstream closed the stream, wait for the upstream status
+				continue
+			}
+			cancel()
+			if _, ok := status.FromError(err); ok {
+				return err
+			}
+			return status.Errorf(codes.Internal, "failed proxying request: %v", err)
+		case err := <-s2c:
+			ss.SetTrailer(cs.Trailer())
+			if err != io.EOF {
+				return err
+			}
+			return nil
+		}
+	}
+
+	return status.Error(codes.Internal, "proxy should never reach this stage")
+}
+
+// forwardClientToServer - forward requests from incoming stream to upstream service
+func forwardClientToServer(src grpc.ServerStream, dst grpc.ClientStream) chan error {
+	ch := make(chan error, 1)
+	go func() {
+		f := new(frame)
+		for {
+			if err := src.RecvMsg(f); err != nil {
+				if err == io.EOF {
+					err = dst.CloseSend()
+				}
+				ch <- err
+				return
+			}
+			if err := dst.SendMsg(f); err != nil {
+				if err == io.EOF {
+					// upstream finished the stream, its status is received by forwardServerToClient
+					err = nil
+				}
+				ch <- err
+				return
+			}
+		}
+	}()
+	return ch
+}
+
+// forwardServerToClient - forward responses from upstream service to incoming stream
+func forwardServerToClient(src grpc.ClientStream, dst grpc.ServerStream) chan error {
+	ch := make(chan error, 1)
+	go func() {
+		// headers are forwarded as soon as upstream sends them, also when it fails without messages,
+		// header error means the stream is finished and its status is returned by RecvMsg
+		if md, err := src.Header(); err == nil {
+			if err := dst.SendHeader(md); err != nil {
+				ch <- err
+				return
+			}
+		}
+
+		f := new(frame)
+		for {
+			if err := src.RecvMsg(f); err != nil {
+				ch <- err
+				return
+			}
+			if err := dst.SendMsg(f); err != nil {
+				ch <- err
+				return
+			}
+		}
+	}()
+	return ch
+}
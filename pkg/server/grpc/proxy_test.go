@@ -0,0 +1,315 @@
+package grpc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/lastbackend/toolkit/pkg/client"
+	grpc_client "github.com/lastbackend/toolkit/pkg/client/grpc"
+	"github.com/lastbackend/toolkit/pkg/client/grpc/resolver"
+	"github.com/lastbackend/toolkit/pkg/client/grpc/resolver/route"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/encoding"
+	"google.golang.org/grpc/health"
+	health_pb "google.golang.org/grpc/health/grpc_health_v1"
+	grpc_md "google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+)
+
+// jsonCodec - content-subtype codec used to check codec selection of registered services
+type jsonCodec struct{}
+
+func (jsonCodec) Marshal(v interface{}) ([]byte, error) {
+	return protojson.Marshal(v.(proto.Message))
+}
+
+func (jsonCodec) Unmarshal(data []byte, v interface{}) error {
+	return protojson.Unmarshal(data, v.(proto.Message))
+}
+
+func (jsonCodec) Name() string {
+	return "json"
+}
+
+func init() {
+	encoding.RegisterCodec(jsonCodec{})
+}
+
+// testResolver - resolve services from the static routes table
+type testResolver struct {
+	resolver.Resolver
+	routes map[string]route.List
+}
+
+func (r *testResolver) Lookup(service string, _ ...resolver.LookupOption) (route.List, error) {
+	if routes, ok := r.routes[service]; ok {
+		return routes, nil
+	}
+	return nil, route.ErrRouteNotFound
+}
+
+func (r *testResolver) Watch(_ string) (resolver.Watcher, error) {
+	return nil, errors.New("not supported")
+}
+
+// countingClient - count streams successfully opened through the client
+type countingClient struct {
+	client.GRPCClient
+	streams int32
+}
+
+func (c *countingClient) Stream(ctx context.Context, service, method string, body interface{}, opts ...client.GRPCCallOption) (grpc.ClientStream, error) {
+	cs, err := c.GRPCClient.Stream(ctx, service, method, body, opts...)
+	if err == nil {
+		atomic.AddInt32(&c.streams, 1)
+	}
+	return cs, err
+}
+
+// uploadDesc - client streaming service failing before reading requests
+var uploadDesc = grpc.ServiceDesc{
+	ServiceName: "test.Upload",
+	HandlerType: (*interface{})(nil),
+	Streams: []grpc.StreamDesc{{
+		StreamName:    "Upload",
+		ClientStreams: true,
+		Handler: func(_ interface{}, _ grpc.ServerStream) error {
+			return status.Error(codes.FailedPrecondition, "upload rejected")
+		},
+	}},
+}
+
+// echoDesc - service sending values of x-value metadata in x-echo header and failing without messages
+var echoDesc = grpc.ServiceDesc{
+	ServiceName: "test.Echo",
+	HandlerType: (*interface{})(nil),
+	Streams: []grpc.StreamDesc{{
+		StreamName:    "Echo",
+		ServerStreams: true,
+		ClientStreams: true,
+		Handler: func(_ interface{}, ss grpc.ServerStream) error {
+			md, _ := grpc_md.FromIncomingContext(ss.Context())
+			if err := ss.SendHeader(grpc_md.MD{"x-echo": md.Get("x-value")}); err != nil {
+				return err
+			}
+			return status.Error(codes.Aborted, "echo")
+		},
+	}},
+}
+
+// localDesc - service registered on the proxy server
+var localDesc = grpc.ServiceDesc{
+	ServiceName: "test.Local",
+	HandlerType: (*interface{})(nil),
+	Methods: []grpc.MethodDesc{{
+		MethodName: "Check",
+		Handler: func(_ interface{}, _ context.Context, dec func(interface{}) error, _ grpc.UnaryServerInterceptor) (interface{}, error) {
+			req := new(health_pb.HealthCheckRequest)
+			if err := dec(req); err != nil {
+				return nil, err
+			}
+			return &health_pb.HealthCheckResponse{Status: health_pb.HealthCheckResponse_SERVING}, nil
+		},
+	}},
+}
+
+func serve(t *testing.T, srv *grpc.Server) string {
+	t.Helper()
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	go func() {
+		_ = srv.Serve(listener)
+	}()
+	t.Cleanup(srv.Stop)
+
+	return listener.Addr().String()
+}
+
+func TestServerProxy_Handler(t *testing.T) {
+
+	upstream := grpc.NewServer()
+	hs := health.NewServer()
+	hs.SetServingStatus("test.Foo", health_pb.HealthCheckResponse_SERVING)
+	health_pb.RegisterHealthServer(upstream, hs)
+	upstream.RegisterService(&uploadDesc, struct{}{})
+	upstream.RegisterService(&echoDesc, struct{}{})
+	upstreamAddr := serve(t, upstream)
+
+	cli, err := grpc_client.NewClient(context.Background(), testRuntime{environment: map[string]string{
+		"GRPC_CLIENT_RESOLVER": "static",
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	cli.SetResolver(&testResolver{routes: map[string]route.List{
+		"grpc.health.v1.Health": {{Service: "grpc.health.v1.Health", Address: upstreamAddr}},
+		"test.Upload":           {{Service: "test.Upload", Address: upstreamAddr}},
+		"test.Echo":             {{Service: "test.Echo", Address: upstreamAddr}},
+	}})
+
+	counter := &countingClient{GRPCClient: cli}
+
+	g := NewServer(testRuntime{
+		environment: map[string]string{"TEST_GRPC_SERVER_PROXY_ENABLED": "true"},
+		client:      &testClient{grpc: counter},
+	}, "test", nil).(*grpcServer)
+
+	// registered services and proxied unknown services are served by the same server
+	srv := grpc.NewServer(g.parseOptions(nil)...)
+	srv.RegisterService(&localDesc, struct{}{})
+	proxyAddr := serve(t, srv)
+
+	conn, err := grpc.Dial(proxyAddr, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	tests := []struct {
+		name    string
+		call    func(ctx context.Context) error
+		code    codes.Code
+		proxied bool
+	}{
+		{
+			"unary call",
+			func(ctx context.Context) error {
+				rsp, err := health_pb.NewHealthClient(conn).Check(ctx, &health_pb.HealthCheckRequest{Service: "test.Foo"})
+				if err == nil && rsp.GetStatus() != health_pb.HealthCheckResponse_SERVING {
+					return errors.New("unexpected status " + rsp.GetStatus().String())
+				}
+				return err
+			},
+			codes.OK,
+			true,
+		},
+		{
+			"upstream error status",
+			func(ctx context.Context) error {
+				_, err := health_pb.NewHealthClient(conn).Check(ctx, &health_pb.HealthCheckRequest{Service: "test.Bar"})
+				return err
+			},
+			codes.NotFound,
+			true,
+		},
+		{
+			"server streaming call",
+			func(ctx context.Context) error {
+				stream, err := health_pb.NewHealthClient(conn).Watch(ctx, &health_pb.HealthCheckRequest{Service: "test.Foo"})
+				if err != nil {
+					return err
+				}
+				rsp, err := stream.Recv()
+				if err == nil && rsp.GetStatus() != health_pb.HealthCheckResponse_SERVING {
+					return errors.New("unexpected status " + rsp.GetStatus().String())
+				}
+				return err
+			},
+			codes.OK,
+			true,
+		},
+		{
+			"upstream closed client stream",
+			func(ctx context.Context) error {
+				stream, err := conn.NewStream(ctx, &grpc.StreamDesc{ClientStreams: true}, "/test.Upload/Upload")
+				if err != nil {
+					return err
+				}
+				for i := 0; i < 100; i++ {
+					if err := stream.SendMsg(&health_pb.HealthCheckRequest{Service: "chunk"}); err != nil {
+						break
+					}
+				}
+				_ = stream.CloseSend()
+				return stream.RecvMsg(new(health_pb.HealthCheckResponse))
+			},
+			codes.FailedPrecondition,
+			true,
+		},
+		{
+			"upstream headers and multi-value metadata",
+			func(ctx context.Context) error {
+				ctx = grpc_md.AppendToOutgoingContext(ctx, "x-value", "a", "x-value", "b")
+				stream, err := conn.NewStream(ctx, &grpc.StreamDesc{ServerStreams: true, ClientStreams: true}, "/test.Echo/Echo")
+				if err != nil {
+					return err
+				}
+				_ = stream.CloseSend()
+				err = stream.RecvMsg(new(health_pb.HealthCheckResponse))
+
+				md, _ := stream.Header()
+				if echo := md.Get("x-echo"); len(echo) != 2 || echo[0] != "a" || echo[1] != "b" {
+					return fmt.Errorf("unexpected header %v", echo)
+				}
+				return err
+			},
+			codes.Aborted,
+			true,
+		},
+		{
+			"registered service",
+			func(ctx context.Context) error {
+				rsp := new(health_pb.HealthCheckResponse)
+				err := conn.Invoke(ctx, "/test.Local/Check", &health_pb.HealthCheckRequest{}, rsp)
+				if err == nil && rsp.GetStatus() != health_pb.HealthCheckResponse_SERVING {
+					return errors.New("unexpected status " + rsp.GetStatus().String())
+				}
+				return err
+			},
+			codes.OK,
+			false,
+		},
+		{
+			"registered service with content subtype codec",
+			func(ctx context.Context) error {
+				rsp := new(health_pb.HealthCheckResponse)
+				err := conn.Invoke(ctx, "/test.Local/Check", &health_pb.HealthCheckRequest{Service: "test.Foo"}, rsp,
+					grpc.CallContentSubtype(jsonCodec{}.Name()))
+				if err == nil && rsp.GetStatus() != health_pb.HealthCheckResponse_SERVING {
+					return errors.New("unexpected status " + rsp.GetStatus().String())
+				}
+				return err
+			},
+			codes.OK,
+			false,
+		},
+		{
+			"unknown service",
+			func(ctx context.Context) error {
+				return conn.Invoke(ctx, "/test.Unknown/Call", &health_pb.HealthCheckRequest{}, new(health_pb.HealthCheckResponse))
+			},
+			codes.Unimplemented,
+			false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+
+			before := atomic.LoadInt32(&counter.streams)
+
+			err := tt.call(ctx)
+			if status.Code(err) != tt.code {
+				t.Error("code: expected", tt.code, "received", status.Code(err), err)
+			}
+
+			if called := atomic.LoadInt32(&counter.streams) > before; called != tt.proxied {
+				t.Error("client stream opened: expected", tt.proxied, "received", called)
+			}
+		})
+	}
+}
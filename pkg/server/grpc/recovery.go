@@ -0,0 +1,83 @@
+/*
+Copyright [2014] - [2023] The Last.Backend authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package grpc
+
+import (
+	"context"
+	"runtime/debug"
+
+	"github.com/lastbackend/toolkit/pkg/runtime/logger"
+	"github.com/lastbackend/toolkit/pkg/tools/metrics"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	metricPanicsTotal = "grpc_server_panics_total"
+)
+
+// serverRecovery - convert panics in handlers to codes.Internal errors
+type serverRecovery struct {
+	name   string
+	log    logger.Logger
+	panics metrics.Counter
+}
+
+func newServerRecovery(name string, log logger.Logger, m metrics.Metrics) (*serverRecovery, error) {
+	sr := &serverRecovery{name: name, log: log}
+
+	if m != nil {
+		var err error
+		if sr.panics, err = m.RegisterCounter(metricPanicsTotal,
+			"Total number of panics recovered in RPCs handled by the server.",
+			"server", "service", "method"); err != nil {
+			return nil, err
+		}
+	}
+
+	return sr, nil
+}
+
+func (r *serverRecovery) recover(fullMethod string, p interface{}) error {
+	r.log.Errorf("server [grpc] panic recovered in %s: %v\n%s", fullMethod, p, debug.Stack())
+
+	if r.panics != nil {
+		service, method := splitMethodName(fullMethod)
+		r.panics.Inc(r.name, service, method)
+	}
+
+	return status.Error(codes.Internal, "internal server error")
+}
+
+func (r *serverRecovery) unaryInterceptor(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (resp interface{}, err error) {
+	defer func() {
+		if p := recover(); p != nil {
+			err = r.recover(info.FullMethod, p)
+		}
+	}()
+	return handler(ctx, req)
+}
+
+func (r *serverRecovery) streamInterceptor(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) (err error) {
+	defer func() {
+		if p := recover(); p != nil {
+			err = r.recover(info.FullMethod, p)
+		}
+	}()
+	return handler(srv, ss)
+}
@@ -0,0 +1,60 @@
+package grpc
+
+import (
+	"context"
+	"testing"
+
+	"github.com/lastbackend/toolkit/pkg/runtime/logger/empty"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestServerRecovery_Interceptors(t *testing.T) {
+	tests := []struct {
+		name   string
+		fn     func() error
+		code   codes.Code
+		panics float64
+	}{
+		{"no panic", func() error { return nil }, codes.OK, 0},
+		{"handler error", func() error { return status.Error(codes.NotFound, "not found") }, codes.NotFound, 0},
+		{"panic with value", func() error { panic("boom") }, codes.Internal, 1},
+		{"nil pointer panic", func() error {
+			var s *grpcServer
+			return s.grpc.Serve(nil)
+		}, codes.Internal, 1},
+	}
+
+	const method = "/test.Foo/Call"
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fm := newFakeMetrics()
+			r, err := newServerRecovery("test", empty.NewLogger(), fm)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			_, err = r.unaryInterceptor(context.Background(), nil, &grpc.UnaryServerInfo{FullMethod: method},
+				func(ctx context.Context, req interface{}) (interface{}, error) {
+					return nil, tt.fn()
+				})
+			if status.Code(err) != tt.code {
+				t.Error("unary code: expected", tt.code, "received", status.Code(err))
+			}
+
+			err = r.streamInterceptor(nil, nil, &grpc.StreamServerInfo{FullMethod: method},
+				func(srv interface{}, ss grpc.ServerStream) error {
+					return tt.fn()
+				})
+			if status.Code(err) != tt.code {
+				t.Error("stream code: expected", tt.code, "received", status.Code(err))
+			}
+
+			if v := fm.get(metricPanicsTotal, "test", "test.Foo", "Call"); v != 2*tt.panics {
+				t.Error("panics: expected", 2*tt.panics, "received", v)
+			}
+		})
+	}
+}
@@ -0,0 +1,86 @@
+/*
+Copyright [2014] - [2023] The Last.Backend authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package grpc
+
+import (
+	"fmt"
+	"reflect"
+
+	"google.golang.org/grpc"
+)
+
+type serviceItem struct {
+	descriptor *grpc.ServiceDesc
+	service    interface{}
+}
+
+// implements - check if user-defined handlers implement the descriptor handler type
+func (s *serviceItem) implements(service interface{}) bool {
+	if s.descriptor.HandlerType == nil {
+		return true
+	}
+	ht := reflect.TypeOf(s.descriptor.HandlerType).Elem()
+	return reflect.TypeOf(service).Implements(ht)
+}
+
+// bind - bind user-defined handlers to the first matched descriptor without implementation,
+// error is returned when every matched descriptor is already bound to another implementation
+func (g *grpcServer) bind(service interface{}) error {
+	matched := false
+
+	for _, item := range g.services {
+		if !item.implements(service) {
+			continue
+		}
+		if item.service == nil {
+			item.service = service
+			return nil
+		}
+		matched = true
+	}
+
+	if matched {
+		return fmt.Errorf("grpc service descriptors implemented by %T are already bound to another implementation", service)
+	}
+
+	return fmt.Errorf("can not find grpc service descriptor for %T", service)
+}
+
+// registerServices - register all descriptor/implementation pairs on the grpc server
+func (g *grpcServer) registerServices() error {
+	g.Lock()
+	defer g.Unlock()
+
+	pending := g.pending
+	g.pending = make([]interface{}, 0)
+
+	for _, service := range pending {
+		if err := g.bind(service); err != nil {
+			return err
+		}
+	}
+
+	for _, item := range g.services {
+		if item.service == nil {
+			g.runtime.Log().Warnf("server [grpc] service %s has no implementation: skip registration", item.descriptor.ServiceName)
+			continue
+		}
+		g.grpc.RegisterService(item.descriptor, item.service)
+	}
+
+	return nil
+}
@@ -0,0 +1,139 @@
+package grpc
+
+import (
+	"sort"
+	"testing"
+
+	"google.golang.org/grpc"
+)
+
+type fooServer interface {
+	Foo()
+}
+
+type barServer interface {
+	Bar()
+}
+
+type fooService struct{}
+
+func (fooService) Foo() {}
+
+type barService struct{}
+
+func (barService) Bar() {}
+
+type fooBarService struct{}
+
+func (fooBarService) Foo() {}
+func (fooBarService) Bar() {}
+
+var (
+	fooDesc = grpc.ServiceDesc{ServiceName: "test.Foo", HandlerType: (*fooServer)(nil)}
+	barDesc = grpc.ServiceDesc{ServiceName: "test.Bar", HandlerType: (*barServer)(nil)}
+)
+
+func TestGrpcServer_RegisterServices(t *testing.T) {
+	tests := []struct {
+		name        string
+		descriptors []grpc.ServiceDesc
+		services    []interface{}
+		registered  []string
+		err         bool
+	}{
+		{
+			"single service",
+			[]grpc.ServiceDesc{fooDesc},
+			[]interface{}{fooService{}},
+			[]string{"test.Foo"},
+			false,
+		},
+		{
+			"multiple services registered before descriptors",
+			[]grpc.ServiceDesc{fooDesc, barDesc},
+			[]interface{}{barService{}, fooService{}},
+			[]string{"test.Bar", "test.Foo"},
+			false,
+		},
+		{
+			"implementations bound to free matched descriptors",
+			[]grpc.ServiceDesc{fooDesc, barDesc},
+			[]interface{}{fooBarService{}, fooBarService{}},
+			[]string{"test.Bar", "test.Foo"},
+			false,
+		},
+		{
+			"descriptor without implementation is skipped",
+			[]grpc.ServiceDesc{fooDesc, barDesc},
+			[]interface{}{fooService{}},
+			[]string{"test.Foo"},
+			false,
+		},
+		{
+			"duplicated descriptor is replaced",
+			[]grpc.ServiceDesc{fooDesc, fooDesc},
+			[]interface{}{fooService{}},
+			[]string{"test.Foo"},
+			false,
+		},
+		{
+			"double binding of descriptor",
+			[]grpc.ServiceDesc{fooDesc},
+			[]interface{}{fooService{}, fooService{}},
+			nil,
+			true,
+		},
+		{
+			"double binding of every matched descriptor",
+			[]grpc.ServiceDesc{fooDesc, barDesc},
+			[]interface{}{fooBarService{}, fooBarService{}, barService{}},
+			nil,
+			true,
+		},
+		{
+			"implementation without descriptor",
+			[]grpc.ServiceDesc{fooDesc},
+			[]interface{}{barService{}},
+			nil,
+			true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := &grpcServer{runtime: testRuntime{}, pending: make([]interface{}, 0)}
+
+			// services are registered before descriptors to check pending binding
+			for _, s := range tt.services {
+				g.RegisterService(s)
+			}
+			for _, d := range tt.descriptors {
+				g.SetDescriptor(d)
+			}
+
+			g.grpc = grpc.NewServer()
+			err := g.registerServices()
+			if (err != nil) != tt.err {
+				t.Fatal("error: expected", tt.err, "received", err)
+			}
+			if tt.err {
+				return
+			}
+
+			registered := make([]string, 0)
+			for name := range g.grpc.GetServiceInfo() {
+				registered = append(registered, name)
+			}
+			sort.Strings(registered)
+
+			if len(registered) != len(tt.registered) {
+				t.Fatal("registered: expected", tt.registered, "received", registered)
+			}
+			for i := range registered {
+				if registered[i] != tt.registered[i] {
+					t.Error("registered: expected", tt.registered, "received", registered)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,91 @@
+/*
+Copyright [2014] - [2023] The Last.Backend authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package grpc
+
+import (
+	"context"
+
+	"github.com/lastbackend/toolkit/pkg/tools/traces"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	grpc_md "google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+type serverTraces struct {
+	tracer traces.Traces
+}
+
+func newServerTraces(tracer traces.Traces) *serverTraces {
+	return &serverTraces{tracer: tracer}
+}
+
+// start - extract remote span context from incoming metadata and start server span
+func (t *serverTraces) start(ctx context.Context, fullMethod string) (context.Context, traces.Span) {
+	carrier := make(map[string]string, 0)
+	if md, ok := grpc_md.FromIncomingContext(ctx); ok {
+		for k, v := range md {
+			if len(v) > 0 {
+				carrier[k] = v[0]
+			}
+		}
+	}
+
+	ctx = t.tracer.Extract(ctx, carrier)
+	ctx, span := t.tracer.StartSpan(ctx, fullMethod, traces.SpanKindServer)
+
+	service, method := splitMethodName(fullMethod)
+	span.SetAttribute("rpc.system", "grpc")
+	span.SetAttribute("rpc.service", service)
+	span.SetAttribute("rpc.method", method)
+
+	return ctx, span
+}
+
+func (t *serverTraces) finish(span traces.Span, err error) {
+	code := status.Code(err)
+	span.SetAttribute("rpc.grpc.status_code", int(code))
+	if code != codes.OK {
+		span.RecordError(err)
+		span.SetStatus(traces.StatusError, code.String())
+	}
+	span.End()
+}
+
+func (t *serverTraces) unaryInterceptor(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	ctx, span := t.start(ctx, info.FullMethod)
+	resp, err := handler(ctx, req)
+	t.finish(span, err)
+	return resp, err
+}
+
+func (t *serverTraces) streamInterceptor(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	ctx, span := t.start(ss.Context(), info.FullMethod)
+	err := handler(srv, &serverStream{ServerStream: ss, ctx: ctx})
+	t.finish(span, err)
+	return err
+}
+
+// serverStream - wrap grpc.ServerStream to override stream context
+type serverStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *serverStream) Context() context.Context {
+	return s.ctx
+}
@@ -0,0 +1,119 @@
+package grpc
+
+import (
+	"context"
+	"testing"
+
+	"github.com/lastbackend/toolkit/pkg/tools/traces"
+	"github.com/lastbackend/toolkit/pkg/tools/traces/exporter"
+	"github.com/lastbackend/toolkit/pkg/tools/traces/tracer"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	grpc_md "google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+func newTestTracer(t *testing.T) (traces.Traces, *exporter.Memory) {
+	t.Helper()
+
+	tr, err := tracer.NewTracer(testRuntime{environment: map[string]string{
+		"TRACES_ENABLED":  "true",
+		"TRACES_EXPORTER": "none",
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	e := exporter.NewMemoryExporter()
+	tr.RegisterExporter(e)
+	return tr, e
+}
+
+func TestServerTraces_UnaryInterceptor(t *testing.T) {
+	const (
+		traceID = "0af7651916cd43dd8448eb211c80319c"
+		spanID  = "b7ad6b7169203331"
+	)
+
+	tests := []struct {
+		name   string
+		md     grpc_md.MD
+		err    error
+		trace  string
+		parent string
+		status string
+	}{
+		{
+			"root span",
+			grpc_md.MD{},
+			nil,
+			"",
+			"",
+			traces.StatusUnset.String(),
+		},
+		{
+			"child of remote caller",
+			grpc_md.Pairs(traces.TraceParentHeader, "00-"+traceID+"-"+spanID+"-01"),
+			nil,
+			traceID,
+			spanID,
+			traces.StatusUnset.String(),
+		},
+		{
+			"failed call",
+			grpc_md.Pairs(traces.TraceParentHeader, "00-"+traceID+"-"+spanID+"-01"),
+			status.Error(codes.Unavailable, "unavailable"),
+			traceID,
+			spanID,
+			traces.StatusError.String(),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tr, e := newTestTracer(t)
+			st := newServerTraces(tr)
+
+			var handled traces.SpanContext
+			handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+				handled, _ = traces.SpanContextFromContext(ctx)
+				return nil, tt.err
+			}
+
+			ctx := grpc_md.NewIncomingContext(context.Background(), tt.md)
+			info := &grpc.UnaryServerInfo{FullMethod: "/helloworld.Greeter/SayHello"}
+			if _, err := st.unaryInterceptor(ctx, nil, info, handler); err != tt.err {
+				t.Error("error: expected", tt.err, "received", err)
+			}
+
+			if err := tr.Shutdown(context.Background()); err != nil {
+				t.Fatal(err)
+			}
+
+			spans := e.Spans()
+			if len(spans) != 1 {
+				t.Fatal("spans: expected", 1, "received", len(spans))
+			}
+
+			span := spans[0]
+			if span.SpanID != handled.SpanID.String() {
+				t.Error("handler span: expected", span.SpanID, "received", handled.SpanID)
+			}
+			if tt.trace != "" && span.TraceID != tt.trace {
+				t.Error("trace id: expected", tt.trace, "received", span.TraceID)
+			}
+			if span.ParentSpanID != tt.parent {
+				t.Error("parent span id: expected", tt.parent, "received", span.ParentSpanID)
+			}
+			if span.Kind != traces.SpanKindServer.String() {
+				t.Error("kind: expected", traces.SpanKindServer, "received", span.Kind)
+			}
+			if span.Status != tt.status {
+				t.Error("status: expected", tt.status, "received", span.Status)
+			}
+			if span.Attributes["rpc.method"] != "SayHello" {
+				t.Error("rpc.method: expected", "SayHello", "received", span.Attributes["rpc.method"])
+			}
+		})
+	}
+}
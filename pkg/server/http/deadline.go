@@ -0,0 +1,52 @@
+/*
+Copyright [2014] - [2023] The Last.Backend authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package http
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/lastbackend/toolkit/pkg/context/deadline"
+	"github.com/lastbackend/toolkit/pkg/server/http/errors"
+)
+
+// deadlineWrap - set request context deadline from grpc-timeout header,
+// requests with exhausted deadline are rejected with 504 response
+func deadlineWrap(h http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		value := r.Header.Get(deadline.Header)
+		if value == "" {
+			h(w, r)
+			return
+		}
+
+		timeout, err := deadline.Decode(value)
+		if err != nil {
+			errors.HTTP.BadRequest(w, err.Error())
+			return
+		}
+		if timeout <= 0 {
+			errors.HTTP.GatewayTimeout(w, deadline.ErrExhausted.Error())
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(r.Context(), timeout)
+		defer cancel()
+
+		h(w, r.WithContext(ctx))
+	}
+}
@@ -46,6 +46,10 @@ func (Http) BadGateway(w http.ResponseWriter) {
 	HTTP.getBadGateway().send(w)
 }
 
+func (Http) GatewayTimeout(w http.ResponseWriter, msg ...string) {
+	HTTP.getGatewayTimeout(msg...).send(w)
+}
+
 func (Http) PaymentRequired(w http.ResponseWriter, msg ...string) {
 	HTTP.getPaymentRequired(msg...).send(w)
 }
@@ -105,6 +109,10 @@ func (Http) getBadGateway() *Http {
 	return getHttpError(http.StatusBadGateway)
 }
 
+func (Http) getGatewayTimeout(msg ...string) *Http {
+	return getHttpError(http.StatusGatewayTimeout, msg...)
+}
+
 func (Http) getNotImplemented(msg ...string) *Http {
 	return getHttpError(http.StatusNotImplemented, msg...)
 }
@@ -0,0 +1,124 @@
+/*
+Copyright [2014] - [2023] The Last.Backend authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package http
+
+import (
+	"bufio"
+	"fmt"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/lastbackend/toolkit/pkg/server"
+	"github.com/lastbackend/toolkit/pkg/tools/metrics"
+)
+
+const (
+	metricRequestsTotal   = "http_server_requests_total"
+	metricErrorsTotal     = "http_server_errors_total"
+	metricDurationSeconds = "http_server_request_duration_seconds"
+)
+
+type serverMetrics struct {
+	name     string
+	requests metrics.Counter
+	errors   metrics.Counter
+	duration metrics.Histogram
+}
+
+func newServerMetrics(name string, m metrics.Metrics) (*serverMetrics, error) {
+	var (
+		sm  = &serverMetrics{name: name}
+		err error
+	)
+
+	if sm.requests, err = m.RegisterCounter(metricRequestsTotal,
+		"Total number of HTTP requests handled by the server.",
+		"server", "method", "route", "status"); err != nil {
+		return nil, err
+	}
+
+	if sm.errors, err = m.RegisterCounter(metricErrorsTotal,
+		"Total number of HTTP requests handled by the server with 4xx or 5xx status code.",
+		"server", "method", "route", "status"); err != nil {
+		return nil, err
+	}
+
+	if sm.duration, err = m.RegisterHistogram(metricDurationSeconds,
+		"Histogram of response latency (seconds) of HTTP requests handled by the server.",
+		metrics.DefBuckets, "server", "method", "route"); err != nil {
+		return nil, err
+	}
+
+	return sm, nil
+}
+
+// wrap - record request metrics labeled by route template to keep labels cardinality bounded
+func (m *serverMetrics) wrap(handler server.HTTPServerHandler, h http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		started := time.Now()
+		rw := &statusResponseWriter{ResponseWriter: w, status: http.StatusOK}
+
+		h(rw, r)
+
+		code := strconv.Itoa(rw.status)
+		m.requests.Inc(m.name, handler.Method, handler.Path, code)
+		if rw.status >= http.StatusBadRequest {
+			m.errors.Inc(m.name, handler.Method, handler.Path, code)
+		}
+		m.duration.Observe(time.Since(started).Seconds(), m.name, handler.Method, handler.Path)
+	}
+}
+
+type statusResponseWriter struct {
+	http.ResponseWriter
+	status      int
+	wroteHeader bool
+}
+
+func (w *statusResponseWriter) WriteHeader(code int) {
+	if !w.wroteHeader {
+		w.status = code
+		w.wroteHeader = true
+	}
+	w.ResponseWriter.WriteHeader(code)
+}
+
+func (w *statusResponseWriter) Write(b []byte) (int, error) {
+	if !w.wroteHeader {
+		w.wroteHeader = true
+	}
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *statusResponseWriter) Flush() {
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+func (w *statusResponseWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	if h, ok := w.ResponseWriter.(http.Hijacker); ok {
+		return h.Hijack()
+	}
+	return nil, nil, fmt.Errorf("http.Hijacker is not supported by response writer")
+}
+
+func (w *statusResponseWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
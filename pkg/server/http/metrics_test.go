@@ -0,0 +1,132 @@
+package http
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/lastbackend/toolkit/pkg/server"
+	"github.com/lastbackend/toolkit/pkg/tools/metrics"
+)
+
+// fakeMetrics - records counter increments by joined label values
+type fakeMetrics struct {
+	mtx    sync.Mutex
+	values map[string]float64
+}
+
+func newFakeMetrics() *fakeMetrics {
+	return &fakeMetrics{values: make(map[string]float64)}
+}
+
+func (f *fakeMetrics) add(name string, v float64, labels ...string) {
+	f.mtx.Lock()
+	defer f.mtx.Unlock()
+	f.values[name+"{"+strings.Join(labels, ",")+"}"] += v
+}
+
+func (f *fakeMetrics) get(name string, labels ...string) float64 {
+	f.mtx.Lock()
+	defer f.mtx.Unlock()
+	return f.values[name+"{"+strings.Join(labels, ",")+"}"]
+}
+
+func (f *fakeMetrics) Start(_ context.Context) error { return nil }
+func (f *fakeMetrics) Handler() http.Handler         { return http.NotFoundHandler() }
+func (f *fakeMetrics) OnCollect(_ func())            {}
+
+func (f *fakeMetrics) RegisterCounter(name, _ string, _ ...string) (metrics.Counter, error) {
+	return &fakeCollector{name: name, m: f}, nil
+}
+
+func (f *fakeMetrics) RegisterGauge(name, _ string, _ ...string) (metrics.Gauge, error) {
+	return &fakeCollector{name: name, m: f}, nil
+}
+
+func (f *fakeMetrics) RegisterHistogram(name, _ string, _ []float64, _ ...string) (metrics.Histogram, error) {
+	return &fakeCollector{name: name, m: f}, nil
+}
+
+type fakeCollector struct {
+	name string
+	m    *fakeMetrics
+}
+
+func (c *fakeCollector) Inc(labels ...string)            { c.m.add(c.name, 1, labels...) }
+func (c *fakeCollector) Dec(labels ...string)            { c.m.add(c.name, -1, labels...) }
+func (c *fakeCollector) Add(v float64, labels ...string) { c.m.add(c.name, v, labels...) }
+func (c *fakeCollector) Set(v float64, labels ...string) { c.m.add(c.name, v, labels...) }
+func (c *fakeCollector) Observe(_ float64, labels ...string) {
+	c.m.add(c.name, 1, labels...)
+}
+
+func TestServerMetrics_Wrap(t *testing.T) {
+	tests := []struct {
+		name    string
+		handler http.HandlerFunc
+		status  string
+		errors  float64
+	}{
+		{
+			"implicit ok",
+			func(w http.ResponseWriter, r *http.Request) {
+				_, _ = w.Write([]byte("ok"))
+			},
+			"200",
+			0,
+		},
+		{
+			"explicit status",
+			func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusCreated)
+			},
+			"201",
+			0,
+		},
+		{
+			"client error",
+			func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusNotFound)
+				w.WriteHeader(http.StatusInternalServerError)
+			},
+			"404",
+			1,
+		},
+		{
+			"server error",
+			func(w http.ResponseWriter, r *http.Request) {
+				http.Error(w, "failed", http.StatusBadGateway)
+			},
+			"502",
+			1,
+		},
+	}
+
+	route := server.HTTPServerHandler{Method: http.MethodGet, Path: "/users/{id}"}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fm := newFakeMetrics()
+			sm, err := newServerMetrics("test", fm)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			h := sm.wrap(route, tt.handler)
+			h(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/users/42", nil))
+
+			if v := fm.get(metricRequestsTotal, "test", route.Method, route.Path, tt.status); v != 1 {
+				t.Error("requests: expected", 1, "received", v)
+			}
+			if v := fm.get(metricErrorsTotal, "test", route.Method, route.Path, tt.status); v != tt.errors {
+				t.Error("errors: expected", tt.errors, "received", v)
+			}
+			if v := fm.get(metricDurationSeconds, "test", route.Method, route.Path); v != 1 {
+				t.Error("duration: expected", 1, "received", v)
+			}
+		})
+	}
+}
@@ -30,6 +30,7 @@ import (
 	"github.com/lastbackend/toolkit/pkg/server/http/errors"
 	"github.com/lastbackend/toolkit/pkg/server/http/marshaler"
 	"github.com/lastbackend/toolkit/pkg/server/http/websockets"
+	tk_tls "github.com/lastbackend/toolkit/pkg/util/tls"
 )
 
 const (
@@ -59,6 +60,9 @@ type httpServer struct {
 	service interface{}
 
 	middlewares *Middlewares
+	metrics     *serverMetrics
+	traces      *serverTraces
+	recovery    *serverRecovery
 
 	corsHandlerFunc http.HandlerFunc
 
@@ -145,6 +149,43 @@ func (s *httpServer) Start(_ context.Context) error {
 		s.middlewares.Add(&corsMiddleware{handler: s.corsHandlerFunc})
 	}
 
+	if s.opts.EnableMetrics && s.runtime.Tools().Metrics() != nil {
+		m, err := newServerMetrics(s.prefix, s.runtime.Tools().Metrics())
+		if err != nil {
+			return err
+		}
+		s.metrics = m
+	}
+
+	if t := s.runtime.Tools().Traces(); t != nil && t.Enabled() {
+		s.traces = newServerTraces(t)
+	}
+
+	if s.opts.EnableRecovery {
+		r, err := newServerRecovery(s.prefix, s.runtime.Log(), s.runtime.Tools().Metrics())
+		if err != nil {
+			return err
+		}
+		s.recovery = r
+	}
+
+	if s.opts.TLSConfig == nil && s.opts.TLSCertFile != "" {
+		r, err := tk_tls.NewReloader(tk_tls.ReloaderOptions{
+			CertFile:   s.opts.TLSCertFile,
+			KeyFile:    s.opts.TLSKeyFile,
+			CAFile:     s.opts.TLSCAFile,
+			ClientAuth: s.opts.TLSClientAuth,
+			Interval:   s.opts.TLSReloadInterval,
+			OnError: func(err error) {
+				s.runtime.Log().Errorf("server [http] tls reload error: %v", err)
+			},
+		})
+		if err != nil {
+			return err
+		}
+		s.opts.TLSConfig = r.Config()
+	}
+
 	s.r.NotFoundHandler = s.methodNotFoundHandler()
 	s.r.MethodNotAllowedHandler = s.methodNotAllowedHandler()
 
@@ -166,7 +207,14 @@ func (s *httpServer) Start(_ context.Context) error {
 
 	go func() {
 		s.runtime.Log().V(5).Infof("server [http] [%s] started", s.server.Addr)
-		if err := s.server.ListenAndServe(); err != http.ErrServerClosed {
+		var err error
+		if s.server.TLSConfig != nil {
+			// certificates are provided by tls config
+			err = s.server.ListenAndServeTLS("", "")
+		} else {
+			err = s.server.ListenAndServe()
+		}
+		if err != http.ErrServerClosed {
 			s.runtime.Log().Errorf("server [http] [%s] start error: %v", s.server.Addr, err)
 		}
 		s.runtime.Log().V(5).Infof("server [http] [%s] stopped", s.server.Addr)
@@ -185,6 +233,20 @@ func (s *httpServer) registerHandler(h server.HTTPServerHandler) error {
 	if err != nil {
 		return err
 	}
+
+	if s.recovery != nil {
+		handler = s.recovery.wrap(h, handler)
+	}
+
+	handler = deadlineWrap(handler)
+
+	if s.metrics != nil {
+		handler = s.metrics.wrap(h, handler)
+	}
+
+	if s.traces != nil {
+		handler = s.traces.wrap(h, handler)
+	}
 	s.r.Handle(h.Path, handler).Methods(h.Method)
 
 	s.runtime.Log().V(5).Infof("bind handler: method: %s, path: %s", h.Method, h.Path)
@@ -20,6 +20,7 @@ import (
 	"crypto/tls"
 	"github.com/lastbackend/toolkit/pkg/server"
 	"net/http"
+	"time"
 )
 
 const (
@@ -74,8 +75,16 @@ type Config struct {
 
 	Prefix string
 
-	EnableCORS bool `env:"SERVER_CORS_ENABLED" envDefault:"false" comment:"Enable Cross-Origin Resource Sharing header"`
-	IsDisable  bool
+	EnableCORS     bool `env:"SERVER_CORS_ENABLED" envDefault:"false" comment:"Enable Cross-Origin Resource Sharing header"`
+	EnableMetrics  bool `env:"SERVER_METRICS_ENABLED" envDefault:"false" comment:"Enable requests, errors and latency metrics for every HTTP route"`
+	EnableRecovery bool `env:"SERVER_RECOVERY_ENABLED" envDefault:"true" comment:"Recover panics in HTTP handlers and respond with 500 status code"`
+	IsDisable      bool
+
+	TLSCertFile       string        `env:"SERVER_TLS_CERT_FILE" comment:"Set path to HTTP server TLS certificate file, enables HTTPS"`
+	TLSKeyFile        string        `env:"SERVER_TLS_KEY_FILE" comment:"Set path to HTTP server TLS private key file"`
+	TLSCAFile         string        `env:"SERVER_TLS_CA_FILE" comment:"Set path to CA bundle used to verify client certificates, requires SERVER_TLS_CLIENT_AUTH"`
+	TLSClientAuth     bool          `env:"SERVER_TLS_CLIENT_AUTH" envDefault:"false" comment:"Require and verify client certificates (mTLS)"`
+	TLSReloadInterval time.Duration `env:"SERVER_TLS_RELOAD_INTERVAL" envDefault:"1m" comment:"Set interval of TLS files modification checks, modified files are reloaded"`
 
 	TLSConfig *tls.Config
 }
@@ -0,0 +1,79 @@
+/*
+Copyright [2014] - [2023] The Last.Backend authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package http
+
+import (
+	"net/http"
+	"runtime/debug"
+
+	"github.com/lastbackend/toolkit/pkg/runtime/logger"
+	"github.com/lastbackend/toolkit/pkg/server"
+	"github.com/lastbackend/toolkit/pkg/server/http/errors"
+	"github.com/lastbackend/toolkit/pkg/tools/metrics"
+)
+
+const (
+	metricPanicsTotal = "http_server_panics_total"
+)
+
+// serverRecovery - convert panics in handlers to 500 responses
+type serverRecovery struct {
+	name   string
+	log    logger.Logger
+	panics metrics.Counter
+}
+
+func newServerRecovery(name string, log logger.Logger, m metrics.Metrics) (*serverRecovery, error) {
+	sr := &serverRecovery{name: name, log: log}
+
+	if m != nil {
+		var err error
+		if sr.panics, err = m.RegisterCounter(metricPanicsTotal,
+			"Total number of panics recovered in HTTP requests handled by the server.",
+			"server", "method", "route"); err != nil {
+			return nil, err
+		}
+	}
+
+	return sr, nil
+}
+
+// wrap - recover panics of handler and middlewares
+func (rc *serverRecovery) wrap(handler server.HTTPServerHandler, h http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		defer func() {
+			p := recover()
+			if p == nil {
+				return
+			}
+			// keep net/http behaviour for aborted handlers
+			if p == http.ErrAbortHandler {
+				panic(p)
+			}
+
+			rc.log.Errorf("server [http] panic recovered in %s %s: %v\n%s", handler.Method, handler.Path, p, debug.Stack())
+
+			if rc.panics != nil {
+				rc.panics.Inc(rc.name, handler.Method, handler.Path)
+			}
+
+			errors.HTTP.InternalServerError(w)
+		}()
+
+		h(w, r)
+	}
+}
@@ -0,0 +1,87 @@
+package http
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/lastbackend/toolkit/pkg/runtime/logger/empty"
+	"github.com/lastbackend/toolkit/pkg/server"
+)
+
+func TestServerRecovery_Wrap(t *testing.T) {
+	tests := []struct {
+		name    string
+		handler http.HandlerFunc
+		status  int
+		panics  float64
+		abort   bool
+	}{
+		{
+			"no panic",
+			func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusAccepted)
+			},
+			http.StatusAccepted,
+			0,
+			false,
+		},
+		{
+			"panic with value",
+			func(w http.ResponseWriter, r *http.Request) {
+				panic("boom")
+			},
+			http.StatusInternalServerError,
+			1,
+			false,
+		},
+		{
+			"panic with error",
+			func(w http.ResponseWriter, r *http.Request) {
+				var m map[string]int
+				m["key"]++
+			},
+			http.StatusInternalServerError,
+			1,
+			false,
+		},
+		{
+			"aborted handler",
+			func(w http.ResponseWriter, r *http.Request) {
+				panic(http.ErrAbortHandler)
+			},
+			0,
+			0,
+			true,
+		},
+	}
+
+	route := server.HTTPServerHandler{Method: http.MethodGet, Path: "/panic"}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fm := newFakeMetrics()
+			rc, err := newServerRecovery("test", empty.NewLogger(), fm)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			rec := httptest.NewRecorder()
+			func() {
+				defer func() {
+					if p := recover(); (p != nil) != tt.abort {
+						t.Error("abort panic: expected", tt.abort, "received", p)
+					}
+				}()
+				rc.wrap(route, tt.handler)(rec, httptest.NewRequest(http.MethodGet, "/panic", nil))
+			}()
+
+			if !tt.abort && rec.Code != tt.status {
+				t.Error("status: expected", tt.status, "received", rec.Code)
+			}
+			if v := fm.get(metricPanicsTotal, "test", route.Method, route.Path); v != tt.panics {
+				t.Error("panics: expected", tt.panics, "received", v)
+			}
+		})
+	}
+}
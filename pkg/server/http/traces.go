@@ -0,0 +1,63 @@
+/*
+Copyright [2014] - [2023] The Last.Backend authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package http
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/lastbackend/toolkit/pkg/server"
+	"github.com/lastbackend/toolkit/pkg/tools/traces"
+)
+
+type serverTraces struct {
+	tracer traces.Traces
+}
+
+func newServerTraces(tracer traces.Traces) *serverTraces {
+	return &serverTraces{tracer: tracer}
+}
+
+// wrap - start server span for every request named by route template
+func (t *serverTraces) wrap(handler server.HTTPServerHandler, h http.HandlerFunc) http.HandlerFunc {
+	name := fmt.Sprintf("%s %s", handler.Method, handler.Path)
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		carrier := make(map[string]string, 0)
+		for k := range r.Header {
+			carrier[strings.ToLower(k)] = r.Header.Get(k)
+		}
+
+		ctx := t.tracer.Extract(r.Context(), carrier)
+		ctx, span := t.tracer.StartSpan(ctx, name, traces.SpanKindServer)
+		defer span.End()
+
+		span.SetAttribute("http.method", handler.Method)
+		span.SetAttribute("http.route", handler.Path)
+		span.SetAttribute("http.target", r.URL.Path)
+
+		rw := &statusResponseWriter{ResponseWriter: w, status: http.StatusOK}
+
+		h(rw, r.WithContext(ctx))
+
+		span.SetAttribute("http.status_code", rw.status)
+		if rw.status >= http.StatusInternalServerError {
+			span.SetStatus(traces.StatusError, http.StatusText(rw.status))
+		}
+	}
+}
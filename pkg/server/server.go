@@ -23,6 +23,7 @@ import (
 	"github.com/lastbackend/toolkit/pkg/server/http/websockets"
 	"google.golang.org/grpc"
 	"net/http"
+	"time"
 )
 
 type HTTPServer interface {
@@ -63,6 +64,11 @@ type ServerInfo struct {
 	Port int
 
 	TLSConfig *tls.Config
+
+	// RegisterInterval - interval of server registration refresh in resolver
+	RegisterInterval time.Duration
+	// RegisterTTL - time after which not refreshed server registration is expired
+	RegisterTTL time.Duration
 }
 
 type HTTPServerHandler struct {
@@ -103,10 +109,14 @@ type GRPCServer interface {
 	SetConstructor(fn interface{})
 
 	GetInterceptors() []interface{}
+	GetStreamInterceptors() []interface{}
 	SetInterceptor(interceptor any)
 
-	GetService() interface{}
-	GetConstructor() interface{}
+	UseInterceptor(...KindInterceptor)
+	SetMethodOptions(method string, opts ...GRPCMethodOption)
+
+	GetServices() []interface{}
+	GetConstructors() []interface{}
 	GetInterceptorsConstructor() interface{}
 
 	RegisterService(service interface{})
@@ -122,12 +132,24 @@ type GRPCServerOptions struct {
 
 type ServerKind string
 
+type GrpcOptionKind string
+
+type GRPCMethodOption interface {
+	Kind() GrpcOptionKind
+}
+
 type GRPCInterceptor interface {
 	Kind() KindInterceptor
 	Order() int
 	Interceptor(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (resp interface{}, err error)
 }
 
+type GRPCStreamInterceptor interface {
+	Kind() KindInterceptor
+	Order() int
+	StreamInterceptor(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error
+}
+
 const (
 	ServerKindHTTPServer = "http"
 	ServerKindGRPCServer = "grpc"
@@ -1,4 +1,42 @@
 package metrics
 
+import (
+	"context"
+	"net/http"
+)
+
+// DefBuckets are the default histogram buckets, tailored to measure
+// network request latency in seconds.
+var DefBuckets = []float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10}
+
 type Metrics interface {
+	Start(ctx context.Context) error
+	Handler() http.Handler
+
+	RegisterCounter(name, help string, labels ...string) (Counter, error)
+	RegisterGauge(name, help string, labels ...string) (Gauge, error)
+	RegisterHistogram(name, help string, buckets []float64, labels ...string) (Histogram, error)
+
+	// OnCollect registers fn called before every metrics collection to update values lazily
+	OnCollect(fn func())
+}
+
+// Counter is a monotonically increasing value.
+// Label values must be passed in the same order as label names on registration.
+type Counter interface {
+	Inc(labels ...string)
+	Add(v float64, labels ...string)
+}
+
+// Gauge is a value that can arbitrarily go up and down.
+type Gauge interface {
+	Set(v float64, labels ...string)
+	Inc(labels ...string)
+	Dec(labels ...string)
+	Add(v float64, labels ...string)
+}
+
+// Histogram samples observations and counts them in configurable buckets.
+type Histogram interface {
+	Observe(v float64, labels ...string)
 }
@@ -0,0 +1,277 @@
+package server
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+const (
+	kindCounter   = "counter"
+	kindGauge     = "gauge"
+	kindHistogram = "histogram"
+)
+
+const labelSeparator = "\xff"
+
+type collector interface {
+	kind() string
+	labels() []string
+	write(w io.Writer)
+}
+
+type desc struct {
+	name       string
+	help       string
+	labelNames []string
+}
+
+func (d *desc) labels() []string {
+	return d.labelNames
+}
+
+func (d *desc) key(values []string) (string, error) {
+	if len(values) != len(d.labelNames) {
+		return "", fmt.Errorf("metric %s: expected %d label values, got %d", d.name, len(d.labelNames), len(values))
+	}
+	return strings.Join(values, labelSeparator), nil
+}
+
+func (d *desc) header(w io.Writer, kind string) {
+	if d.help != "" {
+		_, _ = fmt.Fprintf(w, "# HELP %s %s\n", d.name, escapeHelp(d.help))
+	}
+	_, _ = fmt.Fprintf(w, "# TYPE %s %s\n", d.name, kind)
+}
+
+type value struct {
+	values []string
+	val    float64
+}
+
+type vec struct {
+	desc
+	mtx    sync.RWMutex
+	series map[string]*value
+}
+
+func newVec(name, help string, labels []string) *vec {
+	return &vec{
+		desc:   desc{name: name, help: help, labelNames: labels},
+		series: make(map[string]*value, 0),
+	}
+}
+
+func (v *vec) update(values []string, fn func(float64) float64) {
+	key, err := v.key(values)
+	if err != nil {
+		return
+	}
+
+	v.mtx.Lock()
+	defer v.mtx.Unlock()
+
+	s, ok := v.series[key]
+	if !ok {
+		s = &value{values: append([]string(nil), values...)}
+		v.series[key] = s
+	}
+	s.val = fn(s.val)
+}
+
+func (v *vec) writeSeries(w io.Writer) {
+	v.mtx.RLock()
+	defer v.mtx.RUnlock()
+
+	for _, key := range sortedKeys(v.series) {
+		s := v.series[key]
+		_, _ = fmt.Fprintf(w, "%s%s %s\n", v.name, formatLabels(v.desc.labelNames, s.values), formatValue(s.val))
+	}
+}
+
+type counter struct {
+	*vec
+}
+
+func newCounter(name, help string, labels []string) *counter {
+	return &counter{vec: newVec(name, help, labels)}
+}
+
+func (c *counter) kind() string {
+	return kindCounter
+}
+
+func (c *counter) Inc(labels ...string) {
+	c.Add(1, labels...)
+}
+
+func (c *counter) Add(v float64, labels ...string) {
+	if v < 0 {
+		return
+	}
+	c.update(labels, func(val float64) float64 { return val + v })
+}
+
+func (c *counter) write(w io.Writer) {
+	c.header(w, kindCounter)
+	c.writeSeries(w)
+}
+
+type gauge struct {
+	*vec
+}
+
+func newGauge(name, help string, labels []string) *gauge {
+	return &gauge{vec: newVec(name, help, labels)}
+}
+
+func (g *gauge) kind() string {
+	return kindGauge
+}
+
+func (g *gauge) Set(v float64, labels ...string) {
+	g.update(labels, func(float64) float64 { return v })
+}
+
+func (g *gauge) Inc(labels ...string) {
+	g.Add(1, labels...)
+}
+
+func (g *gauge) Dec(labels ...string) {
+	g.Add(-1, labels...)
+}
+
+func (g *gauge) Add(v float64, labels ...string) {
+	g.update(labels, func(val float64) float64 { return val + v })
+}
+
+func (g *gauge) write(w io.Writer) {
+	g.header(w, kindGauge)
+	g.writeSeries(w)
+}
+
+type histogramValue struct {
+	values []string
+	counts []uint64
+	count  uint64
+	sum    float64
+}
+
+type histogram struct {
+	desc
+	mtx     sync.RWMutex
+	buckets []float64
+	series  map[string]*histogramValue
+}
+
+func newHistogram(name, help string, buckets []float64, labels []string) *histogram {
+	b := append([]float64(nil), buckets...)
+	sort.Float64s(b)
+	return &histogram{
+		desc:    desc{name: name, help: help, labelNames: labels},
+		buckets: b,
+		series:  make(map[string]*histogramValue, 0),
+	}
+}
+
+func (h *histogram) kind() string {
+	return kindHistogram
+}
+
+func (h *histogram) Observe(v float64, labels ...string) {
+	key, err := h.key(labels)
+	if err != nil {
+		return
+	}
+
+	h.mtx.Lock()
+	defer h.mtx.Unlock()
+
+	s, ok := h.series[key]
+	if !ok {
+		s = &histogramValue{
+			values: append([]string(nil), labels...),
+			counts: make([]uint64, len(h.buckets)),
+		}
+		h.series[key] = s
+	}
+
+	for i, upper := range h.buckets {
+		if v <= upper {
+			s.counts[i]++
+		}
+	}
+	s.count++
+	s.sum += v
+}
+
+func (h *histogram) write(w io.Writer) {
+	h.header(w, kindHistogram)
+
+	h.mtx.RLock()
+	defer h.mtx.RUnlock()
+
+	names := append(append([]string(nil), h.desc.labelNames...), "le")
+
+	for _, key := range sortedKeys(h.series) {
+		s := h.series[key]
+		for i, upper := range h.buckets {
+			values := append(append([]string(nil), s.values...), formatValue(upper))
+			_, _ = fmt.Fprintf(w, "%s_bucket%s %d\n", h.name, formatLabels(names, values), s.counts[i])
+		}
+		values := append(append([]string(nil), s.values...), "+Inf")
+		_, _ = fmt.Fprintf(w, "%s_bucket%s %d\n", h.name, formatLabels(names, values), s.count)
+		_, _ = fmt.Fprintf(w, "%s_sum%s %s\n", h.name, formatLabels(h.desc.labelNames, s.values), formatValue(s.sum))
+		_, _ = fmt.Fprintf(w, "%s_count%s %d\n", h.name, formatLabels(h.desc.labelNames, s.values), s.count)
+	}
+}
+
+func sortedKeys[T any](m map[string]T) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func formatLabels(names, values []string) string {
+	if len(names) == 0 {
+		return ""
+	}
+
+	pairs := make([]string, 0, len(names))
+	for i, name := range names {
+		pairs = append(pairs, fmt.Sprintf("%s=\"%s\"", name, escapeLabel(values[i])))
+	}
+	return "{" + strings.Join(pairs, ",") + "}"
+}
+
+func formatValue(v float64) string {
+	switch {
+	case math.IsInf(v, 1):
+		return "+Inf"
+	case math.IsInf(v, -1):
+		return "-Inf"
+	case math.IsNaN(v):
+		return "NaN"
+	}
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
+
+var (
+	helpReplacer  = strings.NewReplacer(`\`, `\\`, "\n", `\n`)
+	labelReplacer = strings.NewReplacer(`\`, `\\`, "\n", `\n`, `"`, `\"`)
+)
+
+func escapeHelp(s string) string {
+	return helpReplacer.Replace(s)
+}
+
+func escapeLabel(s string) string {
+	return labelReplacer.Replace(s)
+}
@@ -0,0 +1,187 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+	"regexp"
+	"sort"
+	"sync"
+
+	"github.com/lastbackend/toolkit/pkg/runtime"
+	"github.com/lastbackend/toolkit/pkg/tools"
+	"github.com/lastbackend/toolkit/pkg/tools/metrics"
+)
+
+const prefix = "metrics"
+
+const (
+	defaultMetricsHttpServerName string = "metrics"
+	defaultContentType                  = "text/plain; version=0.0.4; charset=utf-8"
+)
+
+var (
+	metricNameRegexp = regexp.MustCompile(`^[a-zA-Z_:][a-zA-Z0-9_:]*$`)
+	labelNameRegexp  = regexp.MustCompile(`^[a-zA-Z_][a-zA-Z0-9_]*$`)
+)
+
+type Options struct {
+	Enabled bool   `env:"SERVER_ENABLED" envDefault:"false" comment:"Enable or disable metrics server"`
+	Host    string `env:"SERVER_LISTEN" envDefault:"0.0.0.0" comment:"Set metrics listen host"`
+	Port    int    `env:"SERVER_PORT" envDefault:"8080" comment:"Set metrics listen port"`
+
+	Path string `env:"PATH" envDefault:"/metrics" comment:"Set metrics endpoint path"`
+}
+
+type metric struct {
+	mtx     sync.RWMutex
+	runtime runtime.Runtime
+
+	opts       Options
+	collectors map[string]collector
+	hooks      []func()
+}
+
+func NewMetricsServer(runtime runtime.Runtime) (metrics.Metrics, error) {
+	srv := new(metric)
+
+	srv.runtime = runtime
+	srv.opts = Options{}
+
+	srv.collectors = make(map[string]collector, 0)
+
+	return srv, runtime.Config().Parse(&srv.opts, prefix)
+}
+
+func (m *metric) RegisterCounter(name, help string, labels ...string) (metrics.Counter, error) {
+	c, err := m.register(name, kindCounter, labels, func() collector {
+		return newCounter(name, help, labels)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return c.(metrics.Counter), nil
+}
+
+func (m *metric) RegisterGauge(name, help string, labels ...string) (metrics.Gauge, error) {
+	c, err := m.register(name, kindGauge, labels, func() collector {
+		return newGauge(name, help, labels)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return c.(metrics.Gauge), nil
+}
+
+func (m *metric) RegisterHistogram(name, help string, buckets []float64, labels ...string) (metrics.Histogram, error) {
+	if len(buckets) == 0 {
+		buckets = metrics.DefBuckets
+	}
+	c, err := m.register(name, kindHistogram, labels, func() collector {
+		return newHistogram(name, help, buckets, labels)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return c.(metrics.Histogram), nil
+}
+
+// register - returns already registered collector with the same name, kind and labels or creates a new one
+func (m *metric) register(name, kind string, labels []string, fn func() collector) (collector, error) {
+
+	if !metricNameRegexp.MatchString(name) {
+		return nil, fmt.Errorf("invalid metric name: %s", name)
+	}
+
+	for _, l := range labels {
+		if !labelNameRegexp.MatchString(l) || l == "le" {
+			return nil, fmt.Errorf("invalid label name for metric %s: %s", name, l)
+		}
+	}
+
+	m.mtx.Lock()
+	defer m.mtx.Unlock()
+
+	if c, ok := m.collectors[name]; ok {
+		if c.kind() != kind || !equal(c.labels(), labels) {
+			return nil, fmt.Errorf("trying to override metric: %s", name)
+		}
+		return c, nil
+	}
+
+	c := fn()
+	m.collectors[name] = c
+
+	return c, nil
+}
+
+// OnCollect - register fn called before metrics are written
+func (m *metric) OnCollect(fn func()) {
+	m.mtx.Lock()
+	defer m.mtx.Unlock()
+	m.hooks = append(m.hooks, fn)
+}
+
+func (m *metric) Handler() http.Handler {
+	return http.HandlerFunc(m.metricsHandler)
+}
+
+func (m *metric) metricsHandler(w http.ResponseWriter, _ *http.Request) {
+
+	m.mtx.RLock()
+	hooks := append([]func(){}, m.hooks...)
+	m.mtx.RUnlock()
+
+	for _, fn := range hooks {
+		fn()
+	}
+
+	m.mtx.RLock()
+	names := make([]string, 0, len(m.collectors))
+	for name := range m.collectors {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	buf := new(bytes.Buffer)
+	for _, name := range names {
+		m.collectors[name].write(buf)
+	}
+	m.mtx.RUnlock()
+
+	w.Header().Set("Content-Type", defaultContentType)
+	w.WriteHeader(http.StatusOK)
+
+	if _, err := w.Write(buf.Bytes()); err != nil {
+		m.runtime.Log().Errorf("[metrics] can not write response: %v", err)
+	}
+}
+
+func (m *metric) Start(_ context.Context) error {
+
+	if !m.opts.Enabled {
+		return nil
+	}
+
+	s, err := tools.HTTPServer(m.runtime, defaultMetricsHttpServerName, m.opts.Host, m.opts.Port)
+	if err != nil {
+		return err
+	}
+
+	s.AddHandler(http.MethodGet, m.opts.Path, m.metricsHandler)
+
+	return nil
+}
+
+func equal(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,194 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func newTestMetric() *metric {
+	return &metric{collectors: make(map[string]collector, 0)}
+}
+
+func scrape(t *testing.T, m *metric) string {
+	t.Helper()
+
+	rec := httptest.NewRecorder()
+	m.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+
+	if rec.Code != http.StatusOK {
+		t.Fatal("status: expected", http.StatusOK, "received", rec.Code)
+	}
+	if ct := rec.Header().Get("Content-Type"); ct != defaultContentType {
+		t.Error("content type: expected", defaultContentType, "received", ct)
+	}
+	return rec.Body.String()
+}
+
+func TestMetric_Register(t *testing.T) {
+	tests := []struct {
+		name   string
+		first  func(m *metric) error
+		second func(m *metric) error
+		err    bool
+	}{
+		{
+			"same counter registered twice",
+			func(m *metric) error { _, err := m.RegisterCounter("requests_total", "", "code"); return err },
+			func(m *metric) error { _, err := m.RegisterCounter("requests_total", "", "code"); return err },
+			false,
+		},
+		{
+			"counter overridden by gauge",
+			func(m *metric) error { _, err := m.RegisterCounter("requests_total", ""); return err },
+			func(m *metric) error { _, err := m.RegisterGauge("requests_total", ""); return err },
+			true,
+		},
+		{
+			"counter overridden with different labels",
+			func(m *metric) error { _, err := m.RegisterCounter("requests_total", "", "code"); return err },
+			func(m *metric) error { _, err := m.RegisterCounter("requests_total", "", "method"); return err },
+			true,
+		},
+		{
+			"invalid metric name",
+			func(m *metric) error { return nil },
+			func(m *metric) error { _, err := m.RegisterCounter("requests-total", ""); return err },
+			true,
+		},
+		{
+			"reserved label name",
+			func(m *metric) error { return nil },
+			func(m *metric) error { _, err := m.RegisterHistogram("latency", "", nil, "le"); return err },
+			true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := newTestMetric()
+			if err := tt.first(m); err != nil {
+				t.Fatal("first register: unexpected error", err)
+			}
+			if err := tt.second(m); (err != nil) != tt.err {
+				t.Error("second register: expected error", tt.err, "received", err)
+			}
+		})
+	}
+}
+
+func TestMetric_Handler(t *testing.T) {
+	tests := []struct {
+		name   string
+		record func(t *testing.T, m *metric)
+		lines  []string
+	}{
+		{
+			"counter with labels",
+			func(t *testing.T, m *metric) {
+				c, err := m.RegisterCounter("requests_total", "Total requests", "code")
+				if err != nil {
+					t.Fatal(err)
+				}
+				c.Inc("OK")
+				c.Add(2, "OK")
+				c.Add(-1, "OK")
+				c.Inc("a\"b")
+			},
+			[]string{
+				"# HELP requests_total Total requests",
+				"# TYPE requests_total counter",
+				`requests_total{code="OK"} 3`,
+				`requests_total{code="a\"b"} 1`,
+			},
+		},
+		{
+			"gauge",
+			func(t *testing.T, m *metric) {
+				g, err := m.RegisterGauge("in_flight", "")
+				if err != nil {
+					t.Fatal(err)
+				}
+				g.Set(5)
+				g.Inc()
+				g.Dec()
+				g.Dec()
+			},
+			[]string{
+				"# TYPE in_flight gauge",
+				"in_flight 4",
+			},
+		},
+		{
+			"histogram",
+			func(t *testing.T, m *metric) {
+				h, err := m.RegisterHistogram("latency_seconds", "", []float64{1, 0.1}, "method")
+				if err != nil {
+					t.Fatal(err)
+				}
+				h.Observe(0.05, "get")
+				h.Observe(0.5, "get")
+				h.Observe(5, "get")
+			},
+			[]string{
+				"# TYPE latency_seconds histogram",
+				`latency_seconds_bucket{method="get",le="0.1"} 1`,
+				`latency_seconds_bucket{method="get",le="1"} 2`,
+				`latency_seconds_bucket{method="get",le="+Inf"} 3`,
+				`latency_seconds_sum{method="get"} 5.55`,
+				`latency_seconds_count{method="get"} 3`,
+			},
+		},
+		{
+			"gauge updated on collect",
+			func(t *testing.T, m *metric) {
+				g, err := m.RegisterGauge("connections", "", "state")
+				if err != nil {
+					t.Fatal(err)
+				}
+				n := 0
+				m.OnCollect(func() {
+					n++
+					g.Set(float64(n), "idle")
+				})
+			},
+			[]string{
+				"# TYPE connections gauge",
+				`connections{state="idle"} 1`,
+			},
+		},
+		{
+			"wrong label count is ignored",
+			func(t *testing.T, m *metric) {
+				c, err := m.RegisterCounter("errors_total", "", "code")
+				if err != nil {
+					t.Fatal(err)
+				}
+				c.Inc()
+				c.Inc("a", "b")
+			},
+			[]string{
+				"# TYPE errors_total counter",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := newTestMetric()
+			tt.record(t, m)
+
+			body := scrape(t, m)
+			got := strings.Split(strings.TrimSpace(body), "\n")
+			if len(got) != len(tt.lines) {
+				t.Fatal("lines: expected", tt.lines, "received", got)
+			}
+			for i := range tt.lines {
+				if got[i] != tt.lines[i] {
+					t.Error("line", i, ": expected", tt.lines[i], "received", got[i])
+				}
+			}
+		})
+	}
+}
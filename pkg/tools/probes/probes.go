@@ -13,4 +13,6 @@ const (
 type Probes interface {
 	Start(ctx context.Context) error
 	RegisterCheck(name string, kind ProbeKind, fn HandleFunc) error
+	// Check - run all registered checks of the kind and return the first failure
+	Check(kind ProbeKind) error
 }
@@ -5,7 +5,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"github.com/lastbackend/toolkit/pkg/runtime"
-	"github.com/lastbackend/toolkit/pkg/server"
+	"github.com/lastbackend/toolkit/pkg/tools"
 	"github.com/lastbackend/toolkit/pkg/tools/probes"
 	"net/http"
 	"sync"
@@ -69,6 +69,30 @@ func (p *probe) RegisterCheck(name string, kind probes.ProbeKind, fn probes.Hand
 	return nil
 }
 
+func (p *probe) Check(kind probes.ProbeKind) error {
+	var (
+		probeType string
+		checks    map[string]probes.HandleFunc
+	)
+
+	switch kind {
+	case probes.LivenessProbe:
+		probeType, checks = "liveness_probe", p.livenessProbes
+	case probes.ReadinessProbe:
+		probeType, checks = "readiness_probe", p.readinessProbes
+	default:
+		return nil
+	}
+
+	for name, err := range p.run(probeType, checks) {
+		if err != nil {
+			return fmt.Errorf("%s: %v", name, err)
+		}
+	}
+
+	return nil
+}
+
 func (p *probe) livenessProbeHandler(w http.ResponseWriter, r *http.Request) {
 	p.probeHandler("liveness_probe", w, r, p.livenessProbes)
 }
@@ -77,6 +101,27 @@ func (p *probe) readinessProbeHandler(w http.ResponseWriter, r *http.Request) {
 	p.probeHandler("readiness_probe", w, r, p.readinessProbes)
 }
 
+// run - call all checks and collect their results
+func (p *probe) run(probeType string, probes map[string]probes.HandleFunc) map[string]error {
+
+	p.mtx.RLock()
+	defer p.mtx.RUnlock()
+
+	var result = make(map[string]error, len(probes))
+
+	for name, probeFunc := range probes {
+		fn := probeFunc
+
+		err := fn()
+		if err != nil {
+			p.runtime.Log().Errorf("[%s][%s] Probe failed: %v", probeType, name, err)
+		}
+		result[name] = err
+	}
+
+	return result
+}
+
 func (p *probe) probeHandler(probeType string, w http.ResponseWriter, _ *http.Request, probes map[string]probes.HandleFunc) {
 
 	var (
@@ -84,22 +129,17 @@ func (p *probe) probeHandler(probeType string, w http.ResponseWriter, _ *http.Re
 		status = http.StatusOK
 	)
 
-	for name, probeFunc := range probes {
-		fn := probeFunc
-
-		if err := fn(); err != nil {
+	for name, err := range p.run(probeType, probes) {
+		if err != nil {
 			status = http.StatusInternalServerError
 			result[name] = err.Error()
-
-			p.runtime.Log().Errorf("[%s][%s] Probe failed: %v", probeType, name, err)
-
 		} else {
 			result[name] = "OK"
 		}
 	}
 
-	w.WriteHeader(status)
 	w.Header().Set("Content-Type", defaultContentType)
+	w.WriteHeader(status)
 
 	encoder := json.NewEncoder(w)
 	encoder.SetIndent("", "    ")
@@ -111,39 +151,13 @@ func (p *probe) probeHandler(probeType string, w http.ResponseWriter, _ *http.Re
 
 func (p *probe) Start(_ context.Context) error {
 
-	var (
-		s server.HTTPServer
-	)
-
 	if !p.opts.Enabled {
 		return nil
 	}
 
-	if p.opts.Port > 0 {
-		// check if provided port is used by grpc server
-		grpcServers := p.runtime.Server().GRPCList()
-		for _, srv := range grpcServers {
-			if srv.Info().Port == p.opts.Port {
-				return fmt.Errorf("can not bind probes handlers to grpc server. please change porbes port to different port")
-			}
-		}
-
-		// check if provided port is used on provided http server
-		httpServers := p.runtime.Server().HTTPList()
-		for _, srv := range httpServers {
-			if srv.Info().Port == p.opts.Port {
-				s = srv
-				break
-			}
-		}
-	}
-
-	if s == nil {
-		s = p.runtime.Server().HTTPNew(defaultProbesHttpServerName, &server.HTTPServerOptions{
-			Host:      p.opts.Host,
-			Port:      p.opts.Port,
-			TLSConfig: nil,
-		})
+	s, err := tools.HTTPServer(p.runtime, defaultProbesHttpServerName, p.opts.Host, p.opts.Port)
+	if err != nil {
+		return err
 	}
 
 	s.AddHandler(http.MethodGet, p.opts.LivenessPath, p.livenessProbeHandler)
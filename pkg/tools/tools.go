@@ -0,0 +1,53 @@
+/*
+Copyright [2014] - [2023] The Last.Backend authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package tools
+
+import (
+	"fmt"
+
+	"github.com/lastbackend/toolkit/pkg/runtime"
+	"github.com/lastbackend/toolkit/pkg/server"
+)
+
+// HTTPServer returns the http server bound to the provided port.
+// If no http server is listening on this port, a new one is created with the provided name.
+func HTTPServer(runtime runtime.Runtime, name, host string, port int) (server.HTTPServer, error) {
+
+	if port > 0 {
+		// check if provided port is used by grpc server
+		grpcServers := runtime.Server().GRPCList()
+		for _, srv := range grpcServers {
+			if srv.Info().Port == port {
+				return nil, fmt.Errorf("can not bind %s handlers to grpc server. please change %s port to different port", name, name)
+			}
+		}
+
+		// check if provided port is used on provided http server
+		httpServers := runtime.Server().HTTPList()
+		for _, srv := range httpServers {
+			if srv.Info().Port == port {
+				return srv, nil
+			}
+		}
+	}
+
+	return runtime.Server().HTTPNew(name, &server.HTTPServerOptions{
+		Host:      host,
+		Port:      port,
+		TLSConfig: nil,
+	}), nil
+}
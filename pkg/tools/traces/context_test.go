@@ -0,0 +1,102 @@
+package traces
+
+import (
+	"context"
+	"testing"
+)
+
+func TestParseTraceParent(t *testing.T) {
+	tests := []struct {
+		name    string
+		value   string
+		trace   string
+		span    string
+		sampled bool
+		err     bool
+	}{
+		{
+			"sampled",
+			"00-0af7651916cd43dd8448eb211c80319c-b7ad6b7169203331-01",
+			"0af7651916cd43dd8448eb211c80319c",
+			"b7ad6b7169203331",
+			true,
+			false,
+		},
+		{
+			"not sampled",
+			"00-0af7651916cd43dd8448eb211c80319c-b7ad6b7169203331-00",
+			"0af7651916cd43dd8448eb211c80319c",
+			"b7ad6b7169203331",
+			false,
+			false,
+		},
+		{
+			"future version with extra fields",
+			"01-0af7651916cd43dd8448eb211c80319c-b7ad6b7169203331-01-extra",
+			"0af7651916cd43dd8448eb211c80319c",
+			"b7ad6b7169203331",
+			true,
+			false,
+		},
+		{"invalid version", "ff-0af7651916cd43dd8448eb211c80319c-b7ad6b7169203331-01", "", "", false, true},
+		{"extra fields in version 00", "00-0af7651916cd43dd8448eb211c80319c-b7ad6b7169203331-01-extra", "", "", false, true},
+		{"zero trace id", "00-00000000000000000000000000000000-b7ad6b7169203331-01", "", "", false, true},
+		{"zero span id", "00-0af7651916cd43dd8448eb211c80319c-0000000000000000-01", "", "", false, true},
+		{"not hex", "00-0af7651916cd43dd8448eb211c80319z-b7ad6b7169203331-01", "", "", false, true},
+		{"short", "00-0af7651916cd43dd-b7ad6b7169203331-01", "", "", false, true},
+		{"empty", "", "", "", false, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sc, err := ParseTraceParent(tt.value)
+			if (err != nil) != tt.err {
+				t.Fatal("error: expected", tt.err, "received", err)
+			}
+			if tt.err {
+				return
+			}
+			if sc.TraceID.String() != tt.trace {
+				t.Error("trace id: expected", tt.trace, "received", sc.TraceID)
+			}
+			if sc.SpanID.String() != tt.span {
+				t.Error("span id: expected", tt.span, "received", sc.SpanID)
+			}
+			if sc.Sampled != tt.sampled {
+				t.Error("sampled: expected", tt.sampled, "received", sc.Sampled)
+			}
+			if !sc.Remote {
+				t.Error("remote: expected", true, "received", sc.Remote)
+			}
+			if tt.value[:2] == traceParentVersion && sc.TraceParent() != tt.value {
+				t.Error("traceparent: expected", tt.value, "received", sc.TraceParent())
+			}
+		})
+	}
+}
+
+func TestSpanContextFromContext(t *testing.T) {
+	remote := SpanContext{TraceID: TraceID{1}, SpanID: SpanID{1}, Remote: true}
+
+	tests := []struct {
+		name string
+		ctx  context.Context
+		ok   bool
+		sc   SpanContext
+	}{
+		{"empty context", context.Background(), false, SpanContext{}},
+		{"remote span context", ContextWithRemoteSpanContext(context.Background(), remote), true, remote},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sc, ok := SpanContextFromContext(tt.ctx)
+			if ok != tt.ok {
+				t.Fatal("found: expected", tt.ok, "received", ok)
+			}
+			if sc != tt.sc {
+				t.Error("span context: expected", tt.sc, "received", sc)
+			}
+		})
+	}
+}
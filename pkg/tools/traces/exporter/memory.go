@@ -0,0 +1,45 @@
+package exporter
+
+import (
+	"context"
+	"sync"
+
+	"github.com/lastbackend/toolkit/pkg/tools/traces"
+)
+
+// Memory stores exported spans in memory, useful for tests
+type Memory struct {
+	mtx   sync.RWMutex
+	spans []traces.SpanData
+}
+
+func NewMemoryExporter() *Memory {
+	return &Memory{spans: make([]traces.SpanData, 0)}
+}
+
+func (e *Memory) Export(_ context.Context, spans []traces.SpanData) error {
+	e.mtx.Lock()
+	defer e.mtx.Unlock()
+	e.spans = append(e.spans, spans...)
+	return nil
+}
+
+func (e *Memory) Shutdown(_ context.Context) error {
+	return nil
+}
+
+// Spans - get copy of all exported spans
+func (e *Memory) Spans() []traces.SpanData {
+	e.mtx.RLock()
+	defer e.mtx.RUnlock()
+	spans := make([]traces.SpanData, len(e.spans))
+	copy(spans, e.spans)
+	return spans
+}
+
+// Reset - drop all exported spans
+func (e *Memory) Reset() {
+	e.mtx.Lock()
+	defer e.mtx.Unlock()
+	e.spans = make([]traces.SpanData, 0)
+}
@@ -0,0 +1,57 @@
+package exporter
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"os"
+	"sync"
+
+	"github.com/lastbackend/toolkit/pkg/tools/traces"
+)
+
+// Writer exports spans as JSON lines to the provided writer
+type Writer struct {
+	mtx    sync.Mutex
+	w      io.Writer
+	closer io.Closer
+}
+
+func NewWriterExporter(w io.Writer) traces.Exporter {
+	return &Writer{w: w}
+}
+
+func NewStdoutExporter() traces.Exporter {
+	return NewWriterExporter(os.Stdout)
+}
+
+func NewFileExporter(path string) (traces.Exporter, error) {
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return nil, err
+	}
+	return &Writer{w: f, closer: f}, nil
+}
+
+func (e *Writer) Export(_ context.Context, spans []traces.SpanData) error {
+	e.mtx.Lock()
+	defer e.mtx.Unlock()
+
+	encoder := json.NewEncoder(e.w)
+	for _, span := range spans {
+		if err := encoder.Encode(span); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (e *Writer) Shutdown(_ context.Context) error {
+	e.mtx.Lock()
+	defer e.mtx.Unlock()
+
+	if e.closer != nil {
+		return e.closer.Close()
+	}
+	return nil
+}
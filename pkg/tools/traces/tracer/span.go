@@ -0,0 +1,137 @@
+package tracer
+
+import (
+	"sync"
+	"time"
+
+	"github.com/lastbackend/toolkit/pkg/tools/traces"
+)
+
+type span struct {
+	mtx    sync.Mutex
+	tracer *tracer
+
+	name   string
+	kind   traces.SpanKind
+	sc     traces.SpanContext
+	parent traces.SpanID
+	remote bool
+
+	start time.Time
+	end   time.Time
+
+	attributes  map[string]interface{}
+	status      traces.StatusCode
+	description string
+	ended       bool
+}
+
+func newSpan(t *tracer, name string, kind traces.SpanKind) *span {
+	return &span{
+		tracer:     t,
+		name:       name,
+		kind:       kind,
+		start:      time.Now(),
+		attributes: make(map[string]interface{}, 0),
+	}
+}
+
+func (s *span) SpanContext() traces.SpanContext {
+	return s.sc
+}
+
+func (s *span) IsRecording() bool {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	return s.sc.Sampled && !s.ended
+}
+
+func (s *span) SetAttribute(key string, value interface{}) {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	if s.ended {
+		return
+	}
+	s.attributes[key] = value
+}
+
+func (s *span) SetStatus(code traces.StatusCode, description string) {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	if s.ended {
+		return
+	}
+	s.status = code
+	if code == traces.StatusError {
+		s.description = description
+	}
+}
+
+func (s *span) RecordError(err error) {
+	if err == nil {
+		return
+	}
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	if s.ended {
+		return
+	}
+	s.attributes["error"] = err.Error()
+}
+
+func (s *span) End() {
+	s.mtx.Lock()
+	if s.ended {
+		s.mtx.Unlock()
+		return
+	}
+	s.ended = true
+	s.end = time.Now()
+
+	if !s.sc.Sampled {
+		s.mtx.Unlock()
+		return
+	}
+
+	data := traces.SpanData{
+		Name:        s.name,
+		Kind:        s.kind.String(),
+		TraceID:     s.sc.TraceID.String(),
+		SpanID:      s.sc.SpanID.String(),
+		Remote:      s.remote,
+		Start:       s.start,
+		End:         s.end,
+		Attributes:  make(map[string]interface{}, len(s.attributes)),
+		Status:      s.status.String(),
+		Description: s.description,
+	}
+
+	if s.parent.IsValid() {
+		data.ParentSpanID = s.parent.String()
+	}
+
+	for k, v := range s.attributes {
+		data.Attributes[k] = v
+	}
+	s.mtx.Unlock()
+
+	s.tracer.export(data)
+}
+
+type noopSpan struct{}
+
+func (noopSpan) SpanContext() traces.SpanContext {
+	return traces.SpanContext{}
+}
+
+func (noopSpan) IsRecording() bool {
+	return false
+}
+
+func (noopSpan) SetAttribute(string, interface{}) {}
+
+func (noopSpan) SetStatus(traces.StatusCode, string) {}
+
+func (noopSpan) RecordError(error) {}
+
+func (noopSpan) End() {}
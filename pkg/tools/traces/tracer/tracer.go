@@ -0,0 +1,322 @@
+package tracer
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/binary"
+	"fmt"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/lastbackend/toolkit/pkg/runtime"
+	"github.com/lastbackend/toolkit/pkg/tools/traces"
+	"github.com/lastbackend/toolkit/pkg/tools/traces/exporter"
+)
+
+const prefix = "traces"
+
+const defaultFlushInterval = 5 * time.Second
+
+const (
+	ExporterStdout = "stdout"
+	ExporterFile   = "file"
+	ExporterNone   = "none"
+)
+
+type Options struct {
+	Enabled      bool    `env:"ENABLED" envDefault:"false" comment:"Enable or disable traces"`
+	Exporter     string  `env:"EXPORTER" envDefault:"stdout" comment:"Set traces exporter [stdout, file, none]"`
+	ExporterFile string  `env:"EXPORTER_FILE" comment:"Set file path for file traces exporter"`
+	SamplerRatio float64 `env:"SAMPLER_RATIO" envDefault:"1" comment:"Set ratio of sampled root traces (0..1)"`
+
+	QueueSize     int           `env:"QUEUE_SIZE" envDefault:"2048" comment:"Set max number of finished spans waiting for export, spans are dropped when the queue is full"`
+	BatchSize     int           `env:"BATCH_SIZE" envDefault:"512" comment:"Set max number of spans passed to exporters at once"`
+	FlushInterval time.Duration `env:"FLUSH_INTERVAL" envDefault:"5s" comment:"Set interval of exporting queued spans"`
+}
+
+type tracer struct {
+	mtx     sync.RWMutex
+	runtime runtime.Runtime
+
+	opts      Options
+	exporters []traces.Exporter
+
+	// finished spans are queued by End() and exported in batches by the background loop
+	queue    chan traces.SpanData
+	done     chan struct{}
+	stopped  chan struct{}
+	running  bool
+	stopOnce sync.Once
+}
+
+func NewTracer(runtime runtime.Runtime) (traces.Traces, error) {
+	t := new(tracer)
+
+	t.runtime = runtime
+	t.opts = Options{}
+	t.exporters = make([]traces.Exporter, 0)
+
+	if err := runtime.Config().Parse(&t.opts, prefix); err != nil {
+		return t, err
+	}
+
+	t.init()
+
+	if !t.opts.Enabled {
+		return t, nil
+	}
+
+	switch t.opts.Exporter {
+	case ExporterStdout:
+		t.exporters = append(t.exporters, exporter.NewStdoutExporter())
+	case ExporterFile:
+		e, err := exporter.NewFileExporter(t.opts.ExporterFile)
+		if err != nil {
+			return t, fmt.Errorf("can not create file traces exporter: %v", err)
+		}
+		t.exporters = append(t.exporters, e)
+	case ExporterNone, "":
+	default:
+		return t, fmt.Errorf("unsupported traces exporter: %s", t.opts.Exporter)
+	}
+
+	return t, nil
+}
+
+func (t *tracer) init() {
+	if t.opts.QueueSize <= 0 {
+		t.opts.QueueSize = 1
+	}
+	if t.opts.BatchSize <= 0 || t.opts.BatchSize > t.opts.QueueSize {
+		t.opts.BatchSize = t.opts.QueueSize
+	}
+	if t.opts.FlushInterval <= 0 {
+		t.opts.FlushInterval = defaultFlushInterval
+	}
+	t.queue = make(chan traces.SpanData, t.opts.QueueSize)
+	t.done = make(chan struct{})
+	t.stopped = make(chan struct{})
+}
+
+func (t *tracer) Start(_ context.Context) error {
+	if !t.opts.Enabled {
+		return nil
+	}
+
+	t.mtx.Lock()
+	defer t.mtx.Unlock()
+
+	if t.running {
+		return nil
+	}
+	t.running = true
+
+	go t.loop()
+
+	return nil
+}
+
+// Shutdown - stop the export loop, flush queued spans and shutdown exporters
+func (t *tracer) Shutdown(ctx context.Context) error {
+
+	t.stopOnce.Do(func() {
+		close(t.done)
+	})
+
+	t.mtx.RLock()
+	running := t.running
+	t.mtx.RUnlock()
+
+	if running {
+		select {
+		case <-t.stopped:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	} else {
+		t.drain(make([]traces.SpanData, 0, t.opts.BatchSize))
+	}
+
+	t.mtx.RLock()
+	defer t.mtx.RUnlock()
+
+	for _, e := range t.exporters {
+		if err := e.Shutdown(ctx); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (t *tracer) Enabled() bool {
+	return t.opts.Enabled
+}
+
+func (t *tracer) RegisterExporter(e traces.Exporter) {
+	t.mtx.Lock()
+	defer t.mtx.Unlock()
+	t.exporters = append(t.exporters, e)
+}
+
+func (t *tracer) StartSpan(ctx context.Context, name string, kind traces.SpanKind) (context.Context, traces.Span) {
+
+	if !t.opts.Enabled {
+		return ctx, noopSpan{}
+	}
+
+	s := newSpan(t, name, kind)
+
+	if parent, ok := traces.SpanContextFromContext(ctx); ok && parent.IsValid() {
+		s.sc.TraceID = parent.TraceID
+		s.sc.Sampled = parent.Sampled
+		s.parent = parent.SpanID
+		s.remote = parent.Remote
+	} else {
+		s.sc.TraceID = newTraceID()
+		s.sc.Sampled = t.sample(s.sc.TraceID)
+	}
+
+	s.sc.SpanID = newSpanID()
+
+	return traces.ContextWithSpan(ctx, s), s
+}
+
+func (t *tracer) Inject(ctx context.Context, carrier map[string]string) {
+	if !t.opts.Enabled || carrier == nil {
+		return
+	}
+
+	sc, ok := traces.SpanContextFromContext(ctx)
+	if !ok || !sc.IsValid() {
+		return
+	}
+
+	carrier[traces.TraceParentHeader] = sc.TraceParent()
+}
+
+func (t *tracer) Extract(ctx context.Context, carrier map[string]string) context.Context {
+	if !t.opts.Enabled || carrier == nil {
+		return ctx
+	}
+
+	value, ok := carrier[traces.TraceParentHeader]
+	if !ok {
+		return ctx
+	}
+
+	sc, err := traces.ParseTraceParent(value)
+	if err != nil {
+		t.runtime.Log().V(5).Warnf("[traces] can not extract span context: %v", err)
+		return ctx
+	}
+
+	return traces.ContextWithRemoteSpanContext(ctx, sc)
+}
+
+// export - queue finished span for the export loop, never blocks the caller
+func (t *tracer) export(data traces.SpanData) {
+	select {
+	case <-t.done:
+		return
+	default:
+	}
+
+	select {
+	case t.queue <- data:
+	default:
+		t.runtime.Log().V(5).Warnf("[traces] export queue is full, span %s dropped", data.Name)
+	}
+}
+
+// loop - export queued spans when the batch is full or on flush interval until shutdown
+func (t *tracer) loop() {
+	defer close(t.stopped)
+
+	ticker := time.NewTicker(t.opts.FlushInterval)
+	defer ticker.Stop()
+
+	batch := make([]traces.SpanData, 0, t.opts.BatchSize)
+
+	for {
+		select {
+		case data := <-t.queue:
+			batch = append(batch, data)
+			if len(batch) >= t.opts.BatchSize {
+				batch = t.flush(batch)
+			}
+		case <-ticker.C:
+			batch = t.flush(batch)
+		case <-t.done:
+			t.drain(batch)
+			return
+		}
+	}
+}
+
+// drain - export all spans left in the queue
+func (t *tracer) drain(batch []traces.SpanData) {
+	for {
+		select {
+		case data := <-t.queue:
+			batch = append(batch, data)
+			if len(batch) >= t.opts.BatchSize {
+				batch = t.flush(batch)
+			}
+		default:
+			t.flush(batch)
+			return
+		}
+	}
+}
+
+// flush - pass the batch to exporters and return emptied batch for reuse
+func (t *tracer) flush(batch []traces.SpanData) []traces.SpanData {
+	if len(batch) == 0 {
+		return batch
+	}
+
+	t.mtx.RLock()
+	exporters := make([]traces.Exporter, len(t.exporters))
+	copy(exporters, t.exporters)
+	t.mtx.RUnlock()
+
+	service := t.runtime.Meta().GetName()
+	spans := make([]traces.SpanData, len(batch))
+	for i := range batch {
+		spans[i] = batch[i]
+		spans[i].Service = service
+	}
+
+	for _, e := range exporters {
+		if err := e.Export(context.Background(), spans); err != nil {
+			t.runtime.Log().Errorf("[traces] can not export spans: %v", err)
+		}
+	}
+
+	return batch[:0]
+}
+
+// sample - decide if root trace is sampled based on trace id and configured ratio
+func (t *tracer) sample(id traces.TraceID) bool {
+	switch {
+	case t.opts.SamplerRatio >= 1:
+		return true
+	case t.opts.SamplerRatio <= 0:
+		return false
+	}
+	bound := uint64(t.opts.SamplerRatio * math.MaxUint64)
+	return binary.BigEndian.Uint64(id[8:]) < bound
+}
+
+func newTraceID() traces.TraceID {
+	var id traces.TraceID
+	_, _ = rand.Read(id[:])
+	return id
+}
+
+func newSpanID() traces.SpanID {
+	var id traces.SpanID
+	_, _ = rand.Read(id[:])
+	return id
+}
@@ -0,0 +1,288 @@
+package tracer
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/lastbackend/toolkit/pkg/runtime"
+	"github.com/lastbackend/toolkit/pkg/runtime/logger"
+	"github.com/lastbackend/toolkit/pkg/runtime/logger/empty"
+	"github.com/lastbackend/toolkit/pkg/runtime/meta"
+	"github.com/lastbackend/toolkit/pkg/tools/traces"
+	"github.com/lastbackend/toolkit/pkg/tools/traces/exporter"
+)
+
+type testRuntime struct {
+	runtime.Runtime
+}
+
+func (testRuntime) Meta() *meta.Meta {
+	return new(meta.Meta).SetName("test")
+}
+
+func (testRuntime) Log() logger.Logger {
+	return empty.NewLogger()
+}
+
+func newTestTracer(opts Options) (*tracer, *exporter.Memory) {
+	t := &tracer{runtime: testRuntime{}, opts: opts}
+	t.init()
+
+	e := exporter.NewMemoryExporter()
+	t.RegisterExporter(e)
+	return t, e
+}
+
+func TestTracer_StartSpan(t *testing.T) {
+	parent := traces.SpanContext{
+		TraceID: traces.TraceID{1},
+		SpanID:  traces.SpanID{2},
+		Sampled: true,
+		Remote:  true,
+	}
+
+	tests := []struct {
+		name    string
+		opts    Options
+		ctx     context.Context
+		sampled bool
+		trace   traces.TraceID
+		parent  string
+		remote  bool
+	}{
+		{
+			"disabled tracer returns noop span",
+			Options{Enabled: false},
+			context.Background(),
+			false,
+			traces.TraceID{},
+			"",
+			false,
+		},
+		{
+			"root span sampled",
+			Options{Enabled: true, SamplerRatio: 1},
+			context.Background(),
+			true,
+			traces.TraceID{},
+			"",
+			false,
+		},
+		{
+			"root span not sampled",
+			Options{Enabled: true, SamplerRatio: 0},
+			context.Background(),
+			false,
+			traces.TraceID{},
+			"",
+			false,
+		},
+		{
+			"child of remote span keeps trace and sampling decision",
+			Options{Enabled: true, SamplerRatio: 0},
+			traces.ContextWithRemoteSpanContext(context.Background(), parent),
+			true,
+			parent.TraceID,
+			parent.SpanID.String(),
+			true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tr, _ := newTestTracer(tt.opts)
+
+			ctx, sp := tr.StartSpan(tt.ctx, "op", traces.SpanKindServer)
+
+			if sp.IsRecording() != tt.sampled {
+				t.Error("recording: expected", tt.sampled, "received", sp.IsRecording())
+			}
+
+			if !tt.opts.Enabled {
+				if sp.SpanContext().IsValid() {
+					t.Error("span context: expected invalid, received", sp.SpanContext())
+				}
+				return
+			}
+
+			sc := sp.SpanContext()
+			if !sc.IsValid() {
+				t.Fatal("span context: expected valid")
+			}
+			if tt.trace.IsValid() && sc.TraceID != tt.trace {
+				t.Error("trace id: expected", tt.trace, "received", sc.TraceID)
+			}
+			if s, ok := traces.SpanFromContext(ctx); !ok || s != sp {
+				t.Error("context: expected span stored in context")
+			}
+
+			s := sp.(*span)
+			if s.remote != tt.remote {
+				t.Error("remote: expected", tt.remote, "received", s.remote)
+			}
+			if tt.parent != "" && s.parent.String() != tt.parent {
+				t.Error("parent: expected", tt.parent, "received", s.parent.String())
+			}
+		})
+	}
+}
+
+func TestTracer_Propagation(t *testing.T) {
+	tests := []struct {
+		name    string
+		carrier map[string]string
+		valid   bool
+	}{
+		{
+			"valid traceparent",
+			map[string]string{traces.TraceParentHeader: "00-0af7651916cd43dd8448eb211c80319c-b7ad6b7169203331-01"},
+			true,
+		},
+		{
+			"missing traceparent",
+			map[string]string{},
+			false,
+		},
+		{
+			"malformed traceparent",
+			map[string]string{traces.TraceParentHeader: "00-zz-b7ad6b7169203331-01"},
+			false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server, _ := newTestTracer(Options{Enabled: true, SamplerRatio: 1})
+
+			ctx := server.Extract(context.Background(), tt.carrier)
+			_, ok := traces.SpanContextFromContext(ctx)
+			if ok != tt.valid {
+				t.Fatal("extract: expected", tt.valid, "received", ok)
+			}
+
+			ctx, span := server.StartSpan(ctx, "op", traces.SpanKindServer)
+			defer span.End()
+
+			carrier := make(map[string]string)
+			server.Inject(ctx, carrier)
+
+			sc, err := traces.ParseTraceParent(carrier[traces.TraceParentHeader])
+			if err != nil {
+				t.Fatal("inject: unexpected error", err)
+			}
+			if sc.SpanID != span.SpanContext().SpanID {
+				t.Error("inject span id: expected", span.SpanContext().SpanID, "received", sc.SpanID)
+			}
+			if tt.valid && sc.TraceID.String() != "0af7651916cd43dd8448eb211c80319c" {
+				t.Error("inject trace id: expected remote trace id, received", sc.TraceID)
+			}
+		})
+	}
+}
+
+func TestTracer_Export(t *testing.T) {
+	tests := []struct {
+		name   string
+		opts   Options
+		spans  int
+		start  bool
+		export int
+	}{
+		{
+			"spans exported on shutdown without start",
+			Options{Enabled: true, SamplerRatio: 1, QueueSize: 16, BatchSize: 4, FlushInterval: time.Hour},
+			10,
+			false,
+			10,
+		},
+		{
+			"spans exported by background loop",
+			Options{Enabled: true, SamplerRatio: 1, QueueSize: 16, BatchSize: 4, FlushInterval: time.Hour},
+			10,
+			true,
+			10,
+		},
+		{
+			"spans over queue size are dropped",
+			Options{Enabled: true, SamplerRatio: 1, QueueSize: 4, BatchSize: 4, FlushInterval: time.Hour},
+			10,
+			false,
+			4,
+		},
+		{
+			"not sampled spans are not exported",
+			Options{Enabled: true, SamplerRatio: 0, QueueSize: 16, BatchSize: 4, FlushInterval: time.Hour},
+			10,
+			true,
+			0,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tr, e := newTestTracer(tt.opts)
+
+			if tt.start {
+				if err := tr.Start(context.Background()); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			for i := 0; i < tt.spans; i++ {
+				_, span := tr.StartSpan(context.Background(), "op", traces.SpanKindClient)
+				span.SetAttribute("i", i)
+				span.RecordError(errors.New("failed"))
+				span.SetStatus(traces.StatusError, "failed")
+				span.End()
+				span.End()
+			}
+
+			if err := tr.Shutdown(context.Background()); err != nil {
+				t.Fatal(err)
+			}
+
+			spans := e.Spans()
+			if len(spans) != tt.export {
+				t.Fatal("exported: expected", tt.export, "received", len(spans))
+			}
+			for _, s := range spans {
+				if s.Service != "test" {
+					t.Error("service: expected", "test", "received", s.Service)
+				}
+				if s.Kind != "client" || s.Status != "error" || s.Description != "failed" {
+					t.Error("span: unexpected data", s)
+				}
+				if s.Attributes["error"] != "failed" {
+					t.Error("attributes: expected error attribute, received", s.Attributes)
+				}
+			}
+
+			_, span := tr.StartSpan(context.Background(), "late", traces.SpanKindClient)
+			span.End()
+			if len(e.Spans()) != tt.export {
+				t.Error("exported after shutdown: expected", tt.export, "received", len(e.Spans()))
+			}
+		})
+	}
+}
+
+func TestTracer_FlushInterval(t *testing.T) {
+	tr, e := newTestTracer(Options{Enabled: true, SamplerRatio: 1, QueueSize: 16, BatchSize: 16, FlushInterval: 10 * time.Millisecond})
+	if err := tr.Start(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	defer tr.Shutdown(context.Background())
+
+	_, span := tr.StartSpan(context.Background(), "op", traces.SpanKindInternal)
+	span.End()
+
+	deadline := time.Now().Add(time.Second)
+	for len(e.Spans()) == 0 && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	if len(e.Spans()) != 1 {
+		t.Error("exported: expected", 1, "received", len(e.Spans()))
+	}
+}
@@ -1,4 +1,93 @@
 package traces
 
+import (
+	"context"
+	"time"
+)
+
+type SpanKind int
+
+const (
+	SpanKindInternal SpanKind = iota
+	SpanKindServer
+	SpanKindClient
+)
+
+func (k SpanKind) String() string {
+	switch k {
+	case SpanKindServer:
+		return "server"
+	case SpanKindClient:
+		return "client"
+	default:
+		return "internal"
+	}
+}
+
+type StatusCode int
+
+const (
+	StatusUnset StatusCode = iota
+	StatusOK
+	StatusError
+)
+
+func (c StatusCode) String() string {
+	switch c {
+	case StatusOK:
+		return "ok"
+	case StatusError:
+		return "error"
+	default:
+		return "unset"
+	}
+}
+
 type Traces interface {
+	Start(ctx context.Context) error
+	Shutdown(ctx context.Context) error
+
+	Enabled() bool
+	RegisterExporter(exporter Exporter)
+
+	// StartSpan - start a new span as a child of the span stored in ctx (or of the remote span context)
+	StartSpan(ctx context.Context, name string, kind SpanKind) (context.Context, Span)
+
+	// Inject - write the span context stored in ctx to the carrier as W3C traceparent header
+	Inject(ctx context.Context, carrier map[string]string)
+	// Extract - read W3C traceparent header from the carrier and store remote span context in ctx
+	Extract(ctx context.Context, carrier map[string]string) context.Context
+}
+
+type Span interface {
+	SpanContext() SpanContext
+	IsRecording() bool
+
+	SetAttribute(key string, value interface{})
+	SetStatus(code StatusCode, description string)
+	RecordError(err error)
+
+	End()
+}
+
+// Exporter sends finished spans to the storage backend
+type Exporter interface {
+	Export(ctx context.Context, spans []SpanData) error
+	Shutdown(ctx context.Context) error
+}
+
+// SpanData is a snapshot of the finished span passed to exporters
+type SpanData struct {
+	Name         string                 `json:"name"`
+	Kind         string                 `json:"kind"`
+	TraceID      string                 `json:"trace_id"`
+	SpanID       string                 `json:"span_id"`
+	ParentSpanID string                 `json:"parent_span_id,omitempty"`
+	Remote       bool                   `json:"remote,omitempty"`
+	Start        time.Time              `json:"start"`
+	End          time.Time              `json:"end"`
+	Attributes   map[string]interface{} `json:"attributes,omitempty"`
+	Status       string                 `json:"status"`
+	Description  string                 `json:"description,omitempty"`
+	Service      string                 `json:"service"`
 }
@@ -18,6 +18,7 @@ package backoff
 
 import (
 	"math"
+	"math/rand"
 	"sync/atomic"
 	"time"
 )
@@ -30,6 +31,8 @@ type Backoff struct {
 	Factor float64
 	// Min and Max are the minimum and maximum counter values.
 	Min, Max time.Duration
+	// Jitter randomizes duration between Min and calculated value
+	Jitter bool
 }
 
 func (b *Backoff) Duration() time.Duration {
@@ -58,7 +61,7 @@ func (b *Backoff) ForAttempt(attempt float64) time.Duration {
 	duration := float64(min) * math.Pow(factor, attempt)
 
 	if duration > maxInt64 {
-		return max
+		duration = float64(max)
 	}
 
 	d := time.Duration(duration)
@@ -66,7 +69,10 @@ func (b *Backoff) ForAttempt(attempt float64) time.Duration {
 		return min
 	}
 	if d > max {
-		return max
+		d = max
+	}
+	if b.Jitter {
+		d = time.Duration(rand.Float64()*float64(d-min)) + min
 	}
 	return d
 }
@@ -0,0 +1,197 @@
+/*
+Copyright [2014] - [2023] The Last.Backend authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package tls
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+)
+
+const (
+	defaultReloadInterval = time.Minute
+)
+
+type ReloaderOptions struct {
+	CertFile string
+	KeyFile  string
+	// CAFile is a bundle used to verify client certificates, requires ClientAuth
+	CAFile string
+	// ClientAuth requires and verifies client certificates (mTLS)
+	ClientAuth bool
+	// Interval is a minimal interval between files modification checks
+	Interval time.Duration
+	// OnError is called when rotated files can not be loaded, previous certificates are kept
+	OnError func(err error)
+}
+
+// Reloader - server TLS configuration loaded from files,
+// files are checked on handshakes and reloaded when they are modified
+type Reloader struct {
+	mtx  sync.RWMutex
+	opts ReloaderOptions
+
+	cert    *tls.Certificate
+	ca      *x509.CertPool
+	version string
+	checked time.Time
+}
+
+func NewReloader(opts ReloaderOptions) (*Reloader, error) {
+	if opts.CertFile == "" || opts.KeyFile == "" {
+		return nil, errors.New("tls certificate and key files are required")
+	}
+	if opts.ClientAuth && opts.CAFile == "" {
+		return nil, errors.New("tls ca file is required to verify client certificates")
+	}
+	if opts.CAFile != "" && !opts.ClientAuth {
+		return nil, errors.New("tls client auth must be enabled to verify client certificates with ca file")
+	}
+	if opts.Interval <= 0 {
+		opts.Interval = defaultReloadInterval
+	}
+
+	r := &Reloader{opts: opts}
+	if err := r.load(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// Config - get server TLS config with reloadable certificates
+func (r *Reloader) Config() *tls.Config {
+	cfg := &tls.Config{
+		MinVersion:     tls.VersionTLS12,
+		GetCertificate: r.getCertificate,
+	}
+	if r.opts.ClientAuth {
+		// client certificates are verified against reloadable CA pool
+		cfg.ClientAuth = tls.RequireAnyClientCert
+		cfg.VerifyPeerCertificate = r.verifyClient
+	}
+	return cfg
+}
+
+func (r *Reloader) getCertificate(_ *tls.ClientHelloInfo) (*tls.Certificate, error) {
+	r.reload()
+
+	r.mtx.RLock()
+	defer r.mtx.RUnlock()
+	return r.cert, nil
+}
+
+func (r *Reloader) verifyClient(raw [][]byte, _ [][]*x509.Certificate) error {
+	if len(raw) == 0 {
+		return errors.New("tls: client certificate is required")
+	}
+
+	certs := make([]*x509.Certificate, 0, len(raw))
+	for _, b := range raw {
+		cert, err := x509.ParseCertificate(b)
+		if err != nil {
+			return fmt.Errorf("tls: can not parse client certificate: %v", err)
+		}
+		certs = append(certs, cert)
+	}
+
+	r.mtx.RLock()
+	ca := r.ca
+	r.mtx.RUnlock()
+
+	opts := x509.VerifyOptions{
+		Roots:         ca,
+		Intermediates: x509.NewCertPool(),
+		KeyUsages:     []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}
+	for _, cert := range certs[1:] {
+		opts.Intermediates.AddCert(cert)
+	}
+
+	_, err := certs[0].Verify(opts)
+	return err
+}
+
+// reload - load files again if they were modified since the last load
+func (r *Reloader) reload() {
+	r.mtx.RLock()
+	skip := time.Since(r.checked) < r.opts.Interval
+	r.mtx.RUnlock()
+	if skip {
+		return
+	}
+
+	r.mtx.Lock()
+	r.checked = time.Now()
+	changed := r.fingerprint() != r.version
+	r.mtx.Unlock()
+
+	if !changed {
+		return
+	}
+
+	if err := r.load(); err != nil && r.opts.OnError != nil {
+		r.opts.OnError(err)
+	}
+}
+
+func (r *Reloader) load() error {
+	version := r.fingerprint()
+
+	cert, err := tls.LoadX509KeyPair(r.opts.CertFile, r.opts.KeyFile)
+	if err != nil {
+		return fmt.Errorf("can not load tls certificate: %v", err)
+	}
+
+	var ca *x509.CertPool
+	if r.opts.CAFile != "" {
+		data, err := os.ReadFile(r.opts.CAFile)
+		if err != nil {
+			return fmt.Errorf("can not read tls ca file: %v", err)
+		}
+		ca = x509.NewCertPool()
+		if !ca.AppendCertsFromPEM(data) {
+			return fmt.Errorf("can not parse tls ca file %s", r.opts.CAFile)
+		}
+	}
+
+	r.mtx.Lock()
+	r.cert = &cert
+	r.ca = ca
+	r.version = version
+	r.checked = time.Now()
+	r.mtx.Unlock()
+
+	return nil
+}
+
+// fingerprint - get files modification state
+func (r *Reloader) fingerprint() string {
+	var version string
+	for _, name := range []string{r.opts.CertFile, r.opts.KeyFile, r.opts.CAFile} {
+		if name == "" {
+			continue
+		}
+		if info, err := os.Stat(name); err == nil {
+			version += fmt.Sprintf("%s:%d:%d;", name, info.Size(), info.ModTime().UnixNano())
+		}
+	}
+	return version
+}
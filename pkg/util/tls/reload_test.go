@@ -0,0 +1,269 @@
+package tls
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/pem"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+const testHost = "localhost"
+
+func newTestCertificate(t *testing.T, host ...string) tls.Certificate {
+	t.Helper()
+
+	cert, err := Certificate(host...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return cert
+}
+
+// writeCertificate - write certificate and private key PEM files with the modification time
+func writeCertificate(t *testing.T, certFile, keyFile string, cert tls.Certificate, modified time.Time) {
+	t.Helper()
+
+	key, err := x509.MarshalPKCS8PrivateKey(cert.PrivateKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	files := map[string][]byte{
+		certFile: pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: cert.Certificate[0]}),
+		keyFile:  pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: key}),
+	}
+	for name, data := range files {
+		if err := os.WriteFile(name, data, 0600); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.Chtimes(name, modified, modified); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func certPool(t *testing.T, certs ...tls.Certificate) *x509.CertPool {
+	t.Helper()
+
+	pool := x509.NewCertPool()
+	for _, cert := range certs {
+		leaf, err := x509.ParseCertificate(cert.Certificate[0])
+		if err != nil {
+			t.Fatal(err)
+		}
+		pool.AddCert(leaf)
+	}
+	return pool
+}
+
+// handshake - run TLS handshake over loopback connection and get server certificate seen by client
+func handshake(t *testing.T, server *tls.Config, client *tls.Config) ([]byte, error) {
+	t.Helper()
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer listener.Close()
+
+	serverErr := make(chan error, 1)
+	go func() {
+		c, err := listener.Accept()
+		if err != nil {
+			serverErr <- err
+			return
+		}
+		defer c.Close()
+
+		conn := tls.Server(c, server)
+		if err := conn.Handshake(); err != nil {
+			serverErr <- err
+			return
+		}
+		// TLS 1.3 client certificate is verified after the client handshake is finished
+		_, err = conn.Write([]byte{1})
+		serverErr <- err
+	}()
+
+	c, err := net.Dial("tcp", listener.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+	_ = c.SetDeadline(time.Now().Add(5 * time.Second))
+
+	conn := tls.Client(c, client)
+	err = conn.Handshake()
+	if err == nil {
+		_, err = conn.Read(make([]byte, 1))
+	}
+
+	if err := <-serverErr; err != nil {
+		return nil, err
+	}
+	if err != nil {
+		return nil, err
+	}
+	return conn.ConnectionState().PeerCertificates[0].Raw, nil
+}
+
+func TestNewReloader(t *testing.T) {
+	dir := t.TempDir()
+
+	certFile := filepath.Join(dir, "tls.crt")
+	keyFile := filepath.Join(dir, "tls.key")
+	writeCertificate(t, certFile, keyFile, newTestCertificate(t, testHost), time.Now())
+
+	invalidFile := filepath.Join(dir, "invalid.crt")
+	if err := os.WriteFile(invalidFile, []byte("invalid"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name string
+		opts ReloaderOptions
+		err  bool
+	}{
+		{"valid certificate", ReloaderOptions{CertFile: certFile, KeyFile: keyFile}, false},
+		{"valid certificate with ca", ReloaderOptions{CertFile: certFile, KeyFile: keyFile, CAFile: certFile, ClientAuth: true}, false},
+		{"missing key file option", ReloaderOptions{CertFile: certFile}, true},
+		{"client auth without ca", ReloaderOptions{CertFile: certFile, KeyFile: keyFile, ClientAuth: true}, true},
+		{"missing certificate file", ReloaderOptions{CertFile: filepath.Join(dir, "missing.crt"), KeyFile: keyFile}, true},
+		{"invalid certificate file", ReloaderOptions{CertFile: invalidFile, KeyFile: keyFile}, true},
+		{"invalid ca file", ReloaderOptions{CertFile: certFile, KeyFile: keyFile, CAFile: invalidFile, ClientAuth: true}, true},
+		{"ca without client auth", ReloaderOptions{CertFile: certFile, KeyFile: keyFile, CAFile: certFile}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r, err := NewReloader(tt.opts)
+			if (err != nil) != tt.err {
+				t.Fatal("error: expected", tt.err, "received", err)
+			}
+			if (r == nil) != tt.err {
+				t.Error("reloader: expected", !tt.err, "received", r != nil)
+			}
+		})
+	}
+}
+
+func TestReloader_Reload(t *testing.T) {
+	tests := []struct {
+		name     string
+		interval time.Duration
+		rotate   bool
+		invalid  bool
+		reloaded bool
+		errors   int
+	}{
+		{"rotated certificate reloaded", time.Nanosecond, true, false, true, 0},
+		{"rotated certificate not checked before interval", time.Hour, true, false, false, 0},
+		{"unchanged certificate kept", time.Nanosecond, false, false, false, 0},
+		{"invalid rotated certificate keeps previous", time.Nanosecond, true, true, false, 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var (
+				dir      = t.TempDir()
+				certFile = filepath.Join(dir, "tls.crt")
+				keyFile  = filepath.Join(dir, "tls.key")
+				first    = newTestCertificate(t, testHost)
+				second   = newTestCertificate(t, testHost)
+				errors   = 0
+			)
+
+			writeCertificate(t, certFile, keyFile, first, time.Now())
+
+			r, err := NewReloader(ReloaderOptions{
+				CertFile: certFile,
+				KeyFile:  keyFile,
+				Interval: tt.interval,
+				OnError:  func(error) { errors++ },
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			client := &tls.Config{ServerName: testHost, RootCAs: certPool(t, first, second)}
+
+			served, err := handshake(t, r.Config(), client)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(served) != string(first.Certificate[0]) {
+				t.Fatal("served certificate: expected", "first", "received", "other")
+			}
+
+			if tt.rotate {
+				writeCertificate(t, certFile, keyFile, second, time.Now().Add(time.Minute))
+			}
+			if tt.invalid {
+				if err := os.WriteFile(keyFile, []byte("invalid"), 0600); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			served, err = handshake(t, r.Config(), client)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if reloaded := string(served) == string(second.Certificate[0]); reloaded != tt.reloaded {
+				t.Error("reloaded: expected", tt.reloaded, "received", reloaded)
+			}
+			if errors != tt.errors {
+				t.Error("errors: expected", tt.errors, "received", errors)
+			}
+		})
+	}
+}
+
+func TestReloader_ClientAuth(t *testing.T) {
+	var (
+		dir        = t.TempDir()
+		certFile   = filepath.Join(dir, "tls.crt")
+		keyFile    = filepath.Join(dir, "tls.key")
+		caFile     = filepath.Join(dir, "ca.crt")
+		caKeyFile  = filepath.Join(dir, "ca.key")
+		serverCert = newTestCertificate(t, testHost)
+		clientCert = newTestCertificate(t, "client")
+		otherCert  = newTestCertificate(t, "other")
+	)
+
+	writeCertificate(t, certFile, keyFile, serverCert, time.Now())
+	writeCertificate(t, caFile, caKeyFile, clientCert, time.Now())
+
+	r, err := NewReloader(ReloaderOptions{CertFile: certFile, KeyFile: keyFile, CAFile: caFile, ClientAuth: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name  string
+		certs []tls.Certificate
+		err   bool
+	}{
+		{"trusted client certificate", []tls.Certificate{clientCert}, false},
+		{"untrusted client certificate", []tls.Certificate{otherCert}, true},
+		{"missing client certificate", nil, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := &tls.Config{
+				ServerName:   testHost,
+				RootCAs:      certPool(t, serverCert),
+				Certificates: tt.certs,
+			}
+
+			if _, err := handshake(t, r.Config(), client); (err != nil) != tt.err {
+				t.Error("error: expected", tt.err, "received", err)
+			}
+		})
+	}
+}
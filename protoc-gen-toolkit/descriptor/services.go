@@ -33,6 +33,7 @@ func (d *Descriptor) loadServices(file *File) error {
 			File:                   file,
 			ServiceDescriptorProto: service,
 			HTTPMiddlewares:        make([]string, 0),
+			GRPCInterceptors:       make([]string, 0),
 		}
 		for _, md := range service.GetMethod() {
 			method, err := d.newMethod(svc, md)
@@ -47,6 +48,7 @@ func (d *Descriptor) loadServices(file *File) error {
 			if eServer != nil {
 				ss := eServer.(*toolkit_annotattions.Server)
 				svc.HTTPMiddlewares = ss.Middlewares
+				svc.GRPCInterceptors = ss.Interceptors
 			}
 		}
 		if service.Options != nil && proto.HasExtension(service.Options, toolkit_annotattions.E_Runtime) {
@@ -87,6 +89,15 @@ func (d *Descriptor) newMethod(svc *Service, md *descriptorpb.MethodDescriptorPr
 		ResponseType:          responseType,
 	}
 
+	rOpts, err := getRouteOptions(method)
+	if err != nil {
+		return nil, err
+	}
+	if rOpts != nil {
+		method.Interceptors = rOpts.GetInterceptors()
+		method.ExcludeGlobalInterceptors = rOpts.GetExcludeGlobalInterceptors()
+	}
+
 	if method.Options != nil && proto.HasExtension(method.Options, options.E_Http) {
 		err = setBindingsToMethod(method)
 		if err != nil {
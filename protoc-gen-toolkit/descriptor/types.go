@@ -131,6 +131,7 @@ type Service struct {
 	Methods                 []*Method
 	Plugins                 map[string][]*Plugin
 	HTTPMiddlewares         []string
+	GRPCInterceptors        []string
 	UseGRPCServer           bool
 	UseHTTPServer           bool
 	UseWebsocketProxyServer bool
@@ -148,13 +149,15 @@ func (s *Service) FullyName() string {
 
 type Method struct {
 	*descriptorpb.MethodDescriptorProto
-	Service          *Service
-	RequestType      *Message
-	ResponseType     *Message
-	Name             string
-	IsWebsocket      bool
-	IsWebsocketProxy bool
-	Bindings         []*Binding
+	Service                   *Service
+	RequestType               *Message
+	ResponseType              *Message
+	Name                      string
+	IsWebsocket               bool
+	IsWebsocketProxy          bool
+	Bindings                  []*Binding
+	Interceptors              []string
+	ExcludeGlobalInterceptors []string
 }
 
 func (m *Method) FullyName() string {
@@ -134,12 +134,14 @@ func (g *generator) generateService(file *descriptor.File) ([]byte, error) {
 		"client github.com/lastbackend/toolkit/pkg/client",
 		"runtime github.com/lastbackend/toolkit/pkg/runtime",
 		"controller github.com/lastbackend/toolkit/pkg/runtime/controller",
+		"tk_grpc github.com/lastbackend/toolkit/pkg/server/grpc",
 		"tk_http github.com/lastbackend/toolkit/pkg/server/http",
 		"tk_ws github.com/lastbackend/toolkit/pkg/server/http/websockets",
 		"toolkit github.com/lastbackend/toolkit",
 		"errors github.com/lastbackend/toolkit/pkg/server/http/errors",
 		"google.golang.org/protobuf/types/known/emptypb",
 		"empty github.com/golang/protobuf/ptypes/empty",
+		"fx go.uber.org/fx",
 	})
 
 	// checkers for conflicts and duplicates
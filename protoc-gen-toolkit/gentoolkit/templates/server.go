@@ -56,8 +56,19 @@ type {{ .GetName | ToLower }}GrpcRpcServer struct {
 {{ end }}
 func ({{ $.GetName | ToLower }}GrpcRpcServer) mustEmbedUnimplemented{{ $.GetName }}Server() {}
 
-func register{{ $.GetName }}GRPCServer(runtime runtime.Runtime, srv {{ $.GetName }}RpcServer) error {
-	runtime.Server().GRPC().RegisterService(&{{ $.GetName | ToLower }}GrpcRpcServer{srv})
+type {{ $.GetName | ToLower }}GrpcRpcServerParams struct {
+	fx.In
+
+	Runtime runtime.Runtime
+	Server  {{ $.GetName }}RpcServer ` + "`" + `optional:"true"` + "`" + `
+}
+
+func register{{ $.GetName }}GRPCServer(params {{ $.GetName | ToLower }}GrpcRpcServerParams) error {
+	// skip services of the file without provided implementation
+	if params.Server == nil {
+		return nil
+	}
+	params.Runtime.Server().GRPC().RegisterService(&{{ $.GetName | ToLower }}GrpcRpcServer{params.Server})
 	return nil
 }
 `
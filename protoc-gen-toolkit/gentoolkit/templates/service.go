@@ -31,7 +31,9 @@ var (
 	_ json.Marshaler
 	_ tk_ws.Client
 	_ tk_http.Handler
+	_ tk_grpc.Config
 	_ client.GRPCClient
+	_ fx.Option
 )
 
 // Definitions
@@ -95,9 +97,23 @@ func New{{ $svc.GetName }}Service(name string, opts ...runtime.Option) (_ toolki
 	app.runtime.Server().GRPCNew(name, nil)
 {{ end }}
 {{ if and $svc.UseGRPCServer }}
-  // set descriptor to {{ $svc.GetName }} GRPC server
-	app.runtime.Server().GRPC().SetDescriptor({{ $svc.GetName }}_ServiceDesc)
-	app.runtime.Server().GRPC().SetConstructor(register{{ $svc.GetName }}GRPCServer)
+	{{- range $s := $.Services }}
+	{{- if $s.UseGRPCServer }}
+  // set {{ $s.GetName }} descriptor to {{ $svc.GetName }} GRPC server
+	app.runtime.Server().GRPC().SetDescriptor({{ $s.GetName }}_ServiceDesc)
+	app.runtime.Server().GRPC().SetConstructor(register{{ $s.GetName }}GRPCServer)
+	{{- if $s.GRPCInterceptors }}
+	app.runtime.Server().GRPC().UseInterceptor({{ range $index, $icp := $s.GRPCInterceptors }}{{ if lt 0 $index }}, {{ end }}"{{ $icp }}"{{ end }})
+	{{- end }}
+	{{- range $m := $s.Methods }}
+	{{- if or $m.Interceptors $m.ExcludeGlobalInterceptors }}
+	app.runtime.Server().GRPC().SetMethodOptions("/{{ $s.FullyName }}/{{ $m.GetName }}"
+		{{- range $icp := $m.Interceptors }}, tk_grpc.WithInterceptor("{{ $icp }}"){{ end }}
+		{{- range $icp := $m.ExcludeGlobalInterceptors }}, tk_grpc.WithExcludeGlobalInterceptor("{{ $icp }}"){{ end }})
+	{{- end }}
+	{{- end }}
+	{{- end }}
+	{{- end }}
 {{ end }}
 
 {{ if $svc.UseHTTPServer }}
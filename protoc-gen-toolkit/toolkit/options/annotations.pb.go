@@ -321,6 +321,7 @@ func (x *MockeryTestsSpec) GetPackage() string {
 type Server struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
 	Middlewares   []string               `protobuf:"bytes,1,rep,name=middlewares,proto3" json:"middlewares,omitempty"`
+	Interceptors  []string               `protobuf:"bytes,2,rep,name=interceptors,proto3" json:"interceptors,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
@@ -362,10 +363,19 @@ func (x *Server) GetMiddlewares() []string {
 	return nil
 }
 
+func (x *Server) GetInterceptors() []string {
+	if x != nil {
+		return x.Interceptors
+	}
+	return nil
+}
+
 type Route struct {
-	state                    protoimpl.MessageState `protogen:"open.v1"`
-	Middlewares              []string               `protobuf:"bytes,1,rep,name=middlewares,proto3" json:"middlewares,omitempty"`
-	ExcludeGlobalMiddlewares []string               `protobuf:"bytes,2,rep,name=exclude_global_middlewares,json=excludeGlobalMiddlewares,proto3" json:"exclude_global_middlewares,omitempty"`
+	state                     protoimpl.MessageState `protogen:"open.v1"`
+	Middlewares               []string               `protobuf:"bytes,1,rep,name=middlewares,proto3" json:"middlewares,omitempty"`
+	ExcludeGlobalMiddlewares  []string               `protobuf:"bytes,2,rep,name=exclude_global_middlewares,json=excludeGlobalMiddlewares,proto3" json:"exclude_global_middlewares,omitempty"`
+	Interceptors              []string               `protobuf:"bytes,6,rep,name=interceptors,proto3" json:"interceptors,omitempty"`
+	ExcludeGlobalInterceptors []string               `protobuf:"bytes,7,rep,name=exclude_global_interceptors,json=excludeGlobalInterceptors,proto3" json:"exclude_global_interceptors,omitempty"`
 	// Types that are valid to be assigned to Server:
 	//
 	//	*Route_HttpProxy
@@ -420,6 +430,20 @@ func (x *Route) GetExcludeGlobalMiddlewares() []string {
 	return nil
 }
 
+func (x *Route) GetInterceptors() []string {
+	if x != nil {
+		return x.Interceptors
+	}
+	return nil
+}
+
+func (x *Route) GetExcludeGlobalInterceptors() []string {
+	if x != nil {
+		return x.ExcludeGlobalInterceptors
+	}
+	return nil
+}
+
 func (x *Route) GetServer() isRoute_Server {
 	if x != nil {
 		return x.Server
@@ -677,12 +701,15 @@ const file_protoc_gen_toolkit_toolkit_options_annotations_proto_rawDesc = "" +
 	"\bTestSpec\x123\n" +
 	"\amockery\x18\x01 \x01(\v2\x19.toolkit.MockeryTestsSpecR\amockery\",\n" +
 	"\x10MockeryTestsSpec\x12\x18\n" +
-	"\apackage\x18\x01 \x01(\tR\apackage\"*\n" +
+	"\apackage\x18\x01 \x01(\tR\apackage\"N\n" +
 	"\x06Server\x12 \n" +
-	"\vmiddlewares\x18\x01 \x03(\tR\vmiddlewares\"\x83\x02\n" +
+	"\vmiddlewares\x18\x01 \x03(\tR\vmiddlewares\x12\"\n" +
+	"\finterceptors\x18\x02 \x03(\tR\finterceptors\"\xe7\x02\n" +
 	"\x05Route\x12 \n" +
 	"\vmiddlewares\x18\x01 \x03(\tR\vmiddlewares\x12<\n" +
-	"\x1aexclude_global_middlewares\x18\x02 \x03(\tR\x18excludeGlobalMiddlewares\x123\n" +
+	"\x1aexclude_global_middlewares\x18\x02 \x03(\tR\x18excludeGlobalMiddlewares\x12\"\n" +
+	"\finterceptors\x18\x06 \x03(\tR\finterceptors\x12>\n" +
+	"\x1bexclude_global_interceptors\x18\a \x03(\tR\x19excludeGlobalInterceptors\x123\n" +
 	"\n" +
 	"http_proxy\x18\x03 \x01(\v2\x12.toolkit.HttpProxyH\x00R\thttpProxy\x12;\n" +
 	"\x0fwebsocket_proxy\x18\x04 \x01(\v2\x10.toolkit.WsProxyH\x00R\x0ewebsocketProxy\x12\x1e\n" +